@@ -7,6 +7,7 @@ import (
 	"github.com/proskenion/proskenion/core/model"
 	"github.com/proskenion/proskenion/proto"
 	"google.golang.org/grpc"
+	"time"
 )
 
 type APIClient struct {
@@ -31,6 +32,21 @@ func (c *APIClient) Write(in model.Transaction) error {
 	return err
 }
 
+func (c *APIClient) WriteAndWait(in model.Transaction, timeout time.Duration) (core.WriteResult, error) {
+	tx := in.(*convertor.Transaction).Transaction
+	res, err := c.APIClient.WriteWithOption(context.TODO(), &proskenion.WriteRequest{
+		Transaction:   tx,
+		WaitForCommit: true,
+		Timeout:       int64(timeout / time.Millisecond),
+	})
+	if err != nil {
+		return core.WriteResult{}, err
+	}
+	receipt := c.fc.NewEmptyReceipt()
+	receipt.(*convertor.Receipt).Receipt = res.GetReceipt()
+	return core.WriteResult{BlockHash: res.GetBlockHash(), Height: res.GetHeight(), Receipt: receipt}, nil
+}
+
 func (c *APIClient) Read(in model.Query) (model.QueryResponse, error) {
 	query := in.(*convertor.Query).Query
 	res, err := c.APIClient.Read(context.TODO(), query)
@@ -41,3 +57,27 @@ func (c *APIClient) Read(in model.Query) (model.QueryResponse, error) {
 	qres.(*convertor.QueryResponse).QueryResponse = res
 	return qres, nil
 }
+
+func (c *APIClient) ReadBatch(in []model.Query) (model.BatchQueryResponse, error) {
+	batch := &proskenion.BatchQuery{Queries: make([]*proskenion.Query, 0, len(in))}
+	for _, query := range in {
+		batch.Queries = append(batch.Queries, query.(*convertor.Query).Query)
+	}
+	res, err := c.APIClient.ReadBatch(context.TODO(), batch)
+	if err != nil {
+		return nil, err
+	}
+	bres := c.fc.NewEmptyBatchQueryResponse()
+	bres.(*convertor.BatchQueryResponse).BatchQueryResponse = res
+	return bres, nil
+}
+
+func (c *APIClient) GetReceipt(txHash model.Hash) (model.Receipt, error) {
+	res, err := c.APIClient.GetReceipt(context.TODO(), &proskenion.ReceiptRequest{TxHash: txHash})
+	if err != nil {
+		return nil, err
+	}
+	receipt := c.fc.NewEmptyReceipt()
+	receipt.(*convertor.Receipt).Receipt = res
+	return receipt, nil
+}
@@ -7,11 +7,12 @@ import (
 	"github.com/proskenion/proskenion/config"
 	"github.com/proskenion/proskenion/core"
 	"github.com/proskenion/proskenion/core/model"
+	"github.com/proskenion/proskenion/prosl"
 )
 
 type CommandExecutor struct {
 	factory model.ModelFactory
-	prosl   core.Prosl
+	cryptor core.Cryptor
 	conf    *config.Config
 }
 
@@ -19,9 +20,14 @@ func NewCommandExecutor(conf *config.Config) core.CommandExecutor {
 	return &CommandExecutor{conf: conf}
 }
 
-func (c *CommandExecutor) SetField(factory model.ModelFactory, prosl core.Prosl) {
+func (c *CommandExecutor) SetField(factory model.ModelFactory, cryptor core.Cryptor) {
 	c.factory = factory
-	c.prosl = prosl
+	c.cryptor = cryptor
+}
+
+// newProsl は呼び出し毎に新しい Prosl を返す。Executor は複数の goroutine から共有される
+func (c *CommandExecutor) newProsl() core.Prosl {
+	return prosl.NewProsl(c.factory, c.cryptor, c.conf)
 }
 
 func (c *CommandExecutor) TransferBalance(wsv model.ObjectFinder, cmd model.Command) error {
@@ -86,7 +92,7 @@ func (c *CommandExecutor) SetQuorum(wsv model.ObjectFinder, cmd model.Command) e
 	sq := cmd.GetSetQuorum()
 	ac := c.factory.NewEmptyAccount()
 	if err := wsv.Query(id, ac); err != nil {
-		return errors.Wrapf(core.ErrCommandExecutorAddBalanceNotExistAccount, err.Error())
+		return errors.Wrapf(core.ErrCommandExecutorSetQuorumNotExistAccount, err.Error())
 	}
 	newAc := c.factory.NewAccountBuilder().
 		From(ac).
@@ -145,6 +151,33 @@ func (c *CommandExecutor) AddPublicKeys(wsv model.ObjectFinder, cmd model.Comman
 	return nil
 }
 
+func (c *CommandExecutor) RemovePublicKeys(wsv model.ObjectFinder, cmd model.Command) error {
+	rp := cmd.GetRemovePublicKeys()
+	ac := c.factory.NewEmptyAccount()
+	id := model.MustAddress(model.MustAddress(cmd.GetTargetId()).AccountId())
+	if err := wsv.Query(id, ac); err != nil {
+		return errors.Wrapf(core.ErrCommandExecutorRemovePublicKeyNotExistAccount, err.Error())
+	}
+	keys := make([]model.PublicKey, 0, len(ac.GetPublicKeys()))
+	for _, key := range ac.GetPublicKeys() {
+		if !containsPublicKey(rp.GetPublicKeys(), key) {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys)+len(rp.GetPublicKeys()) != len(ac.GetPublicKeys()) {
+		return errors.Wrapf(core.ErrCommandExecutorRemovePublicKeyNotFoundPubkey,
+			"account keys : %x, remove keys : %x", ac.GetPublicKeys(), rp.GetPublicKeys())
+	}
+	newAc := c.factory.NewAccountBuilder().
+		From(ac).
+		PublicKeys(keys).
+		Build()
+	if err := wsv.Append(id, newAc); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (c *CommandExecutor) DefineStorage(wsv model.ObjectFinder, cmd model.Command) error {
 	ds := cmd.GetDefineStorage()
 	id := model.MustAddress(cmd.GetTargetId())
@@ -359,32 +392,105 @@ func (c *CommandExecutor) Consign(wsv model.ObjectFinder, cmd model.Command) err
 	return nil
 }
 
-func (c *CommandExecutor) CheckAndCommitProsl(wsv model.ObjectFinder, cmd model.Command) error {
-	cc := cmd.GetCheckAndCommitProsl()
-	targetId := model.MustAddress(cmd.GetTargetId())
+// upgradeActivated は activation_height と pending_prosl の検証が有効化されているかを返す
+func (c *CommandExecutor) upgradeActivated(wsv model.ObjectFinder) bool {
+	return core.Activated(wsv, c.factory.NewEmptyStorage(), core.ProslUpgradeFeature)
+}
+
+// activationHeight は params の activation_height を返す。無い場合は次の Block から有効になる。
+// 実行中の Block の中で切り替えることはできないので、次の Block より前の高さは指定できない。
+// 有効化前は activation_height を使わない
+func (c *CommandExecutor) activationHeight(wsv model.ObjectFinder, params map[string]model.Object) (int64, error) {
+	h, ok := params[core.ActivationHeightKey]
+	if !ok || !c.upgradeActivated(wsv) {
+		return 0, nil
+	}
+	if h.GetType() != model.Int64ObjectCode || h.GetI64() < 0 {
+		return 0, errors.Wrapf(core.ErrCommandExecutorCheckAndCommitProslInvalidHeight,
+			"%s must be non-negative int64, but %+v", core.ActivationHeightKey, h)
+	}
+	if f, ok := wsv.(model.ExecutingHeightFinder); ok {
+		if next := f.ExecutingHeight() + 1; h.GetI64() < next {
+			return 0, errors.Wrapf(core.ErrCommandExecutorCheckAndCommitProslInvalidHeight,
+				"%s must be at least the next block height %d, but %d", core.ActivationHeightKey, next, h.GetI64())
+		}
+	}
+	return h.GetI64(), nil
+}
+
+// replacePending は params の replace_pending が true かを返す
+func replacePending(params map[string]model.Object) bool {
+	r, ok := params[core.ReplacePendingKey]
+	return ok && r.GetBoolean()
+}
 
-	// 1. get update prosl
+// checkUpdateProsl は params を引数に update prosl を実行し true が返ることを検証する
+func (c *CommandExecutor) checkUpdateProsl(wsv model.ObjectFinder, params map[string]model.Object) error {
 	updateId := model.MustAddress(c.conf.Prosl.Update.Id)
 	updateSt := c.factory.NewEmptyStorage()
 	if err := wsv.Query(updateId, updateSt); err != nil {
 		return err
 	}
 	buf := updateSt.GetFromKey(core.ProslKey).GetData()
-	if err := c.prosl.Unmarshal(buf); err != nil {
+	pr := c.newProsl()
+	if err := pr.Unmarshal(buf); err != nil {
 		return err
 	}
-
-	// 2. update prosl execute with prams + ["target_id"] = target_id
-	params := cc.GetVariables()
-	params[core.TargetIdKey] = c.factory.NewObjectBuilder().Address(cmd.GetTargetId())
-	if check, variables, err := c.prosl.ExecuteWithParams(wsv, nil, params); err != nil {
+	if check, variables, err := pr.ExecuteWithParams(wsv, nil, params); err != nil {
 		return errors.Errorf("variales: %+v, error: %s", variables, err.Error())
 	} else if !check.GetBoolean() {
 		return errors.Wrapf(core.ErrCommandExecutorCheckAndCommitProslInvalid,
 			"variables: %+v", variables)
 	}
+	return nil
+}
+
+// reserveProsl は Repository が height の Block の先頭で destId の prosl を pr に切り替える様に予約する。
+// 有効化前は予約せずに直ちに切り替える
+func (c *CommandExecutor) reserveProsl(wsv model.ObjectFinder, destId model.Address, pr []byte, height int64, replace bool) error {
+	destSt := c.factory.NewEmptyStorage()
+	if err := wsv.Query(destId, destSt); err != nil {
+		return err
+	}
+	if !c.upgradeActivated(wsv) {
+		return wsv.Append(destId, c.factory.NewStorageBuilder().From(destSt).Data(core.ProslKey, pr).Build())
+	}
+	// 承認済みの更新は replace_pending を指定した時のみ置き換える
+	if len(destSt.GetFromKey(core.PendingProslKey).GetData()) > 0 && !replace {
+		return errors.Wrapf(core.ErrCommandExecutorCheckAndCommitProslPending,
+			"target: %s, activation_height: %d, set %s to replace it",
+			destId.Id(), destSt.GetFromKey(core.ActivationHeightKey).GetI64(), core.ReplacePendingKey)
+	}
+	txHash := model.Hash(nil)
+	if f, ok := wsv.(model.ExecutingTxFinder); ok {
+		txHash = f.ExecutingTxHash()
+	}
+	newSt := c.factory.NewStorageBuilder().
+		From(destSt).
+		Data(core.PendingProslKey, pr).
+		Int64(core.ActivationHeightKey, height).
+		Data(core.PendingTxHashKey, txHash).
+		Build()
+	return wsv.Append(destId, newSt)
+}
 
-	// 3. if true, targetId 's prosl setting to dest incentive or consensus or update
+func (c *CommandExecutor) CheckAndCommitProsl(wsv model.ObjectFinder, cmd model.Command) error {
+	cc := cmd.GetCheckAndCommitProsl()
+	targetId := model.MustAddress(cmd.GetTargetId())
+
+	params := cc.GetVariables()
+	height, err := c.activationHeight(wsv, params)
+	if err != nil {
+		return err
+	}
+
+	// 1. update prosl execute with prams + ["target_id"] = target_id
+	params[core.TargetIdKey] = c.factory.NewObjectBuilder().Address(cmd.GetTargetId())
+	if err := c.checkUpdateProsl(wsv, params); err != nil {
+		return err
+	}
+
+	// 2. if true, targetId 's prosl is reserved to dest incentive or consensus or update
 	proSt := c.factory.NewEmptyStorage()
 	if err := wsv.Query(targetId, proSt); err != nil {
 		return errors.Wrap(core.ErrCommandExecutorCheckAndCommitProslNotFound, err.Error())
@@ -401,12 +507,196 @@ func (c *CommandExecutor) CheckAndCommitProsl(wsv model.ObjectFinder, cmd model.
 	default:
 		return errors.Errorf("not found key %s, or unexpected value: %s", core.ProslKey, t)
 	}
-	if err := wsv.Append(destId, proSt); err != nil {
+	// 有効化前は targetId の Storage で直ちに置き換える
+	if !c.upgradeActivated(wsv) {
+		return wsv.Append(destId, proSt)
+	}
+	return c.reserveProsl(wsv, destId, proSt.GetFromKey(core.ProslKey).GetData(), height, replacePending(params))
+}
+
+func (c *CommandExecutor) RevertProsl(wsv model.ObjectFinder, cmd model.Command) error {
+	rp := cmd.GetRevertProsl()
+	switch cmd.GetTargetId() {
+	case c.conf.Prosl.Incentive.Id, c.conf.Prosl.Consensus.Id, c.conf.Prosl.Update.Id:
+	default:
+		return errors.Wrapf(core.ErrCommandExecutorRevertProslInvalidTarget, "target: %s", cmd.GetTargetId())
+	}
+
+	params := rp.GetVariables()
+	height, err := c.activationHeight(wsv, params)
+	if err != nil {
+		return err
+	}
+
+	// 1. find version from history
+	histSt := c.factory.NewEmptyStorage()
+	if err := wsv.Query(model.MustAddress(core.ProslHistoryId(cmd.GetTargetId())), histSt); err != nil {
+		return errors.Wrap(core.ErrCommandExecutorRevertProslNotFoundVersion, err.Error())
+	}
+	var pr []byte
+	for _, v := range histSt.GetFromKey(core.ProslVersionsKey).GetList() {
+		if v.GetDict()[core.ProslVersionKey].GetI64() == rp.GetVersion() {
+			pr = v.GetDict()[core.ProslKey].GetData()
+		}
+	}
+	if pr == nil {
+		return errors.Wrapf(core.ErrCommandExecutorRevertProslNotFoundVersion,
+			"target: %s, version: %d", cmd.GetTargetId(), rp.GetVersion())
+	}
+
+	// 2. update prosl execute with prams + ["target_id"] = target_id, ["version"] = version
+	params[core.TargetIdKey] = c.factory.NewObjectBuilder().Address(cmd.GetTargetId())
+	params[core.ProslVersionKey] = c.factory.NewObjectBuilder().Int64(rp.GetVersion())
+	if err := c.checkUpdateProsl(wsv, params); err != nil {
 		return err
 	}
+
+	// 3. if true, the version is reserved as new version
+	return c.reserveProsl(wsv, model.MustAddress(cmd.GetTargetId()), pr, height, replacePending(params))
+}
+
+// invokingWSV は InvokeProsl が返した Command の実行中であることを表す wsv。
+// ExecutingTxHash, ExecutingHeight と Emit は元の wsv に委譲する
+type invokingWSV struct {
+	model.ObjectFinder
+}
+
+func (w *invokingWSV) ExecutingTxHash() model.Hash {
+	if f, ok := w.ObjectFinder.(model.ExecutingTxFinder); ok {
+		return f.ExecutingTxHash()
+	}
 	return nil
 }
 
+func (w *invokingWSV) ExecutingHeight() int64 {
+	if f, ok := w.ObjectFinder.(model.ExecutingHeightFinder); ok {
+		return f.ExecutingHeight()
+	}
+	return 0
+}
+
+func (w *invokingWSV) Emit(event model.Event) {
+	if em, ok := w.ObjectFinder.(model.EventEmitter); ok {
+		em.Emit(event)
+	}
+}
+
+func (c *CommandExecutor) InvokeProsl(wsv model.ObjectFinder, cmd model.Command) error {
+	// prosl から InvokeProsl を再帰的に呼び出すことはできない
+	if _, ok := wsv.(*invokingWSV); ok {
+		return errors.Wrapf(core.ErrCommandExecutorInvokeProslNested, "target: %s", cmd.GetTargetId())
+	}
+
+	ip := cmd.GetInvokeProsl()
+	targetId := model.MustAddress(cmd.GetTargetId())
+	ownerId := targetId.Account() + "@" + targetId.Domain()
+
+	// 1. get target prosl
+	proSt := c.factory.NewEmptyStorage()
+	if err := wsv.Query(targetId, proSt); err != nil {
+		return errors.Wrap(core.ErrCommandExecutorInvokeProslNotFound, err.Error())
+	}
+	pr := c.newProsl()
+	if err := pr.Unmarshal(proSt.GetFromKey(core.ProslKey).GetData()); err != nil {
+		return errors.Wrap(core.ErrCommandExecutorInvokeProslNotFound, err.Error())
+	}
+
+	// 2. target prosl execute with params + ["target_id"], ["invoker_id"], ["owner_id"]
+	params := make(map[string]model.Object)
+	for k, v := range ip.GetVariables() {
+		params[k] = v
+	}
+	params[core.TargetIdKey] = c.factory.NewObjectBuilder().Address(cmd.GetTargetId())
+	params[core.InvokerIdKey] = c.factory.NewObjectBuilder().Address(cmd.GetAuthorizerId())
+	params[core.OwnerIdKey] = c.factory.NewObjectBuilder().Address(ownerId)
+	ret, variables, err := pr.ExecuteWithParams(wsv, nil, params)
+	if err != nil {
+		return errors.Wrapf(core.ErrCommandExecutorInvokeProslFailed,
+			"variables: %+v, error: %s", variables, err.Error())
+	}
+	if ret == nil || ret.GetType() != model.TransactionObjectCode {
+		return errors.Wrapf(core.ErrCommandExecutorInvokeProslUnexpectedReturn, "return: %+v", ret)
+	}
+
+	// 3. returned commands execute under the prosl owner's authority
+	iwsv := &invokingWSV{wsv}
+	for _, rc := range ret.GetTransaction().GetPayload().GetCommands() {
+		authorizerId, err := model.NewAddress(rc.GetAuthorizerId())
+		if err != nil || authorizerId.Account()+"@"+authorizerId.Domain() != ownerId {
+			return errors.Wrapf(core.ErrCommandExecutorInvokeProslInvalidAuthorizer,
+				"expected: %s, actual: %s", ownerId, rc.GetAuthorizerId())
+		}
+		if err := rc.Validate(iwsv); err != nil {
+			return err
+		}
+		if err := rc.Execute(iwsv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// permissionStorage は accountId の permission Storage の Address と現在の Storage を返す
+func (c *CommandExecutor) permissionStorage(wsv model.ObjectFinder, accountId string) (model.Address, model.Storage, error) {
+	acId := model.MustAddress(model.MustAddress(accountId).AccountId())
+	ac := c.factory.NewEmptyAccount()
+	if err := wsv.Query(acId, ac); err != nil {
+		return nil, nil, errors.Wrap(core.ErrCommandExecutorGrantPermissionNotFound, err.Error())
+	}
+	id := model.MustAddress(acId.Account() + "@" + acId.Domain() + "/" + core.PermissionStorageName)
+	st := c.factory.NewEmptyStorage()
+	if err := wsv.Query(id, st); err != nil {
+		st = c.factory.NewStorageBuilder().Id(id.Id()).Build()
+	}
+	return id, st, nil
+}
+
+func (c *CommandExecutor) GrantPermission(wsv model.ObjectFinder, cmd model.Command) error {
+	gp := cmd.GetGrantPermission()
+	id, st, err := c.permissionStorage(wsv, cmd.GetTargetId())
+	if err != nil {
+		return err
+	}
+	domains := st.GetFromKey(gp.GetPermission()).GetList()
+	for _, d := range domains {
+		if d.GetStr() == gp.GetDomain() {
+			return nil
+		}
+	}
+	domains = append(domains, c.factory.NewObjectBuilder().Str(gp.GetDomain()))
+	newSt := c.factory.NewStorageBuilder().
+		From(st).
+		List(gp.GetPermission(), domains).
+		Build()
+	return wsv.Append(id, newSt)
+}
+
+func (c *CommandExecutor) RevokePermission(wsv model.ObjectFinder, cmd model.Command) error {
+	rp := cmd.GetRevokePermission()
+	id, st, err := c.permissionStorage(wsv, cmd.GetTargetId())
+	if err != nil {
+		return err
+	}
+	domains := make([]model.Object, 0)
+	found := false
+	for _, d := range st.GetFromKey(rp.GetPermission()).GetList() {
+		if d.GetStr() == rp.GetDomain() {
+			found = true
+			continue
+		}
+		domains = append(domains, d)
+	}
+	if !found {
+		return errors.Wrapf(core.ErrCommandExecutorRevokePermissionNotGranted,
+			"target: %s, permission: %s, domain: %s", cmd.GetTargetId(), rp.GetPermission(), rp.GetDomain())
+	}
+	newSt := c.factory.NewStorageBuilder().
+		From(st).
+		List(rp.GetPermission(), domains).
+		Build()
+	return wsv.Append(id, newSt)
+}
+
 func (c *CommandExecutor) ForceUpdateStorage(wsv model.ObjectFinder, cmd model.Command) error {
 	fus := cmd.GetForceUpdateStorage()
 	st := fus.GetStorage()
@@ -7,6 +7,7 @@ import (
 	"github.com/proskenion/proskenion/core"
 	"github.com/proskenion/proskenion/core/model"
 	"github.com/proskenion/proskenion/proto"
+	"github.com/proskenion/proskenion/repository"
 	. "github.com/proskenion/proskenion/test_utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -39,6 +40,9 @@ func prePareCreateAccounts(t *testing.T, fc model.ModelFactory, rp core.Reposito
 		CreateAccount(authorizerId, "account3@com", []model.PublicKey{}, 0).
 		CreateAccount(authorizerId, "account4@com", []model.PublicKey{}, 0).
 		CreateAccount(authorizerId, "account5@com", []model.PublicKey{}, 0).
+		GrantPermission(authorizerId, authorizerId, core.AdminRole, core.AllDomain).
+		DefineStorage(authorizerId, core.ActivationStorageId, repository.ActivationStorage(fc)).
+		CreateStorage(authorizerId, core.ActivationId).
 		Build()
 	CommitTxWrapBlock(t, rp, fc, tx)
 }
@@ -456,6 +460,78 @@ func TestCommandExecutor_AddPublicKey(t *testing.T) {
 	require.NoError(t, dtx.Commit())
 }
 
+func TestCommandExecutor_RemovePublicKey(t *testing.T) {
+	fc, ex, rp := prePareCommandExecutor(t)
+	prePareCreateAccounts(t, fc, rp)
+
+	keys := []model.PublicKey{
+		RandomPublicKey(),
+		RandomPublicKey(),
+		RandomPublicKey(),
+	}
+	CommitTxWrapBlock(t, rp, fc, fc.NewTxBuilder().
+		AddPublicKeys(authorizerId, "account1@com", []model.PublicKey{keys[0]}).
+		AddPublicKeys(authorizerId, "account1@com", []model.PublicKey{keys[1]}).
+		AddPublicKeys(authorizerId, "account1@com", []model.PublicKey{keys[2]}).
+		Build())
+
+	dtx, wsv := prePareGetDtxWSV(t, rp)
+	for _, c := range []struct {
+		name     string
+		targetId string
+		keys     []model.PublicKey
+		exKeys   []model.PublicKey
+		err      error
+	}{
+		{
+			"case 1 : no error",
+			"account1@com",
+			[]model.PublicKey{keys[1]},
+			[]model.PublicKey{keys[0], keys[2]},
+			nil,
+		},
+		{
+			"case 2 : remove multiple keys",
+			"account1@com",
+			[]model.PublicKey{keys[0], keys[2]},
+			[]model.PublicKey{},
+			nil,
+		},
+		{
+			"case 3 : not found key",
+			"account1@com",
+			[]model.PublicKey{keys[0]},
+			nil,
+			core.ErrCommandExecutorRemovePublicKeyNotFoundPubkey,
+		},
+		{
+			"case 4 : no target account",
+			"unk@unk",
+			[]model.PublicKey{keys[0]},
+			nil,
+			core.ErrCommandExecutorRemovePublicKeyNotExistAccount,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			cmd := fc.NewTxBuilder().
+				RemovePublicKeys(authorizerId, c.targetId, c.keys).
+				Build().GetPayload().GetCommands()[0]
+			err := ex.RemovePublicKeys(wsv, cmd)
+			if c.err != nil {
+				assert.EqualError(t, errors.Cause(err), c.err.Error())
+			} else {
+				assert.NoError(t, err)
+
+				ac := fc.NewEmptyAccount()
+				err = wsv.Query(model.MustAddress(model.MustAddress(c.targetId).AccountId()), ac)
+				require.NoError(t, err)
+				assert.ElementsMatch(t, c.exKeys, ac.GetPublicKeys())
+			}
+		})
+	}
+	require.NoError(t, dtx.Commit())
+}
+
 func TestCommandExecutor_DefineStorage(t *testing.T) {
 	fc, ex, rp := prePareCommandExecutor(t)
 	prePareCreateAccounts(t, fc, rp)
@@ -1074,6 +1150,12 @@ func TestCommandExecutor_CheckAndCommitProsl(t *testing.T) {
 	CommitTxWrapBlock(t, rp, fc, tx)
 
 	dtx, wsv := prePareGetDtxWSV(t, rp)
+	// prosl_upgrade の有効化前は即座に切り替わる
+	activation := fc.NewStorageBuilder().
+		Id(core.ActivationId).
+		Set(core.ProslUpgradeFeature, fc.NewObjectBuilder().Bool(false)).
+		Build()
+	require.NoError(t, wsv.Append(model.MustAddress(core.ActivationId), activation))
 	for _, c := range []struct {
 		name         string
 		authorizerId string
@@ -1129,6 +1211,292 @@ func TestCommandExecutor_CheckAndCommitProsl(t *testing.T) {
 	require.NoError(t, dtx.Commit())
 }
 
+func TestCommandExecutor_CheckAndCommitProsl_UpgradeActivated(t *testing.T) {
+	fc, ex, rp := prePareCommandExecutor(t)
+	prePareCreateAccounts(t, fc, rp)
+	prePareAddPeer(t, fc, rp)
+	preParaProslSave(t, fc, rp, RandomConfig())
+	prePareForUpdate(t, fc, rp)
+
+	newCpr := ConvertYamlFileToProtoBinary(t, "../test_utils/new_consensus.yaml")
+	tx := fc.NewTxBuilder().
+		CreateStorage(authorizerId, "account1@incentive.com/prosl").
+		UpdateObject(authorizerId, "account1@incentive.com/prosl",
+			core.ProslKey, fc.NewObjectBuilder().Data(newCpr)).
+		UpdateObject(authorizerId, "account1@incentive.com/prosl",
+			core.ProslTypeKey, fc.NewObjectBuilder().Str(core.IncentiveKey)).
+		Build()
+	CommitTxWrapBlock(t, rp, fc, tx)
+
+	dtx, wsv := prePareGetDtxWSV(t, rp)
+	activation := fc.NewStorageBuilder().
+		Id(core.ActivationId).
+		Set(core.ProslUpgradeFeature, fc.NewObjectBuilder().Bool(true)).
+		Build()
+	require.NoError(t, wsv.Append(model.MustAddress(core.ActivationId), activation))
+	// height 5 の Block を実行中
+	wsv.SetExecutingHeight(5)
+
+	for _, c := range []struct {
+		name      string
+		params    map[string]model.Object
+		expHeight int64
+		err       error
+	}{
+		{
+			"case 1 : invalid activation height",
+			map[string]model.Object{
+				"account_id":             fc.NewObjectBuilder().Str("account1@com"),
+				core.ActivationHeightKey: fc.NewObjectBuilder().Int64(-1),
+			},
+			0,
+			core.ErrCommandExecutorCheckAndCommitProslInvalidHeight,
+		},
+		{
+			"case 2 : activation height is not after executing block",
+			map[string]model.Object{
+				"account_id":             fc.NewObjectBuilder().Str("account1@com"),
+				core.ActivationHeightKey: fc.NewObjectBuilder().Int64(5),
+			},
+			0,
+			core.ErrCommandExecutorCheckAndCommitProslInvalidHeight,
+		},
+		{
+			"case 3 : no error, activate at next block",
+			map[string]model.Object{
+				"account_id":             fc.NewObjectBuilder().Str("account1@com"),
+				core.ActivationHeightKey: fc.NewObjectBuilder().Int64(6),
+			},
+			6,
+			nil,
+		},
+		{
+			"case 4 : another prosl is pending",
+			map[string]model.Object{
+				"account_id":             fc.NewObjectBuilder().Str("account1@com"),
+				core.ActivationHeightKey: fc.NewObjectBuilder().Int64(10),
+			},
+			0,
+			core.ErrCommandExecutorCheckAndCommitProslPending,
+		},
+		{
+			"case 5 : no error, replace pending prosl",
+			map[string]model.Object{
+				"account_id":             fc.NewObjectBuilder().Str("account1@com"),
+				core.ActivationHeightKey: fc.NewObjectBuilder().Int64(10),
+				core.ReplacePendingKey:   fc.NewObjectBuilder().Bool(true),
+			},
+			10,
+			nil,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			cmd := fc.NewTxBuilder().
+				CheckAndCommitProsl(authorizerId, "account1@incentive.com/prosl", c.params).
+				Build().
+				GetPayload().
+				GetCommands()[0]
+			err := ex.CheckAndCommitProsl(wsv, cmd)
+			if c.err != nil {
+				assert.EqualErrorf(t, errors.Cause(err), c.err.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+
+				st := fc.NewEmptyStorage()
+				err = wsv.Query(model.MustAddress(RandomConfig().Prosl.Incentive.Id), st)
+				require.NoError(t, err)
+				assert.Equal(t, newCpr, st.GetFromKey(core.PendingProslKey).GetData())
+				assert.Equal(t, c.expHeight, st.GetFromKey(core.ActivationHeightKey).GetI64())
+			}
+		})
+	}
+	require.NoError(t, dtx.Commit())
+}
+
+func TestCommandExecutor_RevertProsl(t *testing.T) {
+	fc, ex, rp := prePareCommandExecutor(t)
+	prePareCreateAccounts(t, fc, rp)
+	prePareAddPeer(t, fc, rp)
+	conf := RandomConfig()
+	preParaProslSave(t, fc, rp, conf)
+	prePareForUpdate(t, fc, rp)
+
+	oldPr := ConvertYamlFileToProtoBinary(t, "../test_utils/new_consensus.yaml")
+	dtx, wsv := prePareGetDtxWSV(t, rp)
+	histId := model.MustAddress(core.ProslHistoryId(conf.Prosl.Incentive.Id))
+	histSt := fc.NewStorageBuilder().
+		Id(histId.Id()).
+		List(core.ProslVersionsKey, []model.Object{
+			fc.NewObjectBuilder().Dict(map[string]model.Object{
+				core.ProslVersionKey: fc.NewObjectBuilder().Int64(0),
+				core.ProslKey:        fc.NewObjectBuilder().Data(oldPr),
+			}),
+		}).Build()
+	require.NoError(t, wsv.Append(histId, histSt))
+
+	for _, c := range []struct {
+		name     string
+		targetId string
+		version  int64
+		params   map[string]model.Object
+		err      error
+	}{
+		{
+			"case 1 : invalid target",
+			"account1@com/prflag",
+			0,
+			map[string]model.Object{"account_id": fc.NewObjectBuilder().Str("account1@com")},
+			core.ErrCommandExecutorRevertProslInvalidTarget,
+		},
+		{
+			"case 2 : not found version",
+			conf.Prosl.Incentive.Id,
+			1,
+			map[string]model.Object{"account_id": fc.NewObjectBuilder().Str("account1@com")},
+			core.ErrCommandExecutorRevertProslNotFoundVersion,
+		},
+		{
+			"case 3 : not found history",
+			conf.Prosl.Consensus.Id,
+			0,
+			map[string]model.Object{"account_id": fc.NewObjectBuilder().Str("account1@com")},
+			core.ErrCommandExecutorRevertProslNotFoundVersion,
+		},
+		{
+			"case 4 : return false",
+			conf.Prosl.Incentive.Id,
+			0,
+			map[string]model.Object{"account_id": fc.NewObjectBuilder().Str("account2@com")},
+			core.ErrCommandExecutorCheckAndCommitProslInvalid,
+		},
+		{
+			"case 5 : no error",
+			conf.Prosl.Incentive.Id,
+			0,
+			map[string]model.Object{"account_id": fc.NewObjectBuilder().Str("account1@com")},
+			nil,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			cmd := fc.NewTxBuilder().
+				RevertProsl(authorizerId, c.targetId, c.version, c.params).
+				Build().
+				GetPayload().
+				GetCommands()[0]
+			err := ex.RevertProsl(wsv, cmd)
+			if c.err != nil {
+				assert.EqualErrorf(t, errors.Cause(err), c.err.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+
+				st := fc.NewEmptyStorage()
+				err = wsv.Query(model.MustAddress(c.targetId), st)
+				require.NoError(t, err)
+				assert.Equal(t, oldPr, st.GetFromKey(core.PendingProslKey).GetData())
+			}
+		})
+	}
+	require.NoError(t, dtx.Commit())
+}
+
+func TestCommandExecutor_InvokeProsl(t *testing.T) {
+	fc, ex, rp := prePareCommandExecutor(t)
+	prePareCreateAccounts(t, fc, rp)
+	prePareAddBalance(t, fc, rp)
+
+	contractPr := ConvertYamlFileToProtoBinary(t, "../test_utils/contract.yaml")
+	invalidPr := ConvertYamlFileToProtoBinary(t, "../test_utils/invalid_contract.yaml")
+	tx := fc.NewTxBuilder().
+		DefineStorage(authorizerId, "/contract", fc.NewStorageBuilder().Data(core.ProslKey, nil).Build()).
+		CreateStorage(authorizerId, "account1@com/contract").
+		CreateStorage(authorizerId, "account3@com/contract").
+		UpdateObject(authorizerId, "account1@com/contract",
+			core.ProslKey, fc.NewObjectBuilder().Data(contractPr)).
+		UpdateObject(authorizerId, "account3@com/contract",
+			core.ProslKey, fc.NewObjectBuilder().Data(invalidPr)).
+		Build()
+	CommitTxWrapBlock(t, rp, fc, tx)
+
+	dtx, wsv := prePareGetDtxWSV(t, rp)
+	for _, c := range []struct {
+		name         string
+		authorizerId string
+		walletId     string
+		params       map[string]model.Object
+		expOwner     int64
+		expInvoker   int64
+		err          error
+	}{
+		{
+			"case 1 : no error",
+			"account2@com",
+			"account1@com/contract",
+			map[string]model.Object{"amount": fc.NewObjectBuilder().Int64(10)},
+			90,
+			110,
+			nil,
+		},
+		{
+			"case 2 : not enough balance",
+			"account2@com",
+			"account1@com/contract",
+			map[string]model.Object{"amount": fc.NewObjectBuilder().Int64(1000)},
+			0,
+			0,
+			core.ErrCommandExecutorTransferBalanceNotEnoughSrcAccountBalance,
+		},
+		{
+			"case 3 : not found prosl",
+			"account2@com",
+			"account2@com/contract",
+			map[string]model.Object{"amount": fc.NewObjectBuilder().Int64(10)},
+			0,
+			0,
+			core.ErrCommandExecutorInvokeProslNotFound,
+		},
+		{
+			"case 4 : not prosl owner authority",
+			"account2@com",
+			"account3@com/contract",
+			map[string]model.Object{"amount": fc.NewObjectBuilder().Int64(10)},
+			0,
+			0,
+			core.ErrCommandExecutorInvokeProslInvalidAuthorizer,
+		},
+		{
+			"case 5 : undefined variable",
+			"account2@com",
+			"account1@com/contract",
+			make(map[string]model.Object),
+			0,
+			0,
+			core.ErrCommandExecutorInvokeProslFailed,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			cmd := fc.NewTxBuilder().
+				InvokeProsl(c.authorizerId, c.walletId, c.params).
+				Build().
+				GetPayload().
+				GetCommands()[0]
+			err := ex.InvokeProsl(wsv, cmd)
+			if c.err != nil {
+				assert.EqualErrorf(t, errors.Cause(err), c.err.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+
+				owner := fc.NewEmptyAccount()
+				require.NoError(t, wsv.Query(model.MustAddress("account1@com/account"), owner))
+				assert.Equal(t, c.expOwner, owner.GetBalance())
+				invoker := fc.NewEmptyAccount()
+				require.NoError(t, wsv.Query(model.MustAddress("account2@com/account"), invoker))
+				assert.Equal(t, c.expInvoker, invoker.GetBalance())
+			}
+		})
+	}
+	require.NoError(t, dtx.Commit())
+}
+
 func TestCommandExecutor_ForceUpdateStorage(t *testing.T) {
 	fc, ex, rp := prePareCommandExecutor(t)
 	prePareCreateAccounts(t, fc, rp)
@@ -1182,3 +1550,44 @@ func TestCommandExecutor_ForceUpdateStorage(t *testing.T) {
 	}
 	require.NoError(t, dtx.Commit())
 }
+
+func TestCommandExecutor_GrantAndRevokePermission(t *testing.T) {
+	fc, ex, rp := prePareCommandExecutor(t)
+	prePareCreateAccounts(t, fc, rp)
+
+	dtx, wsv := prePareGetDtxWSV(t, rp)
+	grant := func(accountId string, domain string) error {
+		return ex.GrantPermission(wsv, fc.NewTxBuilder().
+			GrantPermission(authorizerId, accountId, core.AddBalancePermission, domain).
+			Build().GetPayload().GetCommands()[0])
+	}
+	revoke := func(accountId string, domain string) error {
+		return ex.RevokePermission(wsv, fc.NewTxBuilder().
+			RevokePermission(authorizerId, accountId, core.AddBalancePermission, domain).
+			Build().GetPayload().GetCommands()[0])
+	}
+	domains := func() []string {
+		st := fc.NewEmptyStorage()
+		require.NoError(t, wsv.Query(model.MustAddress("account1@com/"+core.PermissionStorageName), st))
+		ret := make([]string, 0)
+		for _, o := range st.GetFromKey(core.AddBalancePermission).GetList() {
+			ret = append(ret, o.GetStr())
+		}
+		return ret
+	}
+
+	require.NoError(t, grant("account1@com", "com"))
+	require.NoError(t, grant("account1@com", "pr"))
+	// 同じ Domain は重複しない
+	require.NoError(t, grant("account1@com", "com"))
+	assert.Equal(t, []string{"com", "pr"}, domains())
+
+	require.NoError(t, revoke("account1@com", "com"))
+	assert.Equal(t, []string{"pr"}, domains())
+
+	err := revoke("account1@com", "com")
+	assert.EqualError(t, errors.Cause(err), core.ErrCommandExecutorRevokePermissionNotGranted.Error())
+	err = grant("unknown@com", "com")
+	assert.EqualError(t, errors.Cause(err), core.ErrCommandExecutorGrantPermissionNotFound.Error())
+	require.NoError(t, dtx.Commit())
+}
@@ -0,0 +1,205 @@
+package command
+
+import (
+	"github.com/pkg/errors"
+	"github.com/proskenion/proskenion/core"
+	"github.com/proskenion/proskenion/core/model"
+	"strings"
+)
+
+// isOwner は targetId が authorizerId 自身のものか、設定された prosl と同じ名前の sub domain にある
+// 同名の Account の prosl Storage かを返す。
+// (e.g. authorizer@com は authorizer@incentive.com/prosl を所有するが、authorizer@incentive.com は所有しない)
+func (c *CommandValidator) isOwner(authorizerId string, targetId string) bool {
+	a, err := model.NewAddress(authorizerId)
+	if err != nil {
+		return false
+	}
+	t, err := model.NewAddress(targetId)
+	if err != nil {
+		return false
+	}
+	if a.Account() == "" || a.Account() != t.Account() {
+		return false
+	}
+	if t.Domain() == a.Domain() {
+		return true
+	}
+	for _, proslId := range []string{c.conf.Prosl.Incentive.Id, c.conf.Prosl.Consensus.Id, c.conf.Prosl.Update.Id} {
+		p, err := model.NewAddress(proslId)
+		if err != nil {
+			continue
+		}
+		if t.Domain() == p.Domain()+"."+a.Domain() && t.Storage() == p.Storage() {
+			return true
+		}
+	}
+	return false
+}
+
+// isRoot は authorizerId が root Account かを返す
+func (c *CommandValidator) isRoot(authorizerId string) bool {
+	ac, err := model.NewAddress(authorizerId)
+	if err != nil {
+		return false
+	}
+	root, err := model.NewAddress(c.conf.Root.Id)
+	return err == nil && root.AccountId() == ac.AccountId()
+}
+
+// permissionActivated は書き込み権限の検証が有効化されているかを返す。
+// 有効化前の Block を再検証できる様に、有効化前は権限と予約 Storage を検証しない
+func (c *CommandValidator) permissionActivated(wsv model.ObjectFinder) bool {
+	return core.Activated(wsv, c.fc.NewEmptyStorage(), core.PermissionFeature)
+}
+
+func containsPermission(permissions []string, permission string) bool {
+	for _, p := range permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPermission は authorizerId が domain に対して permission を持つかを返す。
+// root Account と admin Role は全ての権限を持ち、RolePermissions の Role はその権限を持つ。
+func (c *CommandValidator) hasPermission(wsv model.ObjectFinder, authorizerId string, permission string, domain string) bool {
+	ac, err := model.NewAddress(authorizerId)
+	if err != nil {
+		return false
+	}
+	if c.isRoot(authorizerId) {
+		return true
+	}
+	permId, err := model.NewAddress(ac.Account() + "@" + ac.Domain() + "/" + core.PermissionStorageName)
+	if err != nil {
+		return false
+	}
+	st := c.fc.NewEmptyStorage()
+	if err := wsv.Query(permId, st); err != nil {
+		return false
+	}
+	for name, o := range st.GetObject() {
+		if name != permission && name != core.AdminRole &&
+			!containsPermission(core.RolePermissions[name], permission) {
+			continue
+		}
+		for _, d := range o.GetList() {
+			if core.MatchDomain(d.GetStr(), domain) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkPermission は authorizer が target に対して permission を持つか検証する。
+// ownerAllowed の場合、target を所有する authorizer は権限が無くても実行できる。
+func (c *CommandValidator) checkPermission(wsv model.ObjectFinder, cmd model.Command, permission string, ownerAllowed bool) error {
+	if !c.permissionActivated(wsv) {
+		return nil
+	}
+	if ownerAllowed && c.isOwner(cmd.GetAuthorizerId(), cmd.GetTargetId()) {
+		return nil
+	}
+	domain := ""
+	if id, err := model.NewAddress(cmd.GetTargetId()); err == nil {
+		domain = id.Domain()
+	}
+	if !c.hasPermission(wsv, cmd.GetAuthorizerId(), permission, domain) {
+		return errors.Wrapf(core.ErrCommandValidatorPermissionDenied,
+			"authorizer: %s, target: %s, permission: %s", cmd.GetAuthorizerId(), cmd.GetTargetId(), permission)
+	}
+	return nil
+}
+
+// allowInvoke は walletId の Storage 定義の invoke_acl が invokerId を許可しているかを返す。
+// invoke_acl の要素は AccountId か Domain で、Domain はその sub domain の Account も許可する
+func (c *CommandValidator) allowInvoke(wsv model.ObjectFinder, invokerId string, walletId string) bool {
+	invoker, err := model.NewAddress(invokerId)
+	if err != nil {
+		return false
+	}
+	id, err := model.NewAddress(walletId)
+	if err != nil {
+		return false
+	}
+	defSt := c.fc.NewEmptyStorage()
+	if err := wsv.Query(model.MustAddress("/"+id.Storage()), defSt); err != nil {
+		return false
+	}
+	for _, o := range defSt.GetFromKey(core.InvokeAclKey).GetList() {
+		entry := o.GetStr()
+		if o.GetType() == model.AddressObjectCode {
+			entry = o.GetAddress()
+		}
+		if acId, err := model.NewAddress(entry); err == nil && acId.Account() != "" {
+			if acId.AccountId() == invoker.AccountId() {
+				return true
+			}
+			continue
+		}
+		if core.MatchDomain(entry, invoker.Domain()) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkNotReserved は予約された Storage が専用の Command 以外で書き換えられないことを検証する。
+// permission Storage は GrantPermission, RevokePermission のみ、機能の有効化 Storage は root のみが書き換えられる
+func (c *CommandValidator) checkNotReserved(wsv model.ObjectFinder, cmd model.Command, ids ...string) error {
+	activated := c.permissionActivated(wsv)
+	for _, id := range ids {
+		address, err := model.NewAddress(id)
+		if err != nil {
+			continue
+		}
+		if activated && address.Storage() == core.PermissionStorageName {
+			return errors.Wrapf(core.ErrCommandValidatorReservedStorage, "target: %s", id)
+		}
+		if address.Storage() == model.MustAddress(core.ActivationId).Storage() && !c.isRoot(cmd.GetAuthorizerId()) {
+			return errors.Wrapf(core.ErrCommandValidatorReservedStorage, "target: %s", id)
+		}
+		// ProSL の履歴は CheckAndCommitProsl と RevertProsl のみが書き込む
+		if activated && strings.HasSuffix(address.Storage(), core.ProslHistorySuffix) {
+			return errors.Wrapf(core.ErrCommandValidatorReservedStorage, "target: %s", id)
+		}
+	}
+	return nil
+}
+
+// checkProslKey は wallet の prosl key を所有者のみが書き換えられることを検証する。
+// prosl は InvokeProsl で所有者の権限で実行されるため、update_object 権限を持つ他の Account が書き込めてはならない
+func (c *CommandValidator) checkProslKey(wsv model.ObjectFinder, cmd model.Command, walletId string, key string) error {
+	if key != core.ProslKey || !c.permissionActivated(wsv) {
+		return nil
+	}
+	if c.isOwner(cmd.GetAuthorizerId(), walletId) {
+		return nil
+	}
+	return errors.Wrapf(core.ErrCommandValidatorPermissionDenied,
+		"only the owner can write %s of %s, authorizer: %s", core.ProslKey, walletId, cmd.GetAuthorizerId())
+}
+
+// checkGrant は GrantPermission, RevokePermission の authorizer が target の Domain に対する権限と、
+// 付与(取消)する権限自体を持つかを検証する
+func (c *CommandValidator) checkGrant(wsv model.ObjectFinder, cmd model.Command, cmdPermission string,
+	permission string, domain string) error {
+	if permission == "" || domain == "" {
+		return errors.Wrapf(core.ErrCommandValidatorGrantPermissionInvalid,
+			"permission: %s, domain: %s", permission, domain)
+	}
+	if !c.permissionActivated(wsv) {
+		return nil
+	}
+	if err := c.checkPermission(wsv, cmd, cmdPermission, false); err != nil {
+		return err
+	}
+	if !c.hasPermission(wsv, cmd.GetAuthorizerId(), permission, domain) {
+		return errors.Wrapf(core.ErrCommandValidatorPermissionDenied,
+			"authorizer: %s doesn't have %s on %s", cmd.GetAuthorizerId(), permission, domain)
+	}
+	return nil
+}
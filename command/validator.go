@@ -1,98 +1,577 @@
 package command
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/pkg/errors"
 	"github.com/proskenion/proskenion/config"
 	"github.com/proskenion/proskenion/core"
 	"github.com/proskenion/proskenion/core/model"
+	"github.com/proskenion/proskenion/prosl"
+	"math"
 )
 
 type CommandValidator struct {
-	fc    model.ModelFactory
-	prosl core.Prosl
-	conf  *config.Config
+	fc      model.ModelFactory
+	cryptor core.Cryptor
+	conf    *config.Config
 }
 
 func NewCommandValidator(conf *config.Config) core.CommandValidator {
 	return &CommandValidator{conf: conf}
 }
 
-func (c *CommandValidator) SetField(factory model.ModelFactory, prosl core.Prosl) {
+func (c *CommandValidator) SetField(factory model.ModelFactory, cryptor core.Cryptor) {
 	c.fc = factory
-	c.prosl = prosl
+	c.cryptor = cryptor
+}
+
+// queryAccount は accountId の Account を取得し、存在しなければ notFound を返す
+func (c *CommandValidator) queryAccount(wsv model.ObjectFinder, accountId string, notFound error) (model.Account, error) {
+	id, err := model.NewAddress(accountId)
+	if err != nil {
+		return nil, errors.Wrap(notFound, err.Error())
+	}
+	ac := c.fc.NewEmptyAccount()
+	if err := wsv.Query(model.MustAddress(id.AccountId()), ac); err != nil {
+		return nil, errors.Wrap(notFound, err.Error())
+	}
+	return ac, nil
+}
+
+// queryStorage は walletId の Storage を取得し、存在しなければ notFound を返す
+func (c *CommandValidator) queryStorage(wsv model.ObjectFinder, walletId string, notFound error) (model.Storage, error) {
+	id, err := model.NewAddress(walletId)
+	if err != nil {
+		return nil, errors.Wrap(notFound, err.Error())
+	}
+	st := c.fc.NewEmptyStorage()
+	if err := wsv.Query(id, st); err != nil {
+		return nil, errors.Wrap(notFound, err.Error())
+	}
+	return st, nil
+}
+
+// queryPeer は peerId の Peer を取得する
+func (c *CommandValidator) queryPeer(wsv model.ObjectFinder, peerId string) (model.Peer, error) {
+	id, err := model.NewAddress(peerId)
+	if err != nil {
+		return nil, errors.Wrap(core.ErrCommandValidatorPeerNotFound, err.Error())
+	}
+	peer := c.fc.NewEmptyPeer()
+	if err := wsv.Query(model.MustAddress(id.PeerId()), peer); err != nil {
+		return nil, errors.Wrap(core.ErrCommandValidatorPeerNotFound, err.Error())
+	}
+	return peer, nil
+}
+
+// statefulActivated は State に基づく Command の事前検証が有効化されているかを返す。
+// 有効化前の Block を再検証できる様に、有効化前は権限のみを検証する
+func (c *CommandValidator) statefulActivated(wsv model.ObjectFinder) bool {
+	return core.Activated(wsv, c.fc.NewEmptyStorage(), core.StatefulValidationFeature)
+}
+
+func validateQuorum(quorum int32, keys []model.PublicKey) error {
+	if quorum < 0 || int(quorum) > len(keys) {
+		return errors.Wrapf(core.ErrCommandValidatorInvalidQuorum,
+			"quorum: %d, number of keys: %d", quorum, len(keys))
+	}
+	return nil
 }
 
 func (c *CommandValidator) TransferBalance(wsv model.ObjectFinder, cmd model.Command) error {
+	if err := c.checkPermission(wsv, cmd, core.TransferBalancePermission, true); err != nil {
+		return err
+	}
+	if !c.statefulActivated(wsv) {
+		return nil
+	}
+	tb := cmd.GetTransferBalance()
+	if tb.GetBalance() < 0 {
+		return errors.Wrapf(core.ErrCommandValidatorTransferBalanceInvalidBalance, "balance: %d", tb.GetBalance())
+	}
+	src, err := c.queryAccount(wsv, cmd.GetTargetId(), core.ErrCommandExecutorTransferBalanceNotFoundSrcAccountId)
+	if err != nil {
+		return err
+	}
+	dest, err := c.queryAccount(wsv, tb.GetDestAccountId(), core.ErrCommandExecutorTransferBalanceNotFoundDestAccountId)
+	if err != nil {
+		return err
+	}
+	if src.GetBalance() < tb.GetBalance() {
+		return errors.Wrapf(core.ErrCommandExecutorTransferBalanceNotEnoughSrcAccountBalance,
+			"srcAccount Amount: %d, transfer Amount: %d", src.GetBalance(), tb.GetBalance())
+	}
+	if src.GetAccountId() != dest.GetAccountId() && dest.GetBalance() > math.MaxInt64-tb.GetBalance() {
+		return errors.Wrapf(core.ErrCommandValidatorTransferBalanceOverflow,
+			"destAccount Amount: %d, transfer Amount: %d", dest.GetBalance(), tb.GetBalance())
+	}
 	return nil
 }
 
 func (c *CommandValidator) CreateAccount(wsv model.ObjectFinder, cmd model.Command) error {
+	if err := c.checkPermission(wsv, cmd, core.CreateAccountPermission, false); err != nil {
+		return err
+	}
+	if !c.statefulActivated(wsv) {
+		return nil
+	}
 	id := model.MustAddress(model.MustAddress(cmd.GetTargetId()).AccountId())
 	ac := c.fc.NewEmptyAccount()
 	if err := wsv.Query(id, ac); err == nil {
 		return errors.Wrap(core.ErrCommandExecutorCreateAccountAlreadyExistAccount,
 			fmt.Errorf("already exist accountId : %s", id.AccountId()).Error())
 	}
-	return nil
+	ca := cmd.GetCreateAccount()
+	return validateQuorum(ca.GetQuorum(), ca.GetPublicKeys())
 }
 
 func (c *CommandValidator) SetQuorum(wsv model.ObjectFinder, cmd model.Command) error {
-	return nil
+	if err := c.checkPermission(wsv, cmd, core.SetQuorumPermission, true); err != nil {
+		return err
+	}
+	if !c.statefulActivated(wsv) {
+		return nil
+	}
+	ac, err := c.queryAccount(wsv, cmd.GetTargetId(), core.ErrCommandExecutorSetQuorumNotExistAccount)
+	if err != nil {
+		return err
+	}
+	return validateQuorum(cmd.GetSetQuorum().GetQuorum(), ac.GetPublicKeys())
 }
 
 func (c *CommandValidator) AddBalance(wsv model.ObjectFinder, cmd model.Command) error {
+	if err := c.checkPermission(wsv, cmd, core.AddBalancePermission, false); err != nil {
+		return err
+	}
+	if !c.statefulActivated(wsv) {
+		return nil
+	}
+	ac, err := c.queryAccount(wsv, cmd.GetTargetId(), core.ErrCommandExecutorAddBalanceNotExistAccount)
+	if err != nil {
+		return err
+	}
+	balance := cmd.GetAddBalance().GetBalance()
+	if (balance > 0 && ac.GetBalance() > math.MaxInt64-balance) || ac.GetBalance()+balance < 0 {
+		return errors.Wrapf(core.ErrCommandValidatorAddBalanceOverflow,
+			"account Amount: %d, add Amount: %d", ac.GetBalance(), balance)
+	}
 	return nil
 }
 
 func (c *CommandValidator) AddPublicKeys(wsv model.ObjectFinder, cmd model.Command) error {
+	if err := c.checkPermission(wsv, cmd, core.AddPublicKeysPermission, true); err != nil {
+		return err
+	}
+	if !c.statefulActivated(wsv) {
+		return nil
+	}
+	ac, err := c.queryAccount(wsv, cmd.GetTargetId(), core.ErrCommandExecutorAddPublicKeyNotExistAccount)
+	if err != nil {
+		return err
+	}
+	keys := cmd.GetAddPublicKeys().GetPublicKeys()
+	if len(keys) == 0 {
+		return core.ErrCommandValidatorAddPublicKeyEmpty
+	}
+	for i, key := range keys {
+		if containsPublicKey(ac.GetPublicKeys(), key) || containsPublicKey(keys[:i], key) {
+			return errors.Wrapf(core.ErrCommandExecutorAddPublicKeyDuplicatePubkey,
+				"duplicate key : %x", key)
+		}
+	}
 	return nil
 }
 
-func (c *CommandValidator) DefineStorage(wsv model.ObjectFinder, cmd model.Command) error {
+// RemovePublicKeys は削除後も Account に鍵が残り、その数が quorum 以上であることを検証する
+func (c *CommandValidator) RemovePublicKeys(wsv model.ObjectFinder, cmd model.Command) error {
+	if err := c.checkPermission(wsv, cmd, core.RemovePublicKeysPermission, true); err != nil {
+		return err
+	}
+	ac, err := c.queryAccount(wsv, cmd.GetTargetId(), core.ErrCommandExecutorRemovePublicKeyNotExistAccount)
+	if err != nil {
+		return err
+	}
+	keys := cmd.GetRemovePublicKeys().GetPublicKeys()
+	if len(keys) == 0 {
+		return core.ErrCommandValidatorRemovePublicKeyEmpty
+	}
+	for i, key := range keys {
+		if !containsPublicKey(ac.GetPublicKeys(), key) || containsPublicKey(keys[:i], key) {
+			return errors.Wrapf(core.ErrCommandExecutorRemovePublicKeyNotFoundPubkey,
+				"not found key : %x", key)
+		}
+	}
+	rest := len(ac.GetPublicKeys()) - len(keys)
+	if rest == 0 {
+		return errors.Wrapf(core.ErrCommandValidatorRemovePublicKeyLastPubkey, "account : %s", cmd.GetTargetId())
+	}
+	if rest < int(ac.GetQuorum()) {
+		return errors.Wrapf(core.ErrCommandValidatorRemovePublicKeyUnderQuorum,
+			"quorum : %d, rest keys : %d", ac.GetQuorum(), rest)
+	}
 	return nil
 }
 
+func (c *CommandValidator) DefineStorage(wsv model.ObjectFinder, cmd model.Command) error {
+	if err := c.checkNotReserved(wsv, cmd, cmd.GetTargetId()); err != nil {
+		return err
+	}
+	return c.checkPermission(wsv, cmd, core.DefineStoragePermission, false)
+}
+
 func (c *CommandValidator) CreateStorage(wsv model.ObjectFinder, cmd model.Command) error {
+	if err := c.checkNotReserved(wsv, cmd, cmd.GetTargetId()); err != nil {
+		return err
+	}
+	if err := c.checkPermission(wsv, cmd, core.CreateStoragePermission, true); err != nil {
+		return err
+	}
+	if !c.statefulActivated(wsv) {
+		return nil
+	}
+	id, err := model.NewAddress(cmd.GetTargetId())
+	if err != nil {
+		return errors.Wrap(core.ErrCommandExecutorCreateStorageNotDefinedStorage, err.Error())
+	}
+	_, err = c.queryStorage(wsv, "/"+id.Storage(), core.ErrCommandExecutorCreateStorageNotDefinedStorage)
+	return err
+}
+
+// validatingWSV は validate prosl を実行する wsv。
+// Validate は Execute の前に実行されるので、validate prosl が発行したイベントを Receipt に含めない様に EventEmitter を隠す
+type validatingWSV struct {
+	model.ObjectFinder
+}
+
+// validateStorage は walletId の Storage 定義に validate prosl があれば実行し、真を返すか検証する。
+// st は呼び出し元が queryStorage で取得した walletId の Storage。
+// validate prosl には以下の引数が渡される。
+// command_type, command, authorizer_id, target_id, wallet_id, key, object, storage (, dest_id, direction)
+func (c *CommandValidator) validateStorage(wsv model.ObjectFinder, cmd model.Command, cmdType string,
+	walletId string, st model.Storage, key string, object model.Object, params map[string]model.Object) error {
+	id, err := model.NewAddress(walletId)
+	if err != nil {
+		return err
+	}
+	defSt := c.fc.NewEmptyStorage()
+	if err := wsv.Query(model.MustAddress("/"+id.Storage()), defSt); err != nil {
+		// 定義されていない Storage は Executor で失敗する
+		return nil
+	}
+	buf := defSt.GetFromKey(core.ValidateProslKey).GetData()
+	if buf == nil {
+		return nil
+	}
+
+	params["command_type"] = c.fc.NewObjectBuilder().Str(cmdType)
+	params["command"] = c.fc.NewObjectBuilder().Command(cmd)
+	params[core.AuthorizerIdKey] = c.fc.NewObjectBuilder().Address(cmd.GetAuthorizerId())
+	params[core.TargetIdKey] = c.fc.NewObjectBuilder().Address(cmd.GetTargetId())
+	params["wallet_id"] = c.fc.NewObjectBuilder().Address(walletId)
+	params["key"] = c.fc.NewObjectBuilder().Str(key)
+	params["object"] = object
+	params["storage"] = c.fc.NewObjectBuilder().Storage(st)
+
+	pr := prosl.NewProsl(c.fc, c.cryptor, c.conf)
+	if err := pr.Unmarshal(buf); err != nil {
+		return errors.Wrap(core.ErrCommandValidatorValidateProslFailed, err.Error())
+	}
+	check, variables, err := pr.ExecuteWithParams(&validatingWSV{wsv}, nil, params)
+	if err != nil {
+		return errors.Wrapf(core.ErrCommandValidatorValidateProslFailed,
+			"wallet: %s, variables: %+v, error: %s", walletId, variables, err.Error())
+	}
+	if check == nil || !check.GetBoolean() {
+		return errors.Wrapf(core.ErrCommandValidatorValidateProslInvalid,
+			"wallet: %s, variables: %+v", walletId, variables)
+	}
 	return nil
 }
 
 func (c *CommandValidator) UpdateObject(wsv model.ObjectFinder, cmd model.Command) error {
-	return nil
+	if err := c.checkNotReserved(wsv, cmd, cmd.GetTargetId()); err != nil {
+		return err
+	}
+	if err := c.checkPermission(wsv, cmd, core.UpdateObjectPermission, true); err != nil {
+		return err
+	}
+	uo := cmd.GetUpdateObject()
+	if err := c.checkProslKey(wsv, cmd, cmd.GetTargetId(), uo.GetKey()); err != nil {
+		return err
+	}
+	st, err := c.queryStorage(wsv, cmd.GetTargetId(), core.ErrCommandExecutorUpdateObjectNotExistWallet)
+	if err != nil {
+		if !c.statefulActivated(wsv) {
+			return nil
+		}
+		return err
+	}
+	return c.validateStorage(wsv, cmd, "update_object", cmd.GetTargetId(), st,
+		uo.GetKey(), uo.GetObject(), make(map[string]model.Object))
 }
 
 func (c *CommandValidator) AddObject(wsv model.ObjectFinder, cmd model.Command) error {
+	if err := c.checkNotReserved(wsv, cmd, cmd.GetTargetId()); err != nil {
+		return err
+	}
+	if err := c.checkPermission(wsv, cmd, core.AddObjectPermission, true); err != nil {
+		return err
+	}
+	ao := cmd.GetAddObject()
+	if err := c.checkProslKey(wsv, cmd, cmd.GetTargetId(), ao.GetKey()); err != nil {
+		return err
+	}
+	st, err := c.queryStorage(wsv, cmd.GetTargetId(), core.ErrCommandExecutorAddObjectNotExistWallet)
+	if err != nil {
+		if !c.statefulActivated(wsv) {
+			return nil
+		}
+		return err
+	}
+	if err := c.validateStorage(wsv, cmd, "add_object", cmd.GetTargetId(), st,
+		ao.GetKey(), ao.GetObject(), make(map[string]model.Object)); err != nil {
+		return err
+	}
+	if !c.statefulActivated(wsv) {
+		return nil
+	}
+	if o, ok := st.GetObject()[ao.GetKey()]; !ok || o.GetType() != model.ListObjectCode {
+		return errors.Wrapf(core.ErrCommandValidatorAddObjectNotList,
+			"wallet: %s, key: %s", cmd.GetTargetId(), ao.GetKey())
+	}
 	return nil
 }
 
+// TransferObject は送信元と送信先、両方の Storage の validate prosl を検証する。
+// validate prosl には送信元では direction = "out"、送信先では direction = "in" が渡される
 func (c *CommandValidator) TransferObject(wsv model.ObjectFinder, cmd model.Command) error {
-	return nil
+	to := cmd.GetTransferObject()
+	if err := c.checkNotReserved(wsv, cmd, cmd.GetTargetId(), to.GetDestAccountId()); err != nil {
+		return err
+	}
+	if err := c.checkPermission(wsv, cmd, core.TransferObjectPermission, true); err != nil {
+		return err
+	}
+	if err := c.checkProslKey(wsv, cmd, to.GetDestAccountId(), to.GetKey()); err != nil {
+		return err
+	}
+	stateful := c.statefulActivated(wsv)
+	srcSt, err := c.queryStorage(wsv, cmd.GetTargetId(), core.ErrCommandExecutorTransferObjectNotExistSrcWallet)
+	if err != nil && stateful {
+		return err
+	}
+	destSt, err := c.queryStorage(wsv, to.GetDestAccountId(), core.ErrCommandExecutorTransferObjectNotExistDestWallet)
+	if err != nil && stateful {
+		return err
+	}
+	for _, v := range []struct {
+		walletId  string
+		st        model.Storage
+		direction string
+	}{
+		{cmd.GetTargetId(), srcSt, "out"},
+		{to.GetDestAccountId(), destSt, "in"},
+	} {
+		if v.st == nil {
+			// 有効化前は存在しない Wallet を Executor で失敗させる
+			continue
+		}
+		params := map[string]model.Object{
+			"dest_id":   c.fc.NewObjectBuilder().Address(to.GetDestAccountId()),
+			"direction": c.fc.NewObjectBuilder().Str(v.direction),
+		}
+		if err := c.validateStorage(wsv, cmd, "transfer_object", v.walletId, v.st,
+			to.GetKey(), to.GetObject(), params); err != nil {
+			return err
+		}
+	}
+	if !stateful {
+		return nil
+	}
+	srco, ok1 := srcSt.GetObject()[to.GetKey()]
+	desto, ok2 := destSt.GetObject()[to.GetKey()]
+	if !ok1 || !ok2 || srco.GetType() != model.ListObjectCode || desto.GetType() != model.ListObjectCode {
+		return errors.Wrapf(core.ErrCommandValidatorTransferObjectNotList, "key: %s", to.GetKey())
+	}
+	for _, o := range srco.GetList() {
+		if bytes.Equal(o.Hash(), to.GetObject().Hash()) {
+			return nil
+		}
+	}
+	return errors.Wrapf(core.ErrCommandValidatorTransferObjectNotFoundObject,
+		"wallet: %s, object: %x", cmd.GetTargetId(), to.GetObject().Hash())
 }
 
 func (c *CommandValidator) AddPeer(wsv model.ObjectFinder, cmd model.Command) error {
+	if err := c.checkPermission(wsv, cmd, core.AddPeerPermission, false); err != nil {
+		return err
+	}
+	if !c.statefulActivated(wsv) {
+		return nil
+	}
+	if _, err := c.queryPeer(wsv, cmd.GetTargetId()); err == nil {
+		return errors.Wrapf(core.ErrCommandValidatorAddPeerDuplicatePeer, "peer: %s", cmd.GetTargetId())
+	}
 	return nil
 }
 
-func (c *CommandValidator) ActivatePeer(model.ObjectFinder, model.Command) error {
+func (c *CommandValidator) ActivatePeer(wsv model.ObjectFinder, cmd model.Command) error {
+	if err := c.checkPermission(wsv, cmd, core.ActivatePeerPermission, false); err != nil {
+		return err
+	}
+	if !c.statefulActivated(wsv) {
+		return nil
+	}
+	peer, err := c.queryPeer(wsv, cmd.GetTargetId())
+	if err != nil {
+		return err
+	}
+	if peer.GetBan() {
+		return errors.Wrapf(core.ErrCommandValidatorPeerBanned, "peer: %s", cmd.GetTargetId())
+	}
 	return nil
 }
 
-func (c *CommandValidator) SuspendPeer(model.ObjectFinder, model.Command) error {
-	return nil
+func (c *CommandValidator) SuspendPeer(wsv model.ObjectFinder, cmd model.Command) error {
+	if err := c.checkPermission(wsv, cmd, core.SuspendPeerPermission, false); err != nil {
+		return err
+	}
+	if !c.statefulActivated(wsv) {
+		return nil
+	}
+	_, err := c.queryPeer(wsv, cmd.GetTargetId())
+	return err
 }
 
-func (c *CommandValidator) BanPeer(model.ObjectFinder, model.Command) error {
-	return nil
+func (c *CommandValidator) BanPeer(wsv model.ObjectFinder, cmd model.Command) error {
+	if err := c.checkPermission(wsv, cmd, core.BanPeerPermission, false); err != nil {
+		return err
+	}
+	if !c.statefulActivated(wsv) {
+		return nil
+	}
+	_, err := c.queryPeer(wsv, cmd.GetTargetId())
+	return err
 }
 
+// Consign は委任先の Peer が存在し、活動していることを検証する
 func (c *CommandValidator) Consign(wsv model.ObjectFinder, cmd model.Command) error {
+	if err := c.checkPermission(wsv, cmd, core.ConsignPermission, true); err != nil {
+		return err
+	}
+	if !c.statefulActivated(wsv) {
+		return nil
+	}
+	if _, err := c.queryAccount(wsv, cmd.GetTargetId(), core.ErrCommandExecutorConsignNotFoundAccount); err != nil {
+		return err
+	}
+	peerId := cmd.GetConsign().GetPeerId()
+	peer, err := c.queryPeer(wsv, peerId)
+	if err != nil {
+		return err
+	}
+	if !peer.GetActive() || peer.GetBan() {
+		return errors.Wrapf(core.ErrCommandValidatorConsignInactivePeer, "peer: %s", peerId)
+	}
 	return nil
 }
 
 func (c *CommandValidator) CheckAndCommitProsl(wsv model.ObjectFinder, cmd model.Command) error {
+	if err := c.checkPermission(wsv, cmd, core.CheckAndCommitProslPermission, true); err != nil {
+		return err
+	}
+	if !c.statefulActivated(wsv) {
+		return nil
+	}
+	_, err := c.queryStorage(wsv, cmd.GetTargetId(), core.ErrCommandExecutorCheckAndCommitProslNotFound)
+	return err
+}
+
+// RevertProsl は対象の prosl の履歴に指定した version があることを検証する
+func (c *CommandValidator) RevertProsl(wsv model.ObjectFinder, cmd model.Command) error {
+	switch cmd.GetTargetId() {
+	case c.conf.Prosl.Incentive.Id, c.conf.Prosl.Consensus.Id, c.conf.Prosl.Update.Id:
+	default:
+		return errors.Wrapf(core.ErrCommandExecutorRevertProslInvalidTarget, "target: %s", cmd.GetTargetId())
+	}
+	if err := c.checkPermission(wsv, cmd, core.RevertProslPermission, false); err != nil {
+		return err
+	}
+	if !c.statefulActivated(wsv) {
+		return nil
+	}
+	version := cmd.GetRevertProsl().GetVersion()
+	histSt, err := c.queryStorage(wsv, core.ProslHistoryId(cmd.GetTargetId()), core.ErrCommandExecutorRevertProslNotFoundVersion)
+	if err != nil {
+		return err
+	}
+	for _, v := range histSt.GetFromKey(core.ProslVersionsKey).GetList() {
+		if v.GetDict()[core.ProslVersionKey].GetI64() == version {
+			return nil
+		}
+	}
+	return errors.Wrapf(core.ErrCommandExecutorRevertProslNotFoundVersion,
+		"target: %s, version: %d", cmd.GetTargetId(), version)
+}
+
+// InvokeProsl は invoker が prosl の所有者か invoke_prosl 権限を持つか、Storage 定義の invoke_acl で許可されていることを検証する
+func (c *CommandValidator) InvokeProsl(wsv model.ObjectFinder, cmd model.Command) error {
+	if err := c.checkPermission(wsv, cmd, core.InvokeProslPermission, true); err != nil &&
+		!c.allowInvoke(wsv, cmd.GetAuthorizerId(), cmd.GetTargetId()) {
+		return err
+	}
+	id, err := model.NewAddress(cmd.GetTargetId())
+	if err != nil {
+		return errors.Wrap(core.ErrCommandExecutorInvokeProslNotFound, err.Error())
+	}
+	st := c.fc.NewEmptyStorage()
+	if err := wsv.Query(id, st); err != nil {
+		return errors.Wrap(core.ErrCommandExecutorInvokeProslNotFound, err.Error())
+	}
+	if st.GetFromKey(core.ProslKey).GetData() == nil {
+		return errors.Wrapf(core.ErrCommandExecutorInvokeProslNotFound, "not found key %s in %s", core.ProslKey, cmd.GetTargetId())
+	}
 	return nil
 }
 
+func (c *CommandValidator) GrantPermission(wsv model.ObjectFinder, cmd model.Command) error {
+	gp := cmd.GetGrantPermission()
+	if err := c.checkGrant(wsv, cmd, core.GrantPermissionPermission, gp.GetPermission(), gp.GetDomain()); err != nil {
+		return err
+	}
+	if !c.statefulActivated(wsv) {
+		return nil
+	}
+	_, err := c.queryAccount(wsv, cmd.GetTargetId(), core.ErrCommandExecutorGrantPermissionNotFound)
+	return err
+}
+
+func (c *CommandValidator) RevokePermission(wsv model.ObjectFinder, cmd model.Command) error {
+	rp := cmd.GetRevokePermission()
+	if err := c.checkGrant(wsv, cmd, core.RevokePermissionPermission, rp.GetPermission(), rp.GetDomain()); err != nil {
+		return err
+	}
+	if !c.statefulActivated(wsv) {
+		return nil
+	}
+	ac, err := c.queryAccount(wsv, cmd.GetTargetId(), core.ErrCommandExecutorGrantPermissionNotFound)
+	if err != nil {
+		return err
+	}
+	st, err := c.queryStorage(wsv, ac.GetAccountId()+"/"+core.PermissionStorageName, core.ErrCommandExecutorRevokePermissionNotGranted)
+	if err != nil {
+		return err
+	}
+	for _, d := range st.GetFromKey(rp.GetPermission()).GetList() {
+		if d.GetStr() == rp.GetDomain() {
+			return nil
+		}
+	}
+	return errors.Wrapf(core.ErrCommandExecutorRevokePermissionNotGranted,
+		"target: %s, permission: %s, domain: %s", cmd.GetTargetId(), rp.GetPermission(), rp.GetDomain())
+}
+
 func (c *CommandValidator) ForceUpdateStorage(wsv model.ObjectFinder, cmd model.Command) error {
 	return core.ErrCommandValidatorForceUpdateStorageCanNotUsedDefault
 }
@@ -10,6 +10,7 @@ import (
 	. "github.com/proskenion/proskenion/test_utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"math"
 	"testing"
 )
 
@@ -120,3 +121,580 @@ func TestCommandValidator_CreateAccount(t *testing.T) {
 		})
 	}
 }
+
+func TestCommandValidator_ValidateProsl(t *testing.T) {
+	fc, _, rp := prePareCommandValidator(t)
+	prePareCreateAccounts(t, fc, rp)
+	prePareCreateStorage(t, fc, rp)
+
+	validatePr := ConvertYamlFileToProtoBinary(t, "../test_utils/counter_validate.yaml")
+	counterSt := fc.NewStorageBuilder().
+		Int64("count", 0).
+		List("list", make([]model.Object, 0)).
+		Data(core.ValidateProslKey, validatePr).
+		Build()
+	tx := fc.NewTxBuilder().
+		DefineStorage(authorizerId, "/counter", counterSt).
+		CreateStorage(authorizerId, "account1@com/counter").
+		CreateStorage(authorizerId, "account2@com/counter").
+		AddObject(authorizerId, "account1@com/counter", "list", fc.NewObjectBuilder().Int64(-1)).
+		DefineStorage(authorizerId, "/sealed", fc.NewStorageBuilder().
+			List("list", make([]model.Object, 0)).
+			Data(core.ValidateProslKey, ConvertYamlFileToProtoBinary(t, "../test_utils/sealed_validate.yaml")).
+			Build()).
+		CreateStorage(authorizerId, "account1@com/sealed").
+		CreateStorage(authorizerId, "account2@com/sealed").
+		AddObject(authorizerId, "account2@com/sealed", "list", fc.NewObjectBuilder().Int64(-2)).
+		Build()
+	CommitTxWrapBlock(t, rp, fc, tx)
+
+	_, wsv := prePareGetDtxWSV(t, rp)
+	for _, c := range []struct {
+		name string
+		cmd  model.Command
+		err  error
+	}{
+		{
+			"case 1 : update increase counter",
+			fc.NewTxBuilder().UpdateObject(authorizerId, "account1@com/counter", "count",
+				fc.NewObjectBuilder().Int64(1)).Build().GetPayload().GetCommands()[0],
+			nil,
+		},
+		{
+			"case 2 : update not increase counter",
+			fc.NewTxBuilder().UpdateObject(authorizerId, "account1@com/counter", "count",
+				fc.NewObjectBuilder().Int64(0)).Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorValidateProslInvalid,
+		},
+		{
+			"case 3 : add not increase counter",
+			fc.NewTxBuilder().AddObject(authorizerId, "account1@com/counter", "count",
+				fc.NewObjectBuilder().Int64(-1)).Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorValidateProslInvalid,
+		},
+		{
+			"case 4 : add another key",
+			fc.NewTxBuilder().AddObject(authorizerId, "account1@com/counter", "list",
+				fc.NewObjectBuilder().Int64(-1)).Build().GetPayload().GetCommands()[0],
+			nil,
+		},
+		{
+			"case 5 : transfer another key",
+			fc.NewTxBuilder().TransferObject(authorizerId, "account1@com/counter", "account2@com/counter", "list",
+				fc.NewObjectBuilder().Int64(-1)).Build().GetPayload().GetCommands()[0],
+			nil,
+		},
+		{
+			"case 6 : transfer not increase counter",
+			fc.NewTxBuilder().TransferObject(authorizerId, "account1@com/counter", "account2@com/counter", "count",
+				fc.NewObjectBuilder().Int64(0)).Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorValidateProslInvalid,
+		},
+		{
+			"case 7 : storage without validate prosl",
+			fc.NewTxBuilder().UpdateObject(authorizerId, "account1@com/land", "value",
+				fc.NewObjectBuilder().Int64(0)).Build().GetPayload().GetCommands()[0],
+			nil,
+		},
+		{
+			"case 8 : transfer into sealed storage",
+			fc.NewTxBuilder().TransferObject(authorizerId, "account1@com/counter", "account1@com/sealed", "list",
+				fc.NewObjectBuilder().Int64(-1)).Build().GetPayload().GetCommands()[0],
+			nil,
+		},
+		{
+			"case 9 : transfer out of sealed storage",
+			fc.NewTxBuilder().TransferObject(authorizerId, "account2@com/sealed", "account2@com/counter", "list",
+				fc.NewObjectBuilder().Int64(-2)).Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorValidateProslInvalid,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.cmd.Validate(wsv)
+			if c.err != nil {
+				assert.EqualError(t, errors.Cause(err), c.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCommandValidator_Permission(t *testing.T) {
+	fc, _, rp := prePareCommandValidator(t)
+	prePareCreateAccounts(t, fc, rp)
+	CommitTxWrapBlock(t, rp, fc, fc.NewTxBuilder().
+		GrantPermission(authorizerId, "account1@com", core.AddBalancePermission, "com").
+		GrantPermission(authorizerId, "account2@com", core.PeerOperatorRole, core.AllDomain).
+		GrantPermission(authorizerId, "account2@com", core.UpdateObjectPermission, "com").
+		CreateAccount(authorizerId, "account2@sub.com", []model.PublicKey{}, 0).
+		AddBalance(authorizerId, "account2@com", 100).
+		DefineStorage(authorizerId, "/prosl", fc.NewStorageBuilder().Build()).
+		AddPeer(authorizerId, "peer1@peer", "0.0.0.0:5050", RandomPublicKey()).
+		DefineStorage(authorizerId, "/contract", fc.NewStorageBuilder().Data(core.ProslKey, []byte{1}).Build()).
+		DefineStorage(authorizerId, "/public", fc.NewStorageBuilder().Data(core.ProslKey, []byte{1}).
+			List(core.InvokeAclKey, []model.Object{fc.NewObjectBuilder().Str("com")}).Build()).
+		CreateStorage(authorizerId, "account1@com/contract").
+		CreateStorage(authorizerId, "account1@com/public").
+		Build())
+
+	_, wsv := prePareGetDtxWSV(t, rp)
+	for _, c := range []struct {
+		name string
+		cmd  model.Command
+		err  error
+	}{
+		{
+			"case 1 : granted permission",
+			fc.NewTxBuilder().AddBalance("account1@com", "account2@com", 10).
+				Build().GetPayload().GetCommands()[0],
+			nil,
+		},
+		{
+			"case 2 : granted permission to sub domain",
+			fc.NewTxBuilder().AddBalance("account1@com", "account2@sub.com", 10).
+				Build().GetPayload().GetCommands()[0],
+			nil,
+		},
+		{
+			"case 3 : not granted domain",
+			fc.NewTxBuilder().AddBalance("account1@com", "account2@pr", 10).
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorPermissionDenied,
+		},
+		{
+			"case 4 : owner can't add balance without permission",
+			fc.NewTxBuilder().AddBalance("account2@com", "account2@com", 10).
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorPermissionDenied,
+		},
+		{
+			"case 5 : owner can transfer balance",
+			fc.NewTxBuilder().TransferBalance("account2@com", "account2@com", "account1@com", 10).
+				Build().GetPayload().GetCommands()[0],
+			nil,
+		},
+		{
+			"case 6 : transfer another account's balance",
+			fc.NewTxBuilder().TransferBalance("account2@com", "account1@com", "account2@com", 10).
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorPermissionDenied,
+		},
+		{
+			"case 7 : owner can create storage in incentive sub domain",
+			fc.NewTxBuilder().CreateStorage("account1@com", "account1@incentive.com/prosl").
+				Build().GetPayload().GetCommands()[0],
+			nil,
+		},
+		{
+			"case 8 : permission storage is reserved",
+			fc.NewTxBuilder().UpdateObject("account1@com", "account1@com/"+core.PermissionStorageName,
+				core.AdminRole, fc.NewObjectBuilder().List(nil)).
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorReservedStorage,
+		},
+		{
+			"case 9 : define storage without permission",
+			fc.NewTxBuilder().DefineStorage("account1@com", "/st", fc.NewStorageBuilder().Build()).
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorPermissionDenied,
+		},
+		{
+			"case 10 : admin can define storage",
+			fc.NewTxBuilder().DefineStorage(authorizerId, "/st", fc.NewStorageBuilder().Build()).
+				Build().GetPayload().GetCommands()[0],
+			nil,
+		},
+		{
+			"case 11 : role permission",
+			fc.NewTxBuilder().BanPeer("account2@com", "peer1@peer").
+				Build().GetPayload().GetCommands()[0],
+			nil,
+		},
+		{
+			"case 12 : add peer without permission",
+			fc.NewTxBuilder().AddPeer("account1@com", "peer2@peer", "0.0.0.0:5051", RandomPublicKey()).
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorPermissionDenied,
+		},
+		{
+			"case 13 : root has all permissions",
+			fc.NewTxBuilder().AddPeer(RandomConfig().Root.Id, "peer2@peer", "0.0.0.0:5051", RandomPublicKey()).
+				Build().GetPayload().GetCommands()[0],
+			nil,
+		},
+		{
+			"case 14 : grant without grant permission",
+			fc.NewTxBuilder().GrantPermission("account1@com", "account3@com", core.AddBalancePermission, "com").
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorPermissionDenied,
+		},
+		{
+			"case 15 : admin grants permission",
+			fc.NewTxBuilder().GrantPermission(authorizerId, "account3@com", core.AddBalancePermission, "com").
+				Build().GetPayload().GetCommands()[0],
+			nil,
+		},
+		{
+			"case 16 : grant empty domain",
+			fc.NewTxBuilder().GrantPermission(authorizerId, "account3@com", core.AddBalancePermission, "").
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorGrantPermissionInvalid,
+		},
+		{
+			"case 17 : same account name in incentive sub domain is another account",
+			fc.NewTxBuilder().TransferBalance("account1@com", "account1@incentive.com", "account1@com", 10).
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorPermissionDenied,
+		},
+		{
+			"case 18 : revert prosl without permission",
+			fc.NewTxBuilder().RevertProsl("account1@com", RandomConfig().Prosl.Update.Id, 0, nil).
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorPermissionDenied,
+		},
+		{
+			"case 19 : invoke another account's prosl without permission",
+			fc.NewTxBuilder().InvokeProsl("account2@com", "account1@com/contract", nil).
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorPermissionDenied,
+		},
+		{
+			"case 20 : invoke prosl allowed by invoke_acl",
+			fc.NewTxBuilder().InvokeProsl("account2@com", "account1@com/public", nil).
+				Build().GetPayload().GetCommands()[0],
+			nil,
+		},
+		{
+			"case 21 : owner invokes own prosl",
+			fc.NewTxBuilder().InvokeProsl("account1@com", "account1@com/contract", nil).
+				Build().GetPayload().GetCommands()[0],
+			nil,
+		},
+		{
+			"case 22 : activation storage is reserved for root",
+			fc.NewTxBuilder().UpdateObject(authorizerId, core.ActivationId, core.PermissionFeature,
+				fc.NewObjectBuilder().Bool(false)).Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorReservedStorage,
+		},
+		{
+			"case 23 : granted update_object can not write another account's prosl",
+			fc.NewTxBuilder().UpdateObject("account2@com", "account1@com/contract", core.ProslKey,
+				fc.NewObjectBuilder().Data([]byte{2})).Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorPermissionDenied,
+		},
+		{
+			"case 24 : granted update_object writes another key",
+			fc.NewTxBuilder().UpdateObject("account2@com", "account1@com/contract", "memo",
+				fc.NewObjectBuilder().Str("memo")).Build().GetPayload().GetCommands()[0],
+			nil,
+		},
+		{
+			"case 25 : owner writes own prosl",
+			fc.NewTxBuilder().UpdateObject("account1@com", "account1@com/contract", core.ProslKey,
+				fc.NewObjectBuilder().Data([]byte{2})).Build().GetPayload().GetCommands()[0],
+			nil,
+		},
+		{
+			"case 26 : prosl history storage is reserved even for root",
+			fc.NewTxBuilder().UpdateObject(RandomConfig().Root.Id, core.ProslHistoryId(RandomConfig().Prosl.Consensus.Id),
+				core.ProslVersionsKey, fc.NewObjectBuilder().List(nil)).Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorReservedStorage,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.cmd.Validate(wsv)
+			if c.err != nil {
+				assert.EqualError(t, errors.Cause(err), c.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCommandValidator_Stateful(t *testing.T) {
+	fc, _, rp := prePareCommandValidator(t)
+	prePareCreateAccounts(t, fc, rp)
+	prePareAddBalance(t, fc, rp)
+	prePareCreateStorage(t, fc, rp)
+	prePareAddPeer(t, fc, rp)
+	CommitTxWrapBlock(t, rp, fc, fc.NewTxBuilder().
+		ActivatePeer(authorizerId, "peer1@com").
+		BanPeer(authorizerId, "peer2@com").
+		Build())
+
+	key := RandomPublicKey()
+	_, wsv := prePareGetDtxWSV(t, rp)
+	for _, c := range []struct {
+		name string
+		cmd  model.Command
+		err  error
+	}{
+		{
+			"case 1 : transfer balance",
+			fc.NewTxBuilder().TransferBalance(authorizerId, "account1@com", "account2@com", 100).
+				Build().GetPayload().GetCommands()[0],
+			nil,
+		},
+		{
+			"case 2 : transfer not enough balance",
+			fc.NewTxBuilder().TransferBalance(authorizerId, "account1@com", "account2@com", 101).
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandExecutorTransferBalanceNotEnoughSrcAccountBalance,
+		},
+		{
+			"case 3 : transfer negative balance",
+			fc.NewTxBuilder().TransferBalance(authorizerId, "account1@com", "account2@com", -1).
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorTransferBalanceInvalidBalance,
+		},
+		{
+			"case 4 : transfer to not exist account",
+			fc.NewTxBuilder().TransferBalance(authorizerId, "account1@com", "unk@com", 10).
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandExecutorTransferBalanceNotFoundDestAccountId,
+		},
+		{
+			"case 5 : add balance overflow",
+			fc.NewTxBuilder().AddBalance(authorizerId, authorizerId, math.MaxInt64).
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorAddBalanceOverflow,
+		},
+		{
+			"case 6 : add balance becomes negative",
+			fc.NewTxBuilder().AddBalance(authorizerId, "account3@com", -1).
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorAddBalanceOverflow,
+		},
+		{
+			"case 7 : add balance to not exist account",
+			fc.NewTxBuilder().AddBalance(authorizerId, "unk@com", 10).
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandExecutorAddBalanceNotExistAccount,
+		},
+		{
+			"case 8 : quorum greater than the number of keys",
+			fc.NewTxBuilder().SetQuorum(authorizerId, "account1@com", 1).
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorInvalidQuorum,
+		},
+		{
+			"case 9 : create account with invalid quorum",
+			fc.NewTxBuilder().CreateAccount(authorizerId, "target@com", []model.PublicKey{key}, 2).
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorInvalidQuorum,
+		},
+		{
+			"case 10 : add duplicate public keys",
+			fc.NewTxBuilder().AddPublicKeys(authorizerId, "account1@com", []model.PublicKey{key, key}).
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandExecutorAddPublicKeyDuplicatePubkey,
+		},
+		{
+			"case 11 : add empty public keys",
+			fc.NewTxBuilder().AddPublicKeys(authorizerId, "account1@com", []model.PublicKey{}).
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorAddPublicKeyEmpty,
+		},
+		{
+			"case 12 : consign to active peer",
+			fc.NewTxBuilder().Consign(authorizerId, "account1@com", "peer1@com").
+				Build().GetPayload().GetCommands()[0],
+			nil,
+		},
+		{
+			"case 13 : consign to inactive peer",
+			fc.NewTxBuilder().Consign(authorizerId, "account1@com", "peer3@com").
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorConsignInactivePeer,
+		},
+		{
+			"case 14 : consign to not exist peer",
+			fc.NewTxBuilder().Consign(authorizerId, "account1@com", "unk@com").
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorPeerNotFound,
+		},
+		{
+			"case 15 : add duplicate peer",
+			fc.NewTxBuilder().AddPeer(authorizerId, "peer1@com", "0.0.0.0:5050", RandomPublicKey()).
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorAddPeerDuplicatePeer,
+		},
+		{
+			"case 16 : activate banned peer",
+			fc.NewTxBuilder().ActivatePeer(authorizerId, "peer2@com").
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorPeerBanned,
+		},
+		{
+			"case 17 : suspend not exist peer",
+			fc.NewTxBuilder().SuspendPeer(authorizerId, "unk@com").
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorPeerNotFound,
+		},
+		{
+			"case 18 : create not defined storage",
+			fc.NewTxBuilder().CreateStorage(authorizerId, "account1@com/none").
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandExecutorCreateStorageNotDefinedStorage,
+		},
+		{
+			"case 19 : add object to not list key",
+			fc.NewTxBuilder().AddObject(authorizerId, "account1@com/land", "value", fc.NewObjectBuilder().Int64(1)).
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorAddObjectNotList,
+		},
+		{
+			"case 20 : transfer not exist object",
+			fc.NewTxBuilder().TransferObject(authorizerId, "account1@com/land", "account2@com/land", "list",
+				fc.NewObjectBuilder().Int64(1)).Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorTransferObjectNotFoundObject,
+		},
+		{
+			"case 21 : revoke not granted permission",
+			fc.NewTxBuilder().RevokePermission(authorizerId, "account1@com", core.AddBalancePermission, "com").
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandExecutorRevokePermissionNotGranted,
+		},
+		{
+			"case 22 : grant permission to not exist account",
+			fc.NewTxBuilder().GrantPermission(authorizerId, "unk@com", core.AddBalancePermission, "com").
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandExecutorGrantPermissionNotFound,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.cmd.Validate(wsv)
+			if c.err != nil {
+				assert.EqualError(t, errors.Cause(err), c.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCommandValidator_RemovePublicKeys(t *testing.T) {
+	fc, _, rp := prePareCommandValidator(t)
+	prePareCreateAccounts(t, fc, rp)
+
+	keys := []model.PublicKey{
+		RandomPublicKey(),
+		RandomPublicKey(),
+		RandomPublicKey(),
+		RandomPublicKey(),
+	}
+	CommitTxWrapBlock(t, rp, fc, fc.NewTxBuilder().
+		AddPublicKeys(authorizerId, "account1@com", []model.PublicKey{keys[0]}).
+		AddPublicKeys(authorizerId, "account1@com", []model.PublicKey{keys[1]}).
+		AddPublicKeys(authorizerId, "account1@com", []model.PublicKey{keys[2]}).
+		SetQuorum(authorizerId, "account1@com", 2).
+		AddPublicKeys(authorizerId, "account2@com", []model.PublicKey{keys[3]}).
+		Build())
+
+	_, wsv := prePareGetDtxWSV(t, rp)
+	for _, c := range []struct {
+		name string
+		cmd  model.Command
+		err  error
+	}{
+		{
+			"case 1 : no error",
+			fc.NewTxBuilder().RemovePublicKeys(authorizerId, "account1@com", []model.PublicKey{keys[0]}).
+				Build().GetPayload().GetCommands()[0],
+			nil,
+		},
+		{
+			"case 2 : owner removes own key",
+			fc.NewTxBuilder().RemovePublicKeys("account1@com", "account1@com", []model.PublicKey{keys[1]}).
+				Build().GetPayload().GetCommands()[0],
+			nil,
+		},
+		{
+			"case 3 : remove another account's key without permission",
+			fc.NewTxBuilder().RemovePublicKeys("account2@com", "account1@com", []model.PublicKey{keys[1]}).
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorPermissionDenied,
+		},
+		{
+			"case 4 : keys fall below quorum",
+			fc.NewTxBuilder().RemovePublicKeys(authorizerId, "account1@com", []model.PublicKey{keys[0], keys[1]}).
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorRemovePublicKeyUnderQuorum,
+		},
+		{
+			"case 5 : remove the last key",
+			fc.NewTxBuilder().RemovePublicKeys(authorizerId, "account2@com", []model.PublicKey{keys[3]}).
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorRemovePublicKeyLastPubkey,
+		},
+		{
+			"case 6 : not found key",
+			fc.NewTxBuilder().RemovePublicKeys(authorizerId, "account1@com", []model.PublicKey{keys[3]}).
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandExecutorRemovePublicKeyNotFoundPubkey,
+		},
+		{
+			"case 7 : duplicate key",
+			fc.NewTxBuilder().RemovePublicKeys(authorizerId, "account1@com", []model.PublicKey{keys[0], keys[0]}).
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandExecutorRemovePublicKeyNotFoundPubkey,
+		},
+		{
+			"case 8 : empty keys",
+			fc.NewTxBuilder().RemovePublicKeys(authorizerId, "account1@com", []model.PublicKey{}).
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandValidatorRemovePublicKeyEmpty,
+		},
+		{
+			"case 9 : not exist account",
+			fc.NewTxBuilder().RemovePublicKeys(authorizerId, "unk@com", []model.PublicKey{keys[0]}).
+				Build().GetPayload().GetCommands()[0],
+			core.ErrCommandExecutorRemovePublicKeyNotExistAccount,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.cmd.Validate(wsv)
+			if c.err != nil {
+				assert.EqualError(t, errors.Cause(err), c.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCommandValidator_PermissionNotActivated(t *testing.T) {
+	fc, _, rp := prePareCommandValidator(t)
+	// 機能追加前の Chain では書き込み権限を検証しない
+	CommitTxWrapBlock(t, rp, fc, fc.NewTxBuilder().
+		CreateAccount(authorizerId, authorizerId, []model.PublicKey{}, 0).
+		CreateAccount(authorizerId, "account1@com", []model.PublicKey{}, 0).
+		Build())
+
+	_, wsv := prePareGetDtxWSV(t, rp)
+	assert.NoError(t, fc.NewTxBuilder().AddBalance("account1@com", authorizerId, 10).
+		Build().GetPayload().GetCommands()[0].Validate(wsv))
+	err := fc.NewTxBuilder().DefineStorage("account1@com", core.ActivationStorageId, fc.NewStorageBuilder().Build()).
+		Build().GetPayload().GetCommands()[0].Validate(wsv)
+	assert.EqualError(t, errors.Cause(err), core.ErrCommandValidatorReservedStorage.Error())
+}
+
+func TestCommandValidator_StatefulNotActivated(t *testing.T) {
+	fc, _, rp := prePareCommandValidator(t)
+	// 機能追加前の Chain では State に基づく事前検証をしない
+	CommitTxWrapBlock(t, rp, fc, fc.NewTxBuilder().
+		CreateAccount(authorizerId, authorizerId, []model.PublicKey{}, 0).
+		CreateAccount(authorizerId, "account1@com", []model.PublicKey{}, 0).
+		Build())
+
+	_, wsv := prePareGetDtxWSV(t, rp)
+	for _, cmd := range fc.NewTxBuilder().
+		SetQuorum("account1@com", "account1@com", 2).
+		TransferBalance("account1@com", "account1@com", authorizerId, 100).
+		ActivatePeer(authorizerId, "peer1@com").
+		Build().GetPayload().GetCommands() {
+		assert.NoError(t, cmd.Validate(wsv))
+	}
+}
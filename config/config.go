@@ -8,14 +8,15 @@ import (
 )
 
 type Config struct {
-	DB     DBConfig     `yaml:"db"`
-	Queue  QueueConfig  `yaml:"queue"`
-	Cache  CacheConfig  `yaml:"cache"`
-	Commit CommitConfig `yaml:"commit"`
-	Peer   PeerConfig   `yaml:"peer"`
-	Sync   SyncConfig   `yaml:"sync"`
-	Prosl  ProslConfig  `yaml:"prosl"`
-	Root   RootConfig   `yaml:"root"`
+	DB       DBConfig       `yaml:"db"`
+	Queue    QueueConfig    `yaml:"queue"`
+	Cache    CacheConfig    `yaml:"cache"`
+	Commit   CommitConfig   `yaml:"commit"`
+	TxStatus TxStatusConfig `yaml:"tx_status"`
+	Peer     PeerConfig     `yaml:"peer"`
+	Sync     SyncConfig     `yaml:"sync"`
+	Prosl    ProslConfig    `yaml:"prosl"`
+	Root     RootConfig     `yaml:"root"`
 }
 
 type QueueConfig struct {
@@ -39,6 +40,13 @@ type CommitConfig struct {
 	NumTxInBlock int `yaml:"num_tx_in_block"`
 }
 
+type TxStatusConfig struct {
+	// Queued のまま経過すると Expired とする Block 数、0 以下なら Expired にしない
+	ExpireBlocks int64 `yaml:"expire_blocks"`
+	// 確定した status (Committed, Rejected, Expired) を保持する Block 数、0 以下なら削除しない
+	RetentionBlocks int64 `yaml:"retention_blocks"`
+}
+
 type PeerConfig struct {
 	Id         string `yaml:"id"`
 	PublicKey  string `yaml:"public_key"`
@@ -54,11 +62,11 @@ type SyncConfig struct {
 }
 
 type ProslConfig struct {
-	Id        string             `yaml:"id"`
-	Genesis   DefaultProslConfig `yaml:"genesis"`
-	Incentive DefaultProslConfig `yaml:"incentive"`
-	Consensus DefaultProslConfig `yaml:"consensus"`
-	Update    DefaultProslConfig `yaml:"update"`
+	Id        string               `yaml:"id"`
+	Genesis   DefaultProslConfig   `yaml:"genesis"`
+	Incentive DefaultProslConfig   `yaml:"incentive"`
+	Consensus ConsensusProslConfig `yaml:"consensus"`
+	Update    DefaultProslConfig   `yaml:"update"`
 }
 
 type DefaultProslConfig struct {
@@ -66,6 +74,13 @@ type DefaultProslConfig struct {
 	Id   string `yaml:"id"`
 }
 
+type ConsensusProslConfig struct {
+	DefaultProslConfig `yaml:",inline"`
+	// genesis で consensus prosl の Storage に保存する Block 生成者の最小数。
+	// 以降は Chain 上の値を使い、consensus prosl の結果がこれに満たなければ失敗とする
+	MinSize int `yaml:"min_size"`
+}
+
 type RootConfig struct {
 	Id string `yaml:"id" default:"root@root"`
 }
@@ -18,6 +18,9 @@ func TestNewConfig(t *testing.T) {
 	assert.Equal(t, conf.Commit.WaitInterval, 1000)
 	assert.Equal(t, conf.Commit.NumTxInBlock, 1000)
 
+	assert.Equal(t, conf.TxStatus.ExpireBlocks, int64(50))
+	assert.Equal(t, conf.TxStatus.RetentionBlocks, int64(100))
+
 	assert.Equal(t, conf.Queue.TxsLimits, 1000)
 	assert.Equal(t, conf.Queue.BlockLimits, 30)
 
@@ -37,6 +40,7 @@ func TestNewConfig(t *testing.T) {
 	assert.Equal(t, conf.Prosl.Incentive.Id, "incentive/prosl")
 	assert.Equal(t, conf.Prosl.Consensus.Id, "consensus/prosl")
 	assert.Equal(t, conf.Prosl.Update.Id, "update/prosl")
+	assert.Equal(t, conf.Prosl.Consensus.MinSize, 1)
 
 	assert.Equal(t, conf.Prosl.Genesis.Path, "./grpc_test/genesis.yaml")
 	assert.Equal(t, conf.Prosl.Incentive.Path, "./grpc_test/incentive.yaml")
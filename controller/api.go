@@ -11,6 +11,7 @@ import (
 	"golang.org/x/net/context"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"time"
 )
 
 // APIServer is the server API for API service.
@@ -47,6 +48,36 @@ func (s *APIServer) Write(ctx context.Context, tx *proskenion.Transaction) (*pro
 	return &proskenion.TxResponse{}, nil
 }
 
+func (s *APIServer) WriteWithOption(ctx context.Context, req *proskenion.WriteRequest) (*proskenion.TxResponse, error) {
+	if !req.GetWaitForCommit() {
+		return s.Write(ctx, req.GetTransaction())
+	}
+	modelTx := s.fc.NewEmptyTx()
+	modelTx.(*convertor.Transaction).Transaction = req.GetTransaction()
+
+	s.logger.Debug(fmt.Sprintf("API Server WriteWithOption : %+v", req))
+	res, err := s.api.WriteAndWait(modelTx, time.Duration(req.GetTimeout())*time.Millisecond)
+	if err != nil {
+		s.logger.Error(err.Error())
+		switch errors.Cause(err) {
+		case core.ErrAPIWriteVerifyError:
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case core.ErrAPIWriteTxAlreadyExist:
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		case core.ErrAPIWriteRejected, core.ErrAPIWriteExpired:
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		case core.ErrAPIWriteTimeout:
+			return nil, status.Error(codes.DeadlineExceeded, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &proskenion.TxResponse{
+		BlockHash: res.BlockHash,
+		Height:    res.Height,
+		Receipt:   res.Receipt.(*convertor.Receipt).Receipt,
+	}, nil
+}
+
 func (s *APIServer) Read(ctx context.Context, query *proskenion.Query) (*proskenion.QueryResponse, error) {
 	modelQuery := s.fc.NewEmptyQuery()
 	modelQuery.(*convertor.Query).Query = query
@@ -66,3 +97,133 @@ func (s *APIServer) Read(ctx context.Context, query *proskenion.Query) (*prosken
 	}
 	return res.(*convertor.QueryResponse).QueryResponse, nil
 }
+
+func (s *APIServer) ReadBatch(ctx context.Context, batch *proskenion.BatchQuery) (*proskenion.BatchQueryResponse, error) {
+	queries := make([]model.Query, 0, len(batch.GetQueries()))
+	for _, query := range batch.GetQueries() {
+		modelQuery := s.fc.NewEmptyQuery()
+		modelQuery.(*convertor.Query).Query = query
+		queries = append(queries, modelQuery)
+	}
+
+	s.logger.Debug(fmt.Sprintf("API Server ReadBatch : %d queries", len(queries)))
+	res, err := s.api.ReadBatch(queries)
+	if err != nil {
+		s.logger.Error(err.Error())
+		if errors.Cause(err) == core.ErrAPIQueryBatchTooLarge {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if errors.Cause(err) == core.ErrAPIQueryNotFound {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return res.(*convertor.BatchQueryResponse).BatchQueryResponse, nil
+}
+
+func (s *APIServer) GetReceipt(ctx context.Context, req *proskenion.ReceiptRequest) (*proskenion.Receipt, error) {
+	s.logger.Debug(fmt.Sprintf("API Server GetReceipt : %x", req.GetTxHash()))
+	receipt, err := s.api.GetReceipt(req.GetTxHash())
+	if err != nil {
+		s.logger.Error(err.Error())
+		if errors.Cause(err) == core.ErrAPIReceiptNotFound {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return receipt.(*convertor.Receipt).Receipt, nil
+}
+
+func newTxStatus(txStatus core.TxStatus) *proskenion.TxStatus {
+	return &proskenion.TxStatus{
+		Code:   proskenion.TxStatusCode(txStatus.Code),
+		Height: txStatus.Height,
+		Reason: txStatus.Reason,
+	}
+}
+
+func (s *APIServer) GetTxStatus(ctx context.Context, req *proskenion.TxStatusRequest) (*proskenion.TxStatus, error) {
+	s.logger.Debug(fmt.Sprintf("API Server GetTxStatus : %x", req.GetTxHash()))
+	ret, err := s.api.GetTxStatus(req.GetTxHash())
+	if err != nil {
+		s.logger.Error(err.Error())
+		if errors.Cause(err) == core.ErrAPITxStatusNotFound {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return newTxStatus(ret), nil
+}
+
+func (s *APIServer) GetProslMetrics(ctx context.Context, req *proskenion.ProslMetricsRequest) (*proskenion.ProslMetrics, error) {
+	s.logger.Debug(fmt.Sprintf("API Server GetProslMetrics : %s", req.GetProslId()))
+	ret, err := s.api.GetProslMetrics(req.GetProslId())
+	if err != nil {
+		s.logger.Error(err.Error())
+		if errors.Cause(err) == core.ErrAPIProslMetricsInvalidArgument {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &proskenion.ProslMetrics{
+		Failures:  ret.Failures,
+		Fallbacks: ret.Fallbacks,
+		Defaults:  ret.Defaults,
+	}, nil
+}
+
+func (s *APIServer) newSubscribeResponse(event core.SubscribeEvent) *proskenion.SubscribeResponse {
+	res := &proskenion.SubscribeResponse{
+		Height:    event.Height,
+		BlockHash: event.BlockHash,
+	}
+	if event.Block != nil {
+		res.Event = &proskenion.SubscribeResponse_Block{Block: event.Block.(*convertor.Block).Block}
+	} else if event.Transaction != nil {
+		res.Event = &proskenion.SubscribeResponse_Transaction{Transaction: event.Transaction.(*convertor.Transaction).Transaction}
+	} else if event.Object != nil {
+		res.Event = &proskenion.SubscribeResponse_Object{Object: event.Object.(*convertor.Object).Object}
+	} else if event.TxStatus != nil {
+		res.Event = &proskenion.SubscribeResponse_TxStatus{TxStatus: newTxStatus(*event.TxStatus)}
+	}
+	return res
+}
+
+func (s *APIServer) Subscribe(req *proskenion.SubscribeRequest, stream proskenion.API_SubscribeServer) error {
+	s.logger.Debug(fmt.Sprintf("API Server Subscribe : %+v", req))
+	sub := core.Subscription{
+		Code:         core.SubscribeCode(req.GetCode()),
+		FromHeight:   req.GetFromHeight(),
+		AuthorizerId: req.GetAuthorizerId(),
+		TargetId:     req.GetTargetId(),
+		Prefix:       req.GetPrefix(),
+		TxHash:       req.GetTxHash(),
+	}
+	done := stream.Context().Done()
+	eventChan := make(chan core.SubscribeEvent)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- s.api.Subscribe(sub, done, eventChan)
+	}()
+	for {
+		select {
+		case event := <-eventChan:
+			if err := stream.Send(s.newSubscribeResponse(event)); err != nil {
+				s.logger.Error(err.Error())
+				return status.Error(codes.Internal, err.Error())
+			}
+		case err := <-errChan:
+			if err != nil {
+				s.logger.Error(err.Error())
+				if errors.Cause(err) == core.ErrAPISubscribeInvalidArgument {
+					return status.Error(codes.InvalidArgument, err.Error())
+				}
+				if errors.Cause(err) == core.ErrAPISubscribeReadDenied {
+					return status.Error(codes.PermissionDenied, err.Error())
+				}
+				return status.Error(codes.Internal, err.Error())
+			}
+			return nil
+		}
+	}
+}
@@ -151,3 +151,27 @@ func TestAPIServer_Query(t *testing.T) {
 		})
 	}
 }
+
+func TestAPIServer_GetReceipt(t *testing.T) {
+	acs, _, server := initializeAPI(t)
+
+	// GenesisCommitFromAccounts で commit された tx
+	builder := RandomFactory().NewTxBuilder()
+	for _, ac := range acs {
+		builder = builder.CreateAccount("root@com", ac.AccountId, []model.PublicKey{ac.Pubkey}, 1)
+	}
+	genTx := builder.Build()
+
+	res, err := server.GetReceipt(context.TODO(), &proskenion.ReceiptRequest{TxHash: genTx.Hash()})
+	require.NoError(t, err)
+	assert.Equal(t, []byte(genTx.Hash()), res.GetTxHash())
+	require.Equal(t, len(acs), len(res.GetEvents()))
+	for i, event := range res.GetEvents() {
+		assert.Equal(t, "create_account", event.GetType())
+		assert.Equal(t, "root@com", event.GetAuthorizerId())
+		assert.Equal(t, acs[i].AccountId, event.GetTargetId())
+	}
+
+	_, err = server.GetReceipt(context.TODO(), &proskenion.ReceiptRequest{TxHash: RandomByte()})
+	statusCheck(t, err, codes.NotFound)
+}
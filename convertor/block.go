@@ -66,6 +66,8 @@ func (b *Block) GetFromKey(key string) model.Object {
 		return BytesObject(b.GetPayload().GetTxHistoryHash(), b.cryptor)
 	case "txs_hash", "txs":
 		return BytesObject(b.GetPayload().GetTxListHash(), b.cryptor)
+	case "receipts_hash", "receipts":
+		return BytesObject(b.GetPayload().GetReceiptsHash(), b.cryptor)
 	case "round":
 		return Int32Object(b.GetPayload().GetRound(), b.cryptor)
 	}
@@ -116,3 +118,10 @@ func (p *BlockPayload) GetTxListHash() model.Hash {
 	}
 	return p.Block_Payload.GetTxListHash()
 }
+
+func (p *BlockPayload) GetReceiptsHash() model.Hash {
+	if p.Block_Payload == nil {
+		return nil
+	}
+	return p.Block_Payload.GetReceiptsHash()
+}
@@ -14,7 +14,18 @@ type Command struct {
 	cryptor   core.Cryptor
 }
 
+// Execute は Command を実行し、wsv が EventEmitter であれば Command のイベントを発行する
 func (c *Command) Execute(wsv model.ObjectFinder) error {
+	if err := c.execute(wsv); err != nil {
+		return err
+	}
+	if em, ok := wsv.(model.EventEmitter); ok {
+		em.Emit(c.event())
+	}
+	return nil
+}
+
+func (c *Command) execute(wsv model.ObjectFinder) error {
 	switch x := c.GetCommand().(type) {
 	case *proskenion.Command_TransferBalance:
 		return c.executor.TransferBalance(wsv, c)
@@ -26,6 +37,8 @@ func (c *Command) Execute(wsv model.ObjectFinder) error {
 		return c.executor.SetQuorum(wsv, c)
 	case *proskenion.Command_AddPublicKeys:
 		return c.executor.AddPublicKeys(wsv, c)
+	case *proskenion.Command_RemovePublicKeys:
+		return c.executor.RemovePublicKeys(wsv, c)
 	case *proskenion.Command_DefineStorage:
 		return c.executor.DefineStorage(wsv, c)
 	case *proskenion.Command_CreateStorage:
@@ -48,6 +61,14 @@ func (c *Command) Execute(wsv model.ObjectFinder) error {
 		return c.executor.Consign(wsv, c)
 	case *proskenion.Command_CheckAndCommitProsl:
 		return c.executor.CheckAndCommitProsl(wsv, c)
+	case *proskenion.Command_InvokeProsl:
+		return c.executor.InvokeProsl(wsv, c)
+	case *proskenion.Command_RevertProsl:
+		return c.executor.RevertProsl(wsv, c)
+	case *proskenion.Command_GrantPermission:
+		return c.executor.GrantPermission(wsv, c)
+	case *proskenion.Command_RevokePermission:
+		return c.executor.RevokePermission(wsv, c)
 	case *proskenion.Command_ForceUpdateStorage:
 		return c.executor.ForceUpdateStorage(wsv, c)
 	default:
@@ -55,6 +76,96 @@ func (c *Command) Execute(wsv model.ObjectFinder) error {
 	}
 }
 
+func (c *Command) object(o *proskenion.Object) model.Object {
+	if o == nil {
+		o = &proskenion.Object{}
+	}
+	return &Object{c.cryptor, c.executor, c.validator, o}
+}
+
+// event は Command の種類と主要なパラメータをイベントにまとめる
+func (c *Command) event() model.Event {
+	var eventType string
+	attributes := make(map[string]model.Object)
+	switch x := c.GetCommand().(type) {
+	case *proskenion.Command_TransferBalance:
+		eventType = "transfer_balance"
+		attributes["dest_account_id"] = AddressObject(x.TransferBalance.GetDestAccountId(), c.cryptor)
+		attributes["balance"] = Int64Object(x.TransferBalance.GetBalance(), c.cryptor)
+	case *proskenion.Command_AddBalance:
+		eventType = "add_balance"
+		attributes["balance"] = Int64Object(x.AddBalance.GetBalance(), c.cryptor)
+	case *proskenion.Command_CreateAccount:
+		eventType = "create_account"
+		attributes["quorum"] = Int32Object(x.CreateAccount.GetQuorum(), c.cryptor)
+	case *proskenion.Command_SetQuorum:
+		eventType = "set_quorum"
+		attributes["quorum"] = Int32Object(x.SetQuorum.GetQuorum(), c.cryptor)
+	case *proskenion.Command_AddPublicKeys:
+		eventType = "add_public_keys"
+		attributes["public_keys"] = PublicKeysToListObject(c.GetAddPublicKeys().GetPublicKeys(), c.cryptor)
+	case *proskenion.Command_RemovePublicKeys:
+		eventType = "remove_public_keys"
+		attributes["public_keys"] = PublicKeysToListObject(c.GetRemovePublicKeys().GetPublicKeys(), c.cryptor)
+	case *proskenion.Command_DefineStorage:
+		eventType = "define_storage"
+	case *proskenion.Command_CreateStorage:
+		eventType = "create_storage"
+	case *proskenion.Command_UpdateObject:
+		eventType = "update_object"
+		attributes["key"] = StrObject(x.UpdateObject.GetKey(), c.cryptor)
+		attributes["object"] = c.object(x.UpdateObject.GetObject())
+	case *proskenion.Command_AddObject:
+		eventType = "add_object"
+		attributes["key"] = StrObject(x.AddObject.GetKey(), c.cryptor)
+		attributes["object"] = c.object(x.AddObject.GetObject())
+	case *proskenion.Command_TransferObject:
+		eventType = "transfer_object"
+		attributes["key"] = StrObject(x.TransferObject.GetKey(), c.cryptor)
+		attributes["dest_account_id"] = AddressObject(x.TransferObject.GetDestAccountId(), c.cryptor)
+		attributes["object"] = c.object(x.TransferObject.GetObject())
+	case *proskenion.Command_AddPeer:
+		eventType = "add_peer"
+		attributes["address"] = StrObject(x.AddPeer.GetAddress(), c.cryptor)
+		attributes["public_key"] = BytesObject(x.AddPeer.GetPublicKey(), c.cryptor)
+	case *proskenion.Command_ActivatePeer:
+		eventType = "activate_peer"
+	case *proskenion.Command_SuspendPeer:
+		eventType = "suspend_peer"
+	case *proskenion.Command_BanPeer:
+		eventType = "ban_peer"
+	case *proskenion.Command_Consign:
+		eventType = "consign"
+		attributes["peer_id"] = AddressObject(x.Consign.GetPeerId(), c.cryptor)
+	case *proskenion.Command_CheckAndCommitProsl:
+		eventType = "check_and_commit_prosl"
+	case *proskenion.Command_InvokeProsl:
+		eventType = "invoke_prosl"
+	case *proskenion.Command_RevertProsl:
+		eventType = "revert_prosl"
+		attributes["version"] = Int64Object(x.RevertProsl.GetVersion(), c.cryptor)
+	case *proskenion.Command_GrantPermission:
+		eventType = "grant_permission"
+		attributes["permission"] = StrObject(x.GrantPermission.GetPermission(), c.cryptor)
+		attributes["domain"] = StrObject(x.GrantPermission.GetDomain(), c.cryptor)
+	case *proskenion.Command_RevokePermission:
+		eventType = "revoke_permission"
+		attributes["permission"] = StrObject(x.RevokePermission.GetPermission(), c.cryptor)
+		attributes["domain"] = StrObject(x.RevokePermission.GetDomain(), c.cryptor)
+	case *proskenion.Command_ForceUpdateStorage:
+		eventType = "force_update_storage"
+	}
+	return &Event{
+		&proskenion.Event{
+			Type:         eventType,
+			AuthorizerId: c.GetAuthorizerId(),
+			TargetId:     c.GetTargetId(),
+			Attributes:   ProslObjectMapsFromObjectMaps(attributes),
+		},
+		c.cryptor, c.executor, c.validator,
+	}
+}
+
 func (c *Command) Validate(wsv model.ObjectFinder) error {
 	switch x := c.GetCommand().(type) {
 	case *proskenion.Command_TransferBalance:
@@ -64,9 +175,11 @@ func (c *Command) Validate(wsv model.ObjectFinder) error {
 	case *proskenion.Command_CreateAccount:
 		return c.validator.CreateAccount(wsv, c)
 	case *proskenion.Command_SetQuorum:
-		return c.executor.SetQuorum(wsv, c)
+		return c.validator.SetQuorum(wsv, c)
 	case *proskenion.Command_AddPublicKeys:
 		return c.validator.AddPublicKeys(wsv, c)
+	case *proskenion.Command_RemovePublicKeys:
+		return c.validator.RemovePublicKeys(wsv, c)
 	case *proskenion.Command_DefineStorage:
 		return c.validator.DefineStorage(wsv, c)
 	case *proskenion.Command_CreateStorage:
@@ -89,6 +202,14 @@ func (c *Command) Validate(wsv model.ObjectFinder) error {
 		return c.validator.Consign(wsv, c)
 	case *proskenion.Command_CheckAndCommitProsl:
 		return c.validator.CheckAndCommitProsl(wsv, c)
+	case *proskenion.Command_InvokeProsl:
+		return c.validator.InvokeProsl(wsv, c)
+	case *proskenion.Command_RevertProsl:
+		return c.validator.RevertProsl(wsv, c)
+	case *proskenion.Command_GrantPermission:
+		return c.validator.GrantPermission(wsv, c)
+	case *proskenion.Command_RevokePermission:
+		return c.validator.RevokePermission(wsv, c)
 	case *proskenion.Command_ForceUpdateStorage:
 		return c.validator.ForceUpdateStorage(wsv, c)
 	default:
@@ -252,6 +373,44 @@ func (c *CheckAndCommitProsl) GetVariables() map[string]model.Object {
 	return ObjectMapsFromProslObjectMaps(c.c, c.e, c.v, c.CheckAndCommitProsl.Variables)
 }
 
+type InvokeProsl struct {
+	c core.Cryptor
+	e core.CommandExecutor
+	v core.CommandValidator
+	*proskenion.InvokeProsl
+}
+
+func (c *Command) GetInvokeProsl() model.InvokeProsl {
+	return &InvokeProsl{c.cryptor, c.executor, c.validator, c.Command.GetInvokeProsl()}
+}
+
+func (c *InvokeProsl) GetVariables() map[string]model.Object {
+	return ObjectMapsFromProslObjectMaps(c.c, c.e, c.v, c.InvokeProsl.Variables)
+}
+
+type RevertProsl struct {
+	c core.Cryptor
+	e core.CommandExecutor
+	v core.CommandValidator
+	*proskenion.RevertProsl
+}
+
+func (c *Command) GetRevertProsl() model.RevertProsl {
+	return &RevertProsl{c.cryptor, c.executor, c.validator, c.Command.GetRevertProsl()}
+}
+
+func (c *RevertProsl) GetVariables() map[string]model.Object {
+	return ObjectMapsFromProslObjectMaps(c.c, c.e, c.v, c.RevertProsl.GetVariables())
+}
+
+func (c *Command) GetGrantPermission() model.GrantPermission {
+	return c.Command.GetGrantPermission()
+}
+
+func (c *Command) GetRevokePermission() model.RevokePermission {
+	return c.Command.GetRevokePermission()
+}
+
 type ForceUpdateStorage struct {
 	c core.Cryptor
 	e core.CommandExecutor
@@ -484,6 +484,40 @@ func (f *ModelFactory) NewEmptyQueryResponse() model.QueryResponse {
 	return f.NewQueryResponseBuilder().Build()
 }
 
+func (f *ModelFactory) NewEmptyBatchQueryResponse() model.BatchQueryResponse {
+	return f.NewBatchQueryResponseBuilder().Build()
+}
+
+func (f *ModelFactory) NewEmptyReceipt() model.Receipt {
+	return f.NewReceipt(nil, nil)
+}
+
+func (f *ModelFactory) NewEvent(eventType string, authorizerId string, targetId string, attributes map[string]model.Object) model.Event {
+	return &Event{
+		&proskenion.Event{
+			Type:         eventType,
+			AuthorizerId: authorizerId,
+			TargetId:     targetId,
+			Attributes:   ProslObjectMapsFromObjectMaps(attributes),
+		},
+		f.cryptor, f.executor, f.commandValidator,
+	}
+}
+
+func (f *ModelFactory) NewReceipt(txHash model.Hash, events []model.Event) model.Receipt {
+	pevents := make([]*proskenion.Event, 0, len(events))
+	for _, event := range events {
+		pevents = append(pevents, event.(*Event).Event)
+	}
+	return &Receipt{
+		&proskenion.Receipt{
+			TxHash: txHash,
+			Events: pevents,
+		},
+		f.cryptor, f.executor, f.commandValidator,
+	}
+}
+
 func (f *ModelFactory) NewBlockBuilder() model.BlockBuilder {
 	return &BlockBuilder{
 		&proskenion.Block{
@@ -527,6 +561,16 @@ func (f *ModelFactory) NewQueryResponseBuilder() model.QueryResponseBuilder {
 	}
 }
 
+func (f *ModelFactory) NewBatchQueryResponseBuilder() model.BatchQueryResponseBuilder {
+	return &BatchQueryResponseBuilder{
+		&proskenion.BatchQueryResponse{
+			Payload:   &proskenion.BatchQueryResponse_Payload{},
+			Signature: &proskenion.Signature{},
+		},
+		f.cryptor,
+	}
+}
+
 type BlockBuilder struct {
 	*proskenion.Block
 	cryptor core.Cryptor
@@ -556,6 +600,10 @@ func (b *BlockBuilder) TxListHash(hash model.Hash) model.BlockBuilder {
 	b.Block.Payload.TxListHash = hash
 	return b
 }
+func (b *BlockBuilder) ReceiptsHash(hash model.Hash) model.BlockBuilder {
+	b.Block.Payload.ReceiptsHash = hash
+	return b
+}
 
 func (b *BlockBuilder) Round(round int32) model.BlockBuilder {
 	b.Block.Payload.Round = round
@@ -811,6 +859,54 @@ func (t *TxBuilder) CheckAndCommitProsl(authorizerId string, proslId string, par
 	return t
 }
 
+func (t *TxBuilder) InvokeProsl(authorizerId string, proslId string, params map[string]model.Object) model.TxBuilder {
+	t.Payload.Commands = append(t.Payload.Commands,
+		&proskenion.Command{
+			Command: &proskenion.Command_InvokeProsl{
+				InvokeProsl: &proskenion.InvokeProsl{Variables: ProslObjectMapsFromObjectMaps(params)},
+			},
+			TargetId:     proslId,
+			AuthorizerId: authorizerId,
+		})
+	return t
+}
+
+func (t *TxBuilder) RevertProsl(authorizerId string, proslId string, version int64, params map[string]model.Object) model.TxBuilder {
+	t.Payload.Commands = append(t.Payload.Commands,
+		&proskenion.Command{
+			Command: &proskenion.Command_RevertProsl{
+				RevertProsl: &proskenion.RevertProsl{Version: version, Variables: ProslObjectMapsFromObjectMaps(params)},
+			},
+			TargetId:     proslId,
+			AuthorizerId: authorizerId,
+		})
+	return t
+}
+
+func (t *TxBuilder) GrantPermission(authorizerId string, accountId string, permission string, domain string) model.TxBuilder {
+	t.Payload.Commands = append(t.Payload.Commands,
+		&proskenion.Command{
+			Command: &proskenion.Command_GrantPermission{
+				GrantPermission: &proskenion.GrantPermission{Permission: permission, Domain: domain},
+			},
+			TargetId:     accountId,
+			AuthorizerId: authorizerId,
+		})
+	return t
+}
+
+func (t *TxBuilder) RevokePermission(authorizerId string, accountId string, permission string, domain string) model.TxBuilder {
+	t.Payload.Commands = append(t.Payload.Commands,
+		&proskenion.Command{
+			Command: &proskenion.Command_RevokePermission{
+				RevokePermission: &proskenion.RevokePermission{Permission: permission, Domain: domain},
+			},
+			TargetId:     accountId,
+			AuthorizerId: authorizerId,
+		})
+	return t
+}
+
 func (t *TxBuilder) ForceUpdateStorage(authorizerId string, targetId string, storage model.Storage) model.TxBuilder {
 	t.Payload.Commands = append(t.Payload.Commands,
 		&proskenion.Command{
@@ -882,6 +978,43 @@ func (q *QueryBuilder) RequestCode(code model.ObjectCode) model.QueryBuilder {
 	return q
 }
 
+func (q *QueryBuilder) Cursor(lastKey []byte, order model.OrderCode, blockHash model.Hash) model.QueryBuilder {
+	q.Payload.Cursor = &proskenion.Cursor{
+		LastKey:   lastKey,
+		Order:     proskenion.OrderCode(order),
+		BlockHash: blockHash,
+	}
+	return q
+}
+
+func (q *QueryBuilder) AtBlockHash(hash model.Hash) model.QueryBuilder {
+	q.Payload.At = &proskenion.QueryAt{BlockHash: hash}
+	return q
+}
+
+func (q *QueryBuilder) AtHeight(height int64) model.QueryBuilder {
+	q.Payload.At = &proskenion.QueryAt{Height: height}
+	return q
+}
+
+func (q *QueryBuilder) TxHash(hash model.Hash) model.QueryBuilder {
+	q.Payload.TxHash = hash
+	return q
+}
+
+func (q *QueryBuilder) Aggregate(code model.AggregateCode, key string) model.QueryBuilder {
+	q.Payload.Aggregates = append(q.Payload.Aggregates, &proskenion.Aggregate{
+		Code: proskenion.AggregateCode(code),
+		Key:  key,
+	})
+	return q
+}
+
+func (q *QueryBuilder) GroupBy(key string) model.QueryBuilder {
+	q.Payload.GroupBy = key
+	return q
+}
+
 func (q *QueryBuilder) Build() model.Query {
 	return &Query{q.Query, q.cryptor, q.verifier}
 }
@@ -934,6 +1067,45 @@ func (q *QueryResponseBuilder) Object(o model.Object) model.QueryResponseBuilder
 	return q
 }
 
+func (q *QueryResponseBuilder) NextCursor(lastKey []byte, order model.OrderCode, blockHash model.Hash) model.QueryResponseBuilder {
+	q.QueryResponse.NextCursor = &proskenion.Cursor{
+		LastKey:   lastKey,
+		Order:     proskenion.OrderCode(order),
+		BlockHash: blockHash,
+	}
+	return q
+}
+
 func (q *QueryResponseBuilder) Build() model.QueryResponse {
 	return &QueryResponse{q.QueryResponse, q.cryptor}
 }
+
+type BatchQueryResponseBuilder struct {
+	*proskenion.BatchQueryResponse
+	cryptor core.Cryptor
+}
+
+func (q *BatchQueryResponseBuilder) Result(res model.QueryResponse, err error) model.BatchQueryResponseBuilder {
+	ret := &proskenion.BatchQueryResult{}
+	if err != nil {
+		ret.Error = err.Error()
+	} else {
+		ret.Response = res.(*QueryResponse).QueryResponse
+	}
+	q.Payload.Results = append(q.Payload.Results, ret)
+	return q
+}
+
+func (q *BatchQueryResponseBuilder) BlockHash(hash model.Hash) model.BatchQueryResponseBuilder {
+	q.Payload.BlockHash = hash
+	return q
+}
+
+func (q *BatchQueryResponseBuilder) Height(height int64) model.BatchQueryResponseBuilder {
+	q.Payload.Height = height
+	return q
+}
+
+func (q *BatchQueryResponseBuilder) Build() model.BatchQueryResponse {
+	return &BatchQueryResponse{q.BatchQueryResponse, q.cryptor}
+}
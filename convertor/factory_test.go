@@ -414,6 +414,7 @@ func TestModelFactory_NewQueryBuilder(t *testing.T) {
 			Where("key > 10").
 			Limit(10).
 			RequestCode(model.AccountObjectCode).
+			Cursor([]byte("c"), model.ASC, model.Hash("block")).
 			Build()
 		assert.Equal(t, int64(1), query.GetPayload().GetCreatedTime())
 		assert.Equal(t, "a", query.GetPayload().GetFromId())
@@ -423,6 +424,9 @@ func TestModelFactory_NewQueryBuilder(t *testing.T) {
 		assert.Equal(t, model.OrderCode(model.DESC), query.GetPayload().GetOrderBy().GetOrder())
 		assert.Equal(t, "key > 10", query.GetPayload().GetWhere())
 		assert.Equal(t, int32(10), query.GetPayload().GetLimit())
+		assert.Equal(t, []byte("c"), query.GetPayload().GetCursor().GetLastKey())
+		assert.Equal(t, model.OrderCode(model.ASC), query.GetPayload().GetCursor().GetOrder())
+		assert.Equal(t, model.Hash("block"), query.GetPayload().GetCursor().GetBlockHash())
 		assert.Equal(t, model.AccountObjectCode, query.GetPayload().GetRequestCode())
 	})
 }
@@ -81,6 +81,38 @@ func (p *QueryPaylaod) GetOrderBy() model.OrderBy {
 	return &OrderBy{p.OrderBy}
 }
 
+func (p *QueryPaylaod) GetCursor() model.Cursor {
+	if p.Query_Payload == nil || p.Cursor == nil {
+		return nil
+	}
+	return &Cursor{p.Cursor}
+}
+
+func (p *QueryPaylaod) GetAt() model.QueryAt {
+	if p.Query_Payload == nil || p.At == nil {
+		return nil
+	}
+	return &QueryAt{p.At}
+}
+
+func (p *QueryPaylaod) GetTxHash() model.Hash {
+	if p.Query_Payload == nil {
+		return nil
+	}
+	return model.Hash(p.TxHash)
+}
+
+func (p *QueryPaylaod) GetAggregates() []model.Aggregate {
+	if p.Query_Payload == nil {
+		return nil
+	}
+	ret := make([]model.Aggregate, 0, len(p.Aggregates))
+	for _, a := range p.Aggregates {
+		ret = append(ret, &Aggregate{a})
+	}
+	return ret
+}
+
 func (p *QueryPaylaod) Marshal() ([]byte, error) {
 	return proto.Marshal(p.Query_Payload)
 }
@@ -104,6 +136,46 @@ func (o *OrderBy) GetOrder() model.OrderCode {
 	return model.OrderCode(o.Order)
 }
 
+type Cursor struct {
+	*proskenion.Cursor
+}
+
+func (c *Cursor) GetOrder() model.OrderCode {
+	if c.Cursor == nil {
+		return model.OrderCode(0)
+	}
+	return model.OrderCode(c.Order)
+}
+
+func (c *Cursor) GetBlockHash() model.Hash {
+	if c.Cursor == nil || len(c.BlockHash) == 0 {
+		return nil
+	}
+	return model.Hash(c.BlockHash)
+}
+
+type Aggregate struct {
+	*proskenion.Aggregate
+}
+
+func (a *Aggregate) GetCode() model.AggregateCode {
+	if a.Aggregate == nil {
+		return model.CountAggregate
+	}
+	return model.AggregateCode(a.Code)
+}
+
+type QueryAt struct {
+	*proskenion.QueryAt
+}
+
+func (a *QueryAt) GetBlockHash() model.Hash {
+	if a.QueryAt == nil || len(a.BlockHash) == 0 {
+		return nil
+	}
+	return model.Hash(a.BlockHash)
+}
+
 type QueryResponse struct {
 	*proskenion.QueryResponse
 	cryptor core.Cryptor
@@ -123,6 +195,13 @@ func (q *QueryResponse) GetSignature() model.Signature {
 	return &Signature{q.QueryResponse.GetSignature()}
 }
 
+func (q *QueryResponse) GetNextCursor() model.Cursor {
+	if q.QueryResponse == nil || q.NextCursor == nil {
+		return nil
+	}
+	return &Cursor{q.NextCursor}
+}
+
 func (q *QueryResponse) Marshal() ([]byte, error) {
 	return proto.Marshal(q.QueryResponse)
 }
@@ -154,3 +233,96 @@ func (q *QueryResponse) Verify() error {
 	return q.cryptor.Verify(q.GetSignature().GetPublicKey(),
 		q.GetObject(), q.GetSignature().GetSignature())
 }
+
+type BatchQueryResult struct {
+	*proskenion.BatchQueryResult
+	cryptor core.Cryptor
+}
+
+func (r *BatchQueryResult) GetResponse() model.QueryResponse {
+	if r.BatchQueryResult == nil || r.Response == nil {
+		return nil
+	}
+	return &QueryResponse{r.Response, r.cryptor}
+}
+
+type BatchQueryResponse struct {
+	*proskenion.BatchQueryResponse
+	cryptor core.Cryptor
+}
+
+func (q *BatchQueryResponse) getPayload() *BatchQueryResponsePayload {
+	return &BatchQueryResponsePayload{q.BatchQueryResponse.GetPayload(), q.cryptor}
+}
+
+func (q *BatchQueryResponse) GetResults() []model.BatchQueryResult {
+	rets := make([]model.BatchQueryResult, 0, len(q.GetPayload().GetResults()))
+	for _, r := range q.GetPayload().GetResults() {
+		rets = append(rets, &BatchQueryResult{r, q.cryptor})
+	}
+	return rets
+}
+
+func (q *BatchQueryResponse) GetBlockHash() model.Hash {
+	return q.GetPayload().GetBlockHash()
+}
+
+func (q *BatchQueryResponse) GetHeight() int64 {
+	return q.GetPayload().GetHeight()
+}
+
+func (q *BatchQueryResponse) GetSignature() model.Signature {
+	if q.BatchQueryResponse == nil {
+		return &Signature{}
+	}
+	return &Signature{q.BatchQueryResponse.GetSignature()}
+}
+
+func (q *BatchQueryResponse) Marshal() ([]byte, error) {
+	return proto.Marshal(q.BatchQueryResponse)
+}
+
+func (q *BatchQueryResponse) Unmarshal(pb []byte) error {
+	return proto.Unmarshal(pb, q.BatchQueryResponse)
+}
+
+func (q *BatchQueryResponse) Hash() model.Hash {
+	return q.cryptor.Hash(q)
+}
+
+func (q *BatchQueryResponse) Sign(pubkey model.PublicKey, privkey model.PrivateKey) error {
+	if q.BatchQueryResponse == nil {
+		return errors.Errorf("proskenion.BatchQueryResponse is nil")
+	}
+	signature, err := q.cryptor.Sign(q.getPayload(), privkey)
+	if err != nil {
+		return errors.Wrap(core.ErrCryptorSign, err.Error())
+	}
+	q.BatchQueryResponse.Signature = &proskenion.Signature{
+		PublicKey: []byte(pubkey),
+		Signature: signature,
+	}
+	return nil
+}
+
+func (q *BatchQueryResponse) Verify() error {
+	return q.cryptor.Verify(q.GetSignature().GetPublicKey(),
+		q.getPayload(), q.GetSignature().GetSignature())
+}
+
+type BatchQueryResponsePayload struct {
+	*proskenion.BatchQueryResponse_Payload
+	cryptor core.Cryptor
+}
+
+func (p *BatchQueryResponsePayload) Marshal() ([]byte, error) {
+	return proto.Marshal(p.BatchQueryResponse_Payload)
+}
+
+func (p *BatchQueryResponsePayload) Unmarshal(pb []byte) error {
+	return proto.Unmarshal(pb, p.BatchQueryResponse_Payload)
+}
+
+func (p *BatchQueryResponsePayload) Hash() model.Hash {
+	return p.cryptor.Hash(p)
+}
@@ -0,0 +1,71 @@
+package convertor
+
+import (
+	"github.com/proskenion/proskenion/core"
+	"github.com/proskenion/proskenion/core/model"
+	"github.com/proskenion/proskenion/proto"
+	"github.com/satellitex/protobuf/proto"
+)
+
+type Event struct {
+	*proskenion.Event
+	cryptor   core.Cryptor
+	executor  core.CommandExecutor
+	validator core.CommandValidator
+}
+
+func (e *Event) GetAttributes() map[string]model.Object {
+	if e.Event == nil {
+		return nil
+	}
+	return ObjectMapsFromProslObjectMaps(e.cryptor, e.executor, e.validator, e.Event.GetAttributes())
+}
+
+func (e *Event) Marshal() ([]byte, error) {
+	return proto.Marshal(e.Event)
+}
+
+func (e *Event) Unmarshal(pb []byte) error {
+	return proto.Unmarshal(pb, e.Event)
+}
+
+func (e *Event) Hash() model.Hash {
+	return e.cryptor.Hash(e)
+}
+
+type Receipt struct {
+	*proskenion.Receipt
+	cryptor   core.Cryptor
+	executor  core.CommandExecutor
+	validator core.CommandValidator
+}
+
+func (r *Receipt) GetTxHash() model.Hash {
+	if r.Receipt == nil {
+		return nil
+	}
+	return r.Receipt.GetTxHash()
+}
+
+func (r *Receipt) GetEvents() []model.Event {
+	if r.Receipt == nil {
+		return nil
+	}
+	ret := make([]model.Event, 0, len(r.Receipt.GetEvents()))
+	for _, event := range r.Receipt.GetEvents() {
+		ret = append(ret, &Event{event, r.cryptor, r.executor, r.validator})
+	}
+	return ret
+}
+
+func (r *Receipt) Marshal() ([]byte, error) {
+	return proto.Marshal(r.Receipt)
+}
+
+func (r *Receipt) Unmarshal(pb []byte) error {
+	return proto.Unmarshal(pb, r.Receipt)
+}
+
+func (r *Receipt) Hash() model.Hash {
+	return r.cryptor.Hash(r)
+}
@@ -2,11 +2,16 @@ package core
 
 import (
 	. "github.com/proskenion/proskenion/core/model"
+	"time"
 )
 
 type APIClient interface {
 	Write(in Transaction) error
+	// WriteAndWait は Transaction が Commit されるか timeout まで待つ
+	WriteAndWait(in Transaction, timeout time.Duration) (WriteResult, error)
 	Read(in Query) (QueryResponse, error)
+	ReadBatch(in []Query) (BatchQueryResponse, error)
+	GetReceipt(txHash Hash) (Receipt, error)
 }
 
 type ConsensusClient interface {
@@ -18,6 +18,8 @@ var (
 	ErrCommandExecutorTransferBalanceNotFoundSrcAccountId       = fmt.Errorf("Failed Command Executor TransferBalance Can Not Load SrcAccounId")
 	ErrCommandExecutorTransferBalanceNotFoundDestAccountId      = fmt.Errorf("Failed Command Executor TransferBalance Can Not Load DestAccounId")
 	ErrCommandExecutorTransferBalanceNotEnoughSrcAccountBalance = fmt.Errorf("Failed Command Executor TransferBalance Not Enough SrcAccount Balance")
+	ErrCommandValidatorTransferBalanceInvalidBalance            = fmt.Errorf("Failed Command Validator TransferBalance balance is negative")
+	ErrCommandValidatorTransferBalanceOverflow                  = fmt.Errorf("Failed Command Validator TransferBalance DestAccount Balance overflows")
 )
 
 // CreateAccount Err
@@ -25,15 +27,32 @@ var (
 	ErrCommandExecutorCreateAccountAlreadyExistAccount = fmt.Errorf("Failed Command Executor CreateAccount AlreadyExist AccountId")
 )
 
+// SetQuorum Err
+var (
+	ErrCommandExecutorSetQuorumNotExistAccount = fmt.Errorf("Failed Command Executor SetQuorum Not Exist Account")
+	ErrCommandValidatorInvalidQuorum           = fmt.Errorf("Failed Command Validator quorum is negative or greater than the number of public keys")
+)
+
 // AddBalance Err
 var (
 	ErrCommandExecutorAddBalanceNotExistAccount = fmt.Errorf("Failed Command Executor AddBalance Not Exist Account")
+	ErrCommandValidatorAddBalanceOverflow       = fmt.Errorf("Failed Command Validator AddBalance Balance overflows or becomes negative")
 )
 
 // AddPublicKeys Err
 var (
 	ErrCommandExecutorAddPublicKeyNotExistAccount = fmt.Errorf("Failed Command Executor AddPublicKey Not Exist Account")
 	ErrCommandExecutorAddPublicKeyDuplicatePubkey = fmt.Errorf("Failed Command Executor AddPublicKey Duplicate Add PublicKey")
+	ErrCommandValidatorAddPublicKeyEmpty          = fmt.Errorf("Failed Command Validator AddPublicKey PublicKeys is empty")
+)
+
+// RemovePublicKeys Err
+var (
+	ErrCommandExecutorRemovePublicKeyNotExistAccount = fmt.Errorf("Failed Command Executor RemovePublicKey Not Exist Account")
+	ErrCommandExecutorRemovePublicKeyNotFoundPubkey  = fmt.Errorf("Failed Command Executor RemovePublicKey Not Found PublicKey")
+	ErrCommandValidatorRemovePublicKeyEmpty          = fmt.Errorf("Failed Command Validator RemovePublicKey PublicKeys is empty")
+	ErrCommandValidatorRemovePublicKeyLastPubkey     = fmt.Errorf("Failed Command Validator RemovePublicKey can not remove the last PublicKey")
+	ErrCommandValidatorRemovePublicKeyUnderQuorum    = fmt.Errorf("Failed Command Validator RemovePublicKey the number of PublicKeys falls below quorum")
 )
 
 // CreateStorage Err
@@ -49,23 +68,66 @@ var (
 // AddObject Err
 var (
 	ErrCommandExecutorAddObjectNotExistWallet = fmt.Errorf("Failed Command Executor AddObject Not Exist Wallet")
+	ErrCommandValidatorAddObjectNotList       = fmt.Errorf("Failed Command Validator AddObject key is not list")
 )
 
 // TransferObject Err
 var (
 	ErrCommandExecutorTransferObjectNotExistSrcWallet  = fmt.Errorf("Failed Command Executor TransferObject Not Exist Source Wallet")
 	ErrCommandExecutorTransferObjectNotExistDestWallet = fmt.Errorf("Failed Command Executor TransferObject Not Exist Dest Wallet")
+	ErrCommandValidatorTransferObjectNotList           = fmt.Errorf("Failed Command Validator TransferObject key is not list")
+	ErrCommandValidatorTransferObjectNotFoundObject    = fmt.Errorf("Failed Command Validator TransferObject Not Found Object in Source Wallet")
+)
+
+// Peer Err
+var (
+	ErrCommandValidatorAddPeerDuplicatePeer = fmt.Errorf("Failed Command Validator AddPeer Duplicate PeerId")
+	ErrCommandValidatorPeerNotFound         = fmt.Errorf("Failed Command Validator Not Found Peer")
+	ErrCommandValidatorPeerBanned           = fmt.Errorf("Failed Command Validator Peer is banned")
 )
 
 // Consign Err
 var (
 	ErrCommandExecutorConsignNotFoundAccount = fmt.Errorf("Failed Command Executor Consign Not Found Account")
+	ErrCommandValidatorConsignInactivePeer   = fmt.Errorf("Failed Command Validator Consign Peer is not active")
 )
 
 // CheckAndCommitProsl Err
 var (
-	ErrCommandExecutorCheckAndCommitProslInvalid  = fmt.Errorf("Failed Check And Commit Prosl invalid change rule: false")
-	ErrCommandExecutorCheckAndCommitProslNotFound = fmt.Errorf("Failed Check And Commit Prosl not found target prosl")
+	ErrCommandExecutorCheckAndCommitProslInvalid       = fmt.Errorf("Failed Check And Commit Prosl invalid change rule: false")
+	ErrCommandExecutorCheckAndCommitProslNotFound      = fmt.Errorf("Failed Check And Commit Prosl not found target prosl")
+	ErrCommandExecutorCheckAndCommitProslInvalidHeight = fmt.Errorf("Failed Check And Commit Prosl invalid activation height")
+	ErrCommandExecutorCheckAndCommitProslPending       = fmt.Errorf("Failed Check And Commit Prosl another prosl is pending")
+)
+
+// RevertProsl Err
+var (
+	ErrCommandExecutorRevertProslInvalidTarget   = fmt.Errorf("Failed Command Executor RevertProsl target is not incentive, consensus or update prosl")
+	ErrCommandExecutorRevertProslNotFoundVersion = fmt.Errorf("Failed Command Executor RevertProsl not found version in prosl history")
+)
+
+// InvokeProsl Err
+var (
+	ErrCommandExecutorInvokeProslNotFound          = fmt.Errorf("Failed Command Executor InvokeProsl not found target prosl")
+	ErrCommandExecutorInvokeProslFailed            = fmt.Errorf("Failed Command Executor InvokeProsl failed to execute prosl")
+	ErrCommandExecutorInvokeProslUnexpectedReturn  = fmt.Errorf("Failed Command Executor InvokeProsl return value is not transaction")
+	ErrCommandExecutorInvokeProslInvalidAuthorizer = fmt.Errorf("Failed Command Executor InvokeProsl authorizer is not prosl owner")
+	ErrCommandExecutorInvokeProslNested            = fmt.Errorf("Failed Command Executor InvokeProsl can not invoke prosl from prosl")
+)
+
+// Permission Err
+var (
+	ErrCommandValidatorPermissionDenied          = fmt.Errorf("Failed Command Validator authorizer doesn't have permission")
+	ErrCommandValidatorReservedStorage           = fmt.Errorf("Failed Command Validator storage is reserved")
+	ErrCommandValidatorGrantPermissionInvalid    = fmt.Errorf("Failed Command Validator GrantPermission invalid permission or domain")
+	ErrCommandExecutorGrantPermissionNotFound    = fmt.Errorf("Failed Command Executor GrantPermission Not Found Account")
+	ErrCommandExecutorRevokePermissionNotGranted = fmt.Errorf("Failed Command Executor RevokePermission permission is not granted")
+)
+
+// Storage validate prosl Err
+var (
+	ErrCommandValidatorValidateProslFailed  = fmt.Errorf("Failed Command Validator validate prosl execute error")
+	ErrCommandValidatorValidateProslInvalid = fmt.Errorf("Failed Command Validator validate prosl returned false")
 )
 
 // 	ForceUpdateStorage Err
@@ -87,15 +149,48 @@ const (
 	IncentiveKey  = "incentive"
 	ConsensusKey  = "consensus"
 	UpdateKey = "update"
+
+	// CheckAndCommitProsl で承認され、activation_height で有効になる Prosl を保存する key
+	PendingProslKey     = "pending_prosl"
+	ActivationHeightKey = "activation_height"
+	PendingTxHashKey    = "pending_tx_hash"
+	// true の時、承認済みの pending_prosl を置き換える
+	ReplacePendingKey = "replace_pending"
+
+	// ProSL の履歴 Storage の key 及び各 version の Dict の key
+	ProslHistorySuffix = "_history"
+	ProslVersionsKey   = "versions"
+	ProslVersionKey    = "version"
+	ProslHashKey       = "hash"
+	TxHashKey          = "tx_hash"
+
+	// prosl の実行失敗の記録
+	ProslFailureCountKey    = "failure_count"
+	ProslFailureHeightKey   = "failure_height"
+	ProslFallbackVersionKey = "fallback_version"
+
+	// consensus prosl の結果に必要な Block 生成者の最小数
+	ConsensusMinSizeKey = "min_size"
+
+	// DefineStorage で定義した Storage に対する書き込みを検証する Prosl を保存する key
+	ValidateProslKey = "validate_prosl"
+
+	// InvokeProsl の実行時に渡される引数
+	InvokerIdKey = "invoker_id"
+	OwnerIdKey   = "owner_id"
+
+	// Storage の検証 Prosl の実行時に渡される引数
+	AuthorizerIdKey = "authorizer_id"
 )
 
 type CommandExecutor interface {
-	SetField(factory ModelFactory, prosl Prosl)
+	SetField(factory ModelFactory, cryptor Cryptor)
 	TransferBalance(ObjectFinder, Command) error
 	CreateAccount(ObjectFinder, Command) error
 	SetQuorum(ObjectFinder, Command) error
 	AddBalance(ObjectFinder, Command) error
 	AddPublicKeys(ObjectFinder, Command) error
+	RemovePublicKeys(ObjectFinder, Command) error
 	DefineStorage(ObjectFinder, Command) error
 	CreateStorage(ObjectFinder, Command) error
 	UpdateObject(ObjectFinder, Command) error
@@ -107,17 +202,22 @@ type CommandExecutor interface {
 	BanPeer(ObjectFinder, Command) error
 	Consign(ObjectFinder, Command) error
 	CheckAndCommitProsl(ObjectFinder, Command) error
+	InvokeProsl(ObjectFinder, Command) error
+	RevertProsl(ObjectFinder, Command) error
+	GrantPermission(ObjectFinder, Command) error
+	RevokePermission(ObjectFinder, Command) error
 
 	ForceUpdateStorage(ObjectFinder, Command) error
 }
 
 type CommandValidator interface {
-	SetField(factory ModelFactory, prosl Prosl)
+	SetField(factory ModelFactory, cryptor Cryptor)
 	TransferBalance(ObjectFinder, Command) error
 	CreateAccount(ObjectFinder, Command) error
 	SetQuorum(ObjectFinder, Command) error
 	AddBalance(ObjectFinder, Command) error
 	AddPublicKeys(ObjectFinder, Command) error
+	RemovePublicKeys(ObjectFinder, Command) error
 	DefineStorage(ObjectFinder, Command) error
 	CreateStorage(ObjectFinder, Command) error
 	UpdateObject(ObjectFinder, Command) error
@@ -130,6 +230,10 @@ type CommandValidator interface {
 	Consign(ObjectFinder, Command) error
 	Tx(ObjectFinder, TxFinder, Transaction) error
 	CheckAndCommitProsl(ObjectFinder, Command) error
+	InvokeProsl(ObjectFinder, Command) error
+	RevertProsl(ObjectFinder, Command) error
+	GrantPermission(ObjectFinder, Command) error
+	RevokePermission(ObjectFinder, Command) error
 
 	ForceUpdateStorage(ObjectFinder, Command) error
 }
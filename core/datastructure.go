@@ -76,6 +76,9 @@ type MerklePatriciaController interface {
 // World State の管理 に使う(SubTree の管理にも使う)
 type MerklePatriciaTree interface {
 	Iterator() MerklePatriciaNodeIterator
+	// prefix と一致する key を持つ leaf を key の辞書順で from の次から limit 個まで取得し、最後の leaf の key と共に返す
+	// from が nil なら先頭から、limit が 0 以下なら全て、desc なら逆順に取得する
+	SubLeafsFrom(prefix []byte, from []byte, limit int, desc bool) ([]MerklePatriciaNodeIterator, []byte, error)
 	MerklePatriciaController
 }
 
@@ -0,0 +1,43 @@
+package core
+
+import "github.com/proskenion/proskenion/core/model"
+
+const (
+	// 既存の Chain の再検証を壊す機能を有効化する Storage の定義と Wallet。
+	// key は機能名、値は Bool で、true にした Block 以降の Block でその機能が有効になる
+	ActivationStorageId = "/activation"
+	ActivationId        = "fork/activation"
+
+	// 書き込み権限と予約 Storage の検証
+	PermissionFeature = "permission"
+	// Commit での Block の receiptsHash の検証
+	ReceiptsFeature = "receipts"
+	// CheckAndCommitProsl, RevertProsl の activation_height と pending_prosl の検証
+	ProslUpgradeFeature = "prosl_upgrade"
+	// Command の State に基づく事前検証と、Command 毎の Validate, Execute
+	StatefulValidationFeature = "stateful_validation"
+	// prosl の実行失敗の記録と、過去の version による代替
+	ProslFallbackFeature = "prosl_fallback"
+	// prosl の command operator の不明な引数名の拒否
+	ProslParamsFeature = "prosl_params"
+)
+
+// Features は新しく作成する Chain で genesis から有効にする機能の一覧
+var Features = []string{
+	PermissionFeature,
+	ReceiptsFeature,
+	ProslUpgradeFeature,
+	StatefulValidationFeature,
+	ProslFallbackFeature,
+	ProslParamsFeature,
+}
+
+// Activated は wsv で feature が有効化されているかを返す。
+// ActivationId の無い Chain (機能追加前の Chain) では全ての機能が無効になる
+func Activated(wsv model.ObjectFinder, st model.Storage, feature string) bool {
+	id := model.MustAddress(ActivationId)
+	if err := wsv.Query(id, st); err != nil {
+		return false
+	}
+	return st.GetFromKey(feature).GetBoolean()
+}
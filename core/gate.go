@@ -3,6 +3,7 @@ package core
 import (
 	"fmt"
 	. "github.com/proskenion/proskenion/core/model"
+	"time"
 )
 
 // API
@@ -10,15 +11,85 @@ var (
 	ErrAPIWriteVerifyError    = fmt.Errorf("Failed API Write Stateless Verify Error")
 	ErrAPIWriteTxAlreadyExist = fmt.Errorf("Failed API Write Transaction is already exists")
 	ErrAPIWriteGossipTxError = fmt.Errorf("Failed API Write Gossip Tx occures error")
+	ErrAPIWriteRejected       = fmt.Errorf("Failed API Write Transaction is rejected")
+	ErrAPIWriteExpired        = fmt.Errorf("Failed API Write Transaction is expired")
+	ErrAPIWriteTimeout        = fmt.Errorf("Failed API Write Transaction is not committed until timeout")
 
 	ErrAPIQueryVerifyError   = fmt.Errorf("Failed API Read query Verify Error")
 	ErrAPIQueryValidateError = fmt.Errorf("Failed API Read query Validate Error")
 	ErrAPIQueryNotFound      = fmt.Errorf("Failed API Read query not found")
+	ErrAPIQueryBatchTooLarge = fmt.Errorf("Failed API ReadBatch too many queries")
+
+	ErrAPIReceiptNotFound = fmt.Errorf("Failed API GetReceipt receipt not found")
+
+	ErrAPITxStatusNotFound = fmt.Errorf("Failed API GetTxStatus status not found")
+
+	ErrAPIProslMetricsInvalidArgument = fmt.Errorf("Failed API GetProslMetrics invalid prosl id")
+
+	ErrAPISubscribeInvalidArgument = fmt.Errorf("Failed API Subscribe invalid subscription")
+	ErrAPISubscribeReadDenied      = fmt.Errorf("Failed API Subscribe storage is protected by read_acl")
 )
 
 type API interface {
 	Write(tx Transaction) error
+	// WriteAndWait は Transaction を受け付けて、Commit されるか timeout まで待つ
+	WriteAndWait(tx Transaction, timeout time.Duration) (WriteResult, error)
 	Read(query Query) (QueryResponse, error)
+	// ReadBatch は全ての Query を同じ Block の WSV に対して実行する
+	ReadBatch(queries []Query) (BatchQueryResponse, error)
+	GetReceipt(txHash Hash) (Receipt, error)
+	// GetTxStatus は この Peer が受け付けた Transaction の処理状況を返す
+	GetTxStatus(txHash Hash) (TxStatus, error)
+	// GetProslMetrics は この Peer で観測した prosl の実行失敗の統計を返す
+	GetProslMetrics(proslId string) (ProslMetrics, error)
+	// Subscribe は sub に一致する Commit 済みの Event を done が閉じられるまで eventChan に送る
+	Subscribe(sub Subscription, done <-chan struct{}, eventChan chan SubscribeEvent) error
+}
+
+// WriteResult は Commit された Transaction の結果
+type WriteResult struct {
+	BlockHash Hash
+	Height    int64
+	Receipt   Receipt
+}
+
+// SubscribeCode は購読する Event の種類
+type SubscribeCode int
+
+const (
+	// BlockSubscribe は Commit された Block を購読する
+	BlockSubscribe SubscribeCode = iota
+	// TransactionSubscribe は Commit された Transaction のうち AuthorizerId か TargetId に一致するものを購読する
+	TransactionSubscribe
+	// StateSubscribe は Prefix 以下の WSV の値の追加・更新を購読する
+	StateSubscribe
+	// TxStatusSubscribe は TxHash の Transaction の status の変化を確定するまで購読する
+	TxStatusSubscribe
+)
+
+// Subscription は Subscribe の購読条件
+type Subscription struct {
+	Code SubscribeCode
+	// FromHeight 以降の Block から配信する。負なら購読を始めた後に Commit された Block から配信する
+	FromHeight int64
+	// AuthorizerId の command を含む Transaction を配信する
+	AuthorizerId string
+	// TargetId を対象とする command を含む Transaction を配信する
+	TargetId string
+	// Prefix 以下の address の値の変化を配信する
+	Prefix string
+	// TxHash の Transaction の status を配信する
+	TxHash Hash
+}
+
+// SubscribeEvent は Subscribe で配信する Event。Block, Transaction, Object, TxStatus のいずれか 1 つを持つ
+type SubscribeEvent struct {
+	Height      int64
+	BlockHash   Hash
+	Block       Block
+	Transaction Transaction
+	Object      Object
+	TxStatus    *TxStatus
 }
 
 // Consensus
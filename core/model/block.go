@@ -30,6 +30,7 @@ type BlockPayload interface {
 	GetWSVHash() Hash
 	GetTxHistoryHash() Hash
 	GetTxListHash() Hash
+	GetReceiptsHash() Hash
 	GetRound() int32
 	Modelor
 }
@@ -21,6 +21,10 @@ type Command interface {
 	GetBanPeer() BanPeer
 	GetConsign() Consign
 	GetCheckAndCommitProsl() CheckAndCommitProsl
+	GetInvokeProsl() InvokeProsl
+	GetRevertProsl() RevertProsl
+	GetGrantPermission() GrantPermission
+	GetRevokePermission() RevokePermission
 
 	GetForceUpdateStorage() ForceUpdateStorage
 
@@ -95,6 +99,25 @@ type CheckAndCommitProsl interface {
 	GetVariables() map[string]Object
 }
 
+type InvokeProsl interface {
+	GetVariables() map[string]Object
+}
+
+type RevertProsl interface {
+	GetVersion() int64
+	GetVariables() map[string]Object
+}
+
+type GrantPermission interface {
+	GetPermission() string
+	GetDomain() string
+}
+
+type RevokePermission interface {
+	GetPermission() string
+	GetDomain() string
+}
+
 type ForceUpdateStorage interface {
 	GetStorage() Storage
 }
\ No newline at end of file
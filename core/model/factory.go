@@ -32,11 +32,16 @@ type ModelFactory interface {
 	NewTxBuilder() TxBuilder
 	NewQueryBuilder() QueryBuilder
 	NewQueryResponseBuilder() QueryResponseBuilder
+	NewBatchQueryResponseBuilder() BatchQueryResponseBuilder
+	NewEvent(eventType string, authorizerId string, targetId string, attributes map[string]Object) Event
+	NewReceipt(txHash Hash, events []Event) Receipt
 
 	NewEmptyBlock() Block
 	NewEmptyTx() Transaction
 	NewEmptyQuery() Query
 	NewEmptyQueryResponse() QueryResponse
+	NewEmptyBatchQueryResponse() BatchQueryResponse
+	NewEmptyReceipt() Receipt
 }
 
 type ObjectBuilder interface {
@@ -98,6 +103,7 @@ type BlockBuilder interface {
 	WSVHash(Hash) BlockBuilder
 	TxHistoryHash(Hash) BlockBuilder
 	TxListHash(Hash) BlockBuilder
+	ReceiptsHash(Hash) BlockBuilder
 	Round(int32) BlockBuilder
 	Build() Block
 }
@@ -121,6 +127,10 @@ type TxBuilder interface {
 	BanPeer(authorizerId string, peerId string) TxBuilder
 	Consign(authorizerId string, accountId string, peerId string) TxBuilder
 	CheckAndCommitProsl(authorizerId string, proslId string, params map[string]Object) TxBuilder
+	InvokeProsl(authorizerId string, proslId string, params map[string]Object) TxBuilder
+	RevertProsl(authorizerId string, proslId string, version int64, params map[string]Object) TxBuilder
+	GrantPermission(authorizerId string, accountId string, permission string, domain string) TxBuilder
+	RevokePermission(authorizerId string, accountId string, permission string, domain string) TxBuilder
 	ForceUpdateStorage(authorizerId string, targetId string, storage Storage) TxBuilder
 	AppendCommand(cmd Command) TxBuilder
 	Build() Transaction
@@ -135,6 +145,12 @@ type QueryBuilder interface {
 	Limit(int32) QueryBuilder
 	CreatedTime(int64) QueryBuilder
 	RequestCode(code ObjectCode) QueryBuilder
+	Cursor(lastKey []byte, order OrderCode, blockHash Hash) QueryBuilder
+	AtBlockHash(hash Hash) QueryBuilder
+	AtHeight(height int64) QueryBuilder
+	TxHash(hash Hash) QueryBuilder
+	Aggregate(code AggregateCode, key string) QueryBuilder
+	GroupBy(key string) QueryBuilder
 	Build() Query
 }
 
@@ -144,5 +160,14 @@ type QueryResponseBuilder interface {
 	Storage(Storage) QueryResponseBuilder
 	List([]Object) QueryResponseBuilder
 	Object(Object) QueryResponseBuilder
+	NextCursor(lastKey []byte, order OrderCode, blockHash Hash) QueryResponseBuilder
 	Build() QueryResponse
 }
+
+type BatchQueryResponseBuilder interface {
+	// Result は Query の結果を追加する。err が nil でなければ失敗した結果として追加する
+	Result(res QueryResponse, err error) BatchQueryResponseBuilder
+	BlockHash(Hash) BatchQueryResponseBuilder
+	Height(int64) BatchQueryResponseBuilder
+	Build() BatchQueryResponse
+}
@@ -24,6 +24,11 @@ const (
         OrderBy orederBy = 6;
         int32 limit = 7;
         int64 createdTime = 8;
+        Cursor cursor = 9;
+        QueryAt at = 10;
+        bytes txHash = 11;
+        repeated Aggregate aggregates = 12;
+        string groupBy = 13;
 */
 type QueryPayload interface {
 	GetAuthorizerId() string
@@ -34,6 +39,13 @@ type QueryPayload interface {
 	GetOrderBy() OrderBy
 	GetLimit() int32
 	GetCreatedTime() int64
+	// cursor が指定されていなければ nil
+	GetCursor() Cursor
+	// at が指定されていなければ nil
+	GetAt() QueryAt
+	GetTxHash() Hash
+	GetAggregates() []Aggregate
+	GetGroupBy() string
 	Modelor
 }
 
@@ -42,9 +54,59 @@ type OrderBy interface {
 	GetOrder() OrderCode
 }
 
+type AggregateCode int
+
+const (
+	CountAggregate AggregateCode = iota
+	SumAggregate
+	MinAggregate
+	MaxAggregate
+)
+
+type Aggregate interface {
+	GetCode() AggregateCode
+	GetKey() string
+}
+
+// Cursor は範囲指定の Query の継続トークン
+type Cursor interface {
+	GetLastKey() []byte
+	GetOrder() OrderCode
+	// 最初のページを取得した Block の hash。指定されていなければ nil
+	GetBlockHash() Hash
+}
+
+// QueryAt は Query の対象とする過去の Block
+type QueryAt interface {
+	// 指定されていなければ nil
+	GetBlockHash() Hash
+	GetHeight() int64
+}
+
 type QueryResponse interface {
 	GetObject() Object
 	GetSignature() Signature
+	// 続きが無ければ nil
+	GetNextCursor() Cursor
+	Modelor
+	Sign(PublicKey, PrivateKey) error
+	Verify() error
+}
+
+// BatchQueryResult は BatchQuery の 1 つの Query の結果
+type BatchQueryResult interface {
+	// 失敗していれば nil
+	GetResponse() QueryResponse
+	// 成功していれば空
+	GetError() string
+}
+
+// BatchQueryResponse は同じ Block の WSV に対して実行した Query の結果をまとめて署名したもの
+type BatchQueryResponse interface {
+	GetResults() []BatchQueryResult
+	GetBlockHash() Hash
+	GetHeight() int64
+	GetSignature() Signature
 	Modelor
 	Sign(PublicKey, PrivateKey) error
 	Verify() error
@@ -0,0 +1,22 @@
+package model
+
+// Command 及び Prosl の emit によって発行されるイベント
+type Event interface {
+	GetType() string
+	GetAuthorizerId() string
+	GetTargetId() string
+	GetAttributes() map[string]Object
+	Modelor
+}
+
+// Transaction 1 つ分のイベントの記録
+type Receipt interface {
+	GetTxHash() Hash
+	GetEvents() []Event
+	Modelor
+}
+
+// EventEmitter はイベントを受け取り Receipt に積む
+type EventEmitter interface {
+	Emit(event Event)
+}
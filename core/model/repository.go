@@ -5,10 +5,27 @@ type ObjectFinder interface {
 	Query(targetId Address, value Unmarshaler) error
 	// Query All gets value from fromId
 	QueryAll(fromId Address, value UnmarshalerFactory) ([]Unmarshaler, error)
+	// QueryPage gets at most limit values from fromId in key order after lastKey, and returns the key of last value
+	QueryPage(fromId Address, lastKey []byte, order OrderCode, limit int, value UnmarshalerFactory) ([]Unmarshaler, []byte, error)
 	// Append [targetId] = value
 	Append(targetId Address, value Marshaler) error
 }
 
+// ExecutingTxFinder は実行中の Transaction のハッシュ値を返す
+type ExecutingTxFinder interface {
+	ExecutingTxHash() Hash
+}
+
+// ExecutingHeightFinder は実行中の Block の高さを返す
+type ExecutingHeightFinder interface {
+	ExecutingHeight() int64
+}
+
+// ReadingBlockFinder は Query で読み込んでいる Block のハッシュ値を返す
+type ReadingBlockFinder interface {
+	ReadingBlockHash() Hash
+}
+
 type TxFinder interface {
 	// GetTxList gets
 	GetTx(txHash Hash) (Transaction, error)
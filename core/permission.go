@@ -0,0 +1,57 @@
+package core
+
+import "strings"
+
+const (
+	// Account に付与された権限を保存する Storage 名。
+	// key は権限名または Role 名、値は権限を行使できる Domain の List
+	PermissionStorageName = "permission"
+	// 全ての Domain を表す
+	AllDomain = "*"
+	// DefineStorage で定義した Storage の prosl を invoke_prosl できる AccountId, Domain の List を保存する key
+	InvokeAclKey = "invoke_acl"
+)
+
+// 権限名は Command のイベント名と同一
+const (
+	CreateAccountPermission       = "create_account"
+	AddBalancePermission          = "add_balance"
+	TransferBalancePermission     = "transfer_balance"
+	AddPublicKeysPermission       = "add_public_keys"
+	RemovePublicKeysPermission    = "remove_public_keys"
+	SetQuorumPermission           = "set_quorum"
+	DefineStoragePermission       = "define_storage"
+	CreateStoragePermission       = "create_storage"
+	UpdateObjectPermission        = "update_object"
+	AddObjectPermission           = "add_object"
+	TransferObjectPermission      = "transfer_object"
+	AddPeerPermission             = "add_peer"
+	ActivatePeerPermission        = "activate_peer"
+	SuspendPeerPermission         = "suspend_peer"
+	BanPeerPermission             = "ban_peer"
+	ConsignPermission             = "consign"
+	CheckAndCommitProslPermission = "check_and_commit_prosl"
+	RevertProslPermission         = "revert_prosl"
+	InvokeProslPermission         = "invoke_prosl"
+	GrantPermissionPermission     = "grant_permission"
+	RevokePermissionPermission    = "revoke_permission"
+)
+
+const (
+	// AdminRole は全ての権限を持つ
+	AdminRole = "admin"
+	// PeerOperatorRole は Peer の管理と Consign の権限を持つ
+	PeerOperatorRole = "peer_operator"
+)
+
+// RolePermissions は書き込み権限を持つ Role とその権限の一覧。
+// ここに無い Role は書き込み権限を持たず、Storage の read_acl でのみ使われる。
+var RolePermissions = map[string][]string{
+	PeerOperatorRole: {AddPeerPermission, ActivatePeerPermission, SuspendPeerPermission, BanPeerPermission, ConsignPermission},
+}
+
+// MatchDomain は granted に付与された権限が domain に及ぶかを返す。権限は sub domain にも及ぶ。
+func MatchDomain(granted string, domain string) bool {
+	return granted == AllDomain || granted == domain ||
+		(granted != "" && strings.HasSuffix(domain, "."+granted))
+}
@@ -4,8 +4,27 @@ import "github.com/proskenion/proskenion/core/model"
 
 type Prosl interface {
 	ConvertFromYaml(yaml []byte) error
+	ConvertToYaml() ([]byte, error)
 	Validate() error
 	Execute(model.ObjectFinder, model.Block) (model.Object, map[string]model.Object, error)
 	ExecuteWithParams(model.ObjectFinder, model.Block, map[string]model.Object) (model.Object, map[string]model.Object, error)
 	model.Modelor
 }
+
+// ProslFallbackDefault は履歴の全 version が失敗し built-in default で代替したことを表す fallback_version
+const ProslFallbackDefault int64 = -1
+
+// ProslMetrics はこの Peer で観測した Prosl の実行失敗の統計
+type ProslMetrics struct {
+	// Failures は有効な prosl が失敗した Block の数
+	Failures int64
+	// Fallbacks は過去の version で代替した Block の数
+	Fallbacks int64
+	// Defaults は built-in default で代替した Block の数
+	Defaults int64
+}
+
+// ProslHistoryId は id の ProSL の全 version を保存する Storage の Id を返す
+func ProslHistoryId(id string) string {
+	return id + ProslHistorySuffix
+}
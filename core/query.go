@@ -11,14 +11,40 @@ var (
 	ErrQueryProcessorNotFound                      = fmt.Errorf("Failed QueryProcessor Query Not Found")
 	ErrQueryProcessorNotExistAuthoirizer           = fmt.Errorf("Failed QueryProcessor No exists authorizer")
 	ErrQueryProcessorNotSignedAuthorizer           = fmt.Errorf("Failed QueryProcessor Query don't sign authorizer")
+	ErrQueryProcessorInvalidWhere                  = fmt.Errorf("Failed QueryProcessor Query where is not valid expression")
+	ErrQueryProcessorInvalidCursor                 = fmt.Errorf("Failed QueryProcessor Query cursor is not valid")
+	ErrQueryProcessorInvalidAggregate              = fmt.Errorf("Failed QueryProcessor Query aggregate is not valid")
+	ErrQueryProcessorReadDenied                    = fmt.Errorf("Failed QueryProcessor Query authorizer is not allowed to read")
+)
+
+const (
+	// DefineStorage で定義した Storage の読み込み権限を保存する key
+	ReadAclKey = "read_acl"
+
+	// read_acl の Dict の key
+	ReadAclOwnerKey    = "owner"
+	ReadAclDomainKey   = "domain"
+	ReadAclAccountsKey = "accounts"
+	ReadAclRolesKey    = "roles"
 )
 
 type QueryProcessor interface {
 	Query(wsv model.ObjectFinder, query model.Query) (model.QueryResponse, error)
+	// QueryChain は Block と Transaction を取得する Query を top までの Blockchain と TxHistory から処理する
+	QueryChain(bc Blockchain, txHistory TxHistory, top model.Block, query model.Query) (model.QueryResponse, error)
+	// QueryUnsigned, QueryChainUnsigned は署名していない結果を返す。BatchResponse でまとめて署名する結果に使う
+	QueryUnsigned(wsv model.ObjectFinder, query model.Query) (model.QueryResponse, error)
+	QueryChainUnsigned(bc Blockchain, txHistory TxHistory, top model.Block, query model.Query) (model.QueryResponse, error)
+	// QueryChanges は fromId 以下で preWSVHash の WSV から追加・更新された値の Object を取得する
+	QueryChanges(wsv WSV, preWSVHash model.Hash, fromId model.Address) ([]model.Object, error)
+	// BatchResponse は Query の結果をまとめて、実行した top の Block と共に署名した BatchQueryResponse を返す
+	BatchResponse(top model.Block, rets []model.QueryResponse, errs []error) (model.BatchQueryResponse, error)
 }
 
 type QueryValidator interface {
 	Validate(wsv model.ObjectFinder, query model.Query) error
+	// ValidateRead は authorizerId が fromId を読み込めるかを Storage 定義の read_acl で検証する
+	ValidateRead(wsv model.ObjectFinder, authorizerId string, fromId string) error
 }
 
 type QueryVerifier interface {
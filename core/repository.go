@@ -9,8 +9,9 @@ var (
 	ErrWSVNotFound       = errors.Errorf("Failed WSV Query Not Found")
 	ErrWSVQueryUnmarshal = errors.Errorf("Failed WSV Query Unmarshal")
 
-	ErrTxHistoryNotFound       = errors.Errorf("Failed TxHistory Query Not Found")
-	ErrTxHistoryQueryUnmarshal = errors.Errorf("Failed TxHistory Query Unmarshal")
+	ErrTxHistoryNotFound        = errors.Errorf("Failed TxHistory Query Not Found")
+	ErrTxHistoryQueryUnmarshal  = errors.Errorf("Failed TxHistory Query Unmarshal")
+	ErrTxHistoryReceiptNotFound = errors.Errorf("Failed TxHistory Receipt Not Found")
 
 	ErrBlockchainNotFound       = errors.Errorf("Failed Blockchain Get Not Found")
 	ErrBlockchainQueryUnmarshal = errors.Errorf("Failed Blocchain Get Unmarshal")
@@ -22,6 +23,8 @@ var (
 	ErrRepositoryCommitLoadPreBlock  = errors.Errorf("Failed Repository Commit Load PreBlockchain")
 	ErrRepositoryCommitLoadWSV       = errors.Errorf("Failed Repository Commit Load WSV")
 	ErrRepositoryCommitLoadTxHistory = errors.Errorf("Failed Repository Commit Load TxHistory")
+	ErrRepositoryReceiptsHash        = errors.Errorf("Failed Repository Receipts Hash")
+	ErrRepositoryInvalidConsensus    = errors.Errorf("Failed Repository Invalid Consensus Prosl Result")
 )
 
 // TxList Wrap MerkleTree
@@ -32,6 +35,13 @@ type TxList interface {
 	Modelor
 }
 
+// ReceiptList Wrap MerkleTree
+type ReceiptList interface {
+	Push(receipt Receipt) error
+	List() []Receipt
+	Hasher
+}
+
 type TxListCache interface {
 	Set(txList TxList) error
 	Get(hash Hash) (TxList, bool)
@@ -53,6 +63,10 @@ type WSV interface {
 	Query(targetId Address, value Unmarshaler) error
 	// Query All gets value from fromId
 	QueryAll(fromId Address, value UnmarshalerFactory) ([]Unmarshaler, error)
+	// QueryPage gets at most limit values from fromId in key order after lastKey, and returns the key of last value
+	QueryPage(fromId Address, lastKey []byte, order OrderCode, limit int, value UnmarshalerFactory) ([]Unmarshaler, []byte, error)
+	// QueryChanges gets values from fromId which are added or updated since the WSV of preHash
+	QueryChanges(fromId Address, preHash Hash, value UnmarshalerFactory) ([]Unmarshaler, error)
 	// Get PeerService
 	PeerService() (PeerService, error)
 	// Append [targetId] = value
@@ -61,6 +75,20 @@ type WSV interface {
 	Commit() error
 	// RollBack
 	Rollback() error
+	// Revert discards values appended after the state of hash
+	Revert(hash Hash) error
+	// Emit stacks event of executing transaction
+	EventEmitter
+	// PopEvents gets emitted events and clears them
+	PopEvents() []Event
+	// ExecutingTxHash gets hash of executing transaction
+	ExecutingTxFinder
+	// SetExecutingTxHash sets hash of executing transaction
+	SetExecutingTxHash(txHash Hash)
+	// ExecutingHeight gets height of executing block
+	ExecutingHeightFinder
+	// SetExecutingHeight sets height of executing block
+	SetExecutingHeight(height int64)
 }
 
 // 全Tx履歴 (MerklePatriciaTree で管理)
@@ -72,6 +100,10 @@ type TxHistory interface {
 	GetTx(txHash Hash) (Transaction, error)
 	// Append tx
 	Append(txList TxList) error
+	// GetReceipt gets receipt from txHash
+	GetReceipt(txHash Hash) (Receipt, error)
+	// AppendReceipts appends receipts of txs
+	AppendReceipts(receipts ReceiptList) error
 	// Commit appenging nodes
 	Commit() error
 	// RollBack
@@ -84,6 +116,8 @@ type Blockchain interface {
 	Next(blockHash Hash) (Block, error)
 	// blockHash を指定して Block を取得
 	Get(blockHash Hash) (Block, error)
+	// height を指定して Block を取得
+	GetByHeight(height int64) (Block, error)
 	// Commit block
 	Append(block Block) error
 }
@@ -108,8 +142,20 @@ type Repository interface {
 	Me() PeerWithPriKey
 
 	TopWSV() (WSV, error)
+	// WSVAt gets WSV at the block of blockHash
+	WSVAt(blockHash Hash) (WSV, error)
+	// BlockHashAt gets hash of the block at height
+	BlockHashAt(height int64) (Hash, error)
 
 	GetDelegatedAccounts() ([]Account, error)
+	// ProslMetrics gets failure statistics of the prosl (incentive or consensus)
+	ProslMetrics(id string) ProslMetrics
+	// TxStatus gets status of the transaction observed by this peer
+	TxStatus(txHash Hash) (TxStatus, bool)
+	// SetTxQueued records that the transaction is pushed to ProposalTxQueue
+	SetTxQueued(txHash Hash)
+	// SubscribeCommit gets channel notified of committed blocks and function to unsubscribe
+	SubscribeCommit() (<-chan Block, func())
 	Commit(Block, TxList) error
 	GenesisCommit(TxList) error
 	CreateBlock(queue ProposalTxQueue, round int32, now int64) (Block, TxList, error)
@@ -0,0 +1,30 @@
+package core
+
+// TxStatusCode は Transaction の処理状況
+type TxStatusCode int
+
+const (
+	// TxStatusQueued は ProposalTxQueue に入って Block に含まれるのを待っている
+	TxStatusQueued TxStatusCode = iota
+	// TxStatusCommitted は Block に含まれて Commit された
+	TxStatusCommitted
+	// TxStatusRejected は Block の生成時に validation で落ちた。Block を生成した Peer でのみ記録される
+	TxStatusRejected
+	// TxStatusExpired は Queued のまま expire_blocks が経過した
+	TxStatusExpired
+)
+
+// TxStatus はこの Peer で観測した Transaction の処理状況
+type TxStatus struct {
+	Code TxStatusCode
+	// Height は Queued なら受け付けた時の top の height、それ以外は status が確定した Block の height。
+	// 保持期間を過ぎた Committed の Transaction では不明なので -1
+	Height int64
+	// Reason は Rejected の validation error
+	Reason string
+}
+
+// Final は status がこれ以上変化しないか判定する
+func (s TxStatus) Final() bool {
+	return s.Code != TxStatusQueued
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/proskenion/proskenion/core"
 	"github.com/proskenion/proskenion/core/model"
+	"sort"
 	"strconv"
 )
 
@@ -93,6 +94,25 @@ func (t *MerklePatriciaTree) Find(key []byte) (core.MerklePatriciaNodeIterator,
 	return ret, nil
 }
 
+// prefix と一致する key を持つ leaf を key の辞書順で from の次から limit 個まで取得し、最後の leaf の key と共に返す
+func (t *MerklePatriciaTree) SubLeafsFrom(prefix []byte, from []byte, limit int, desc bool) ([]core.MerklePatriciaNodeIterator, []byte, error) {
+	w := &subLeafsWalker{
+		prefix: prefix,
+		from:   from,
+		limit:  limit,
+		desc:   desc,
+		leafs:  make([]core.MerklePatriciaNodeIterator, 0),
+	}
+	root, ok := t.Iterator().(*MerklePatriciaNodeIterator)
+	if !ok {
+		return nil, nil, errors.Errorf("unexpected root iterator type: %T", t.Iterator())
+	}
+	if err := w.walk(root, nil); err != nil {
+		return nil, nil, err
+	}
+	return w.leafs, w.last, nil
+}
+
 // Upsert したあとの新しい Iterator を生成して取得
 func (t *MerklePatriciaTree) Upsert(node core.KVNode) (core.MerklePatriciaNodeIterator, error) {
 	it, err := t.Iterator().Upsert(node)
@@ -585,3 +605,94 @@ func (t *MerklePatriciaNodeIterator) SubLeafs() ([]core.MerklePatriciaNodeIterat
 		return rets, nil
 	}
 }
+
+// subLeafsWalker は key の辞書順に leaf を走査し、from 以前(desc なら以後)の部分木を読まずに飛ばす
+type subLeafsWalker struct {
+	prefix []byte
+	from   []byte
+	limit  int
+	desc   bool
+	leafs  []core.MerklePatriciaNodeIterator
+	last   []byte
+}
+
+func (w *subLeafsWalker) full() bool {
+	return w.limit > 0 && len(w.leafs) >= w.limit
+}
+
+// walk は parent を親までの key として t 以下の leaf を走査する
+func (w *subLeafsWalker) walk(t *MerklePatriciaNodeIterator, parent []byte) error {
+	key := make([]byte, 0, len(parent)+len(t.Key()))
+	key = append(append(key, parent...), t.Key()...)
+	if !bytes.HasPrefix(key, w.prefix) && !bytes.HasPrefix(w.prefix, key) {
+		return nil
+	}
+
+	// from と比較して、部分木の範囲 [key, key+...] を絞り込む
+	cmp := 1
+	if w.desc {
+		cmp = -1
+	}
+	fromPrefixed := false
+	if w.from != nil {
+		fromPrefixed = bytes.HasPrefix(w.from, key)
+		cmp = bytes.Compare(key, w.from)
+		if !fromPrefixed && (cmp < 0) != w.desc {
+			return nil
+		}
+		if w.desc && bytes.Equal(key, w.from) {
+			return nil
+		}
+	}
+	hasLeaf := len(t.DataHash()) != 0 && len(key) >= len(w.prefix) && (cmp > 0) != w.desc
+
+	// 辿る子ノードを決める
+	childs := make([]int, 0, len(t.Childs()))
+	for k := range t.Childs() {
+		if len(w.prefix) > len(key) && k != w.prefix[len(key)] {
+			continue
+		}
+		if fromPrefixed && len(w.from) > len(key) {
+			if !w.desc && k < w.from[len(key)] || w.desc && k > w.from[len(key)] {
+				continue
+			}
+		}
+		childs = append(childs, int(k))
+	}
+	if w.desc {
+		sort.Sort(sort.Reverse(sort.IntSlice(childs)))
+	} else {
+		sort.Ints(childs)
+	}
+
+	appendLeaf := func() error {
+		leaf, err := t.getLeaf()
+		if err != nil {
+			return err
+		}
+		w.leafs = append(w.leafs, leaf)
+		w.last = key
+		return nil
+	}
+	if hasLeaf && !w.desc {
+		if err := appendLeaf(); err != nil {
+			return err
+		}
+	}
+	for _, k := range childs {
+		if w.full() {
+			return nil
+		}
+		child, err := t.getChild(byte(k))
+		if err != nil {
+			return err
+		}
+		if err := w.walk(child.(*MerklePatriciaNodeIterator), key); err != nil {
+			return err
+		}
+	}
+	if hasLeaf && w.desc && !w.full() {
+		return appendLeaf()
+	}
+	return nil
+}
@@ -166,3 +166,61 @@ func TestMerklePatriciaTree(t *testing.T) {
 	require.NoError(t, err)
 	testMerklePatriciaTree(t, tree1, tree2)
 }
+
+func TestMerklePatriciaTree_SubLeafsFrom(t *testing.T) {
+	tree, err := NewMerklePatriciaTree(RandomDBA(), RandomCryptor(), model.Hash(nil), MOCK_ROOT_KEY)
+	require.NoError(t, err)
+
+	keys := [][]byte{
+		{MOCK_ROOT_KEY, 1},
+		{MOCK_ROOT_KEY, 1, 2},
+		{MOCK_ROOT_KEY, 1, 3},
+		{MOCK_ROOT_KEY, 2},
+		{MOCK_ROOT_KEY, 2, 1, 1},
+		{MOCK_ROOT_KEY, 3},
+	}
+	acs := make(map[string]model.Account)
+	// 挿入順と key 順が一致しないように逆順に Upsert
+	for i := len(keys) - 1; i >= 0; i-- {
+		ac := RandomAccount()
+		acs[string(keys[i])] = ac
+		_, err := tree.Upsert(RandomKVStoreFromAccount(keys[i], ac))
+		require.NoError(t, err)
+	}
+
+	for _, c := range []struct {
+		name    string
+		prefix  []byte
+		from    []byte
+		limit   int
+		desc    bool
+		expKeys [][]byte
+	}{
+		{"all", []byte{MOCK_ROOT_KEY}, nil, 0, false, keys},
+		{"all desc", []byte{MOCK_ROOT_KEY}, nil, 0, true,
+			[][]byte{keys[5], keys[4], keys[3], keys[2], keys[1], keys[0]}},
+		{"from", []byte{MOCK_ROOT_KEY}, keys[1], 2, false, [][]byte{keys[2], keys[3]}},
+		{"from desc", []byte{MOCK_ROOT_KEY}, keys[3], 0, true, [][]byte{keys[2], keys[1], keys[0]}},
+		{"from not exists", []byte{MOCK_ROOT_KEY}, []byte{MOCK_ROOT_KEY, 1, 2, 5}, 0, false, keys[2:]},
+		{"prefix", []byte{MOCK_ROOT_KEY, 2}, nil, 0, false, [][]byte{keys[3], keys[4]}},
+		{"prefix from", []byte{MOCK_ROOT_KEY, 1}, keys[0], 1, false, [][]byte{keys[1]}},
+		{"prefix desc", []byte{MOCK_ROOT_KEY, 1}, nil, 0, true, [][]byte{keys[2], keys[1], keys[0]}},
+		{"end", []byte{MOCK_ROOT_KEY}, keys[5], 0, false, [][]byte{}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			leafs, last, err := tree.SubLeafsFrom(c.prefix, c.from, c.limit, c.desc)
+			require.NoError(t, err)
+			require.Equal(t, len(c.expKeys), len(leafs))
+			for i, leaf := range leafs {
+				ac := RandomAccount()
+				require.NoError(t, leaf.Data(ac))
+				assert.Equal(t, acs[string(c.expKeys[i])].Hash(), ac.Hash())
+			}
+			if len(c.expKeys) > 0 {
+				assert.Equal(t, c.expKeys[len(c.expKeys)-1], last)
+			} else {
+				assert.Nil(t, last)
+			}
+		})
+	}
+}
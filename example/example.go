@@ -20,8 +20,31 @@ import (
 	"time"
 )
 
-func WaitSecond(second int) {
-	time.Sleep(time.Duration(second) * time.Second)
+// BalancesInterval は残高を表示する間隔
+const BalancesInterval = 3 * time.Second
+
+// parallel は creators それぞれで f を並列に実行し、全て Commit されるまで待つ
+func parallel(creators []*SenderManager, f func(i int, cm *SenderManager)) {
+	w := &sync.WaitGroup{}
+	for i, cm := range creators {
+		w.Add(1)
+		go func(i int, cm *SenderManager) {
+			f(i, cm)
+			w.Done()
+		}(i, cm)
+	}
+	w.Wait()
+}
+
+// watchBalances は BalancesInterval ごとに times 回残高を表示する。times が負の時は表示し続ける
+func watchBalances(logger log15.Logger, authorizer *SenderManager, times int) {
+	ticker := time.NewTicker(BalancesInterval)
+	defer ticker.Stop()
+	for i := 0; times < 0 || i < times; i++ {
+		<-ticker.C
+		authorizer.QueryAccountsBalances()
+		logger.Info(color.GreenString("===================== :: Waiting 3 seconds :: ====================="))
+	}
 }
 
 const NUM_CREATORS = 10
@@ -124,8 +147,6 @@ func main() {
 	// 1. authorizer を登録
 	authorizer := NewSenderManager(NewAccountWithPri("authorizer@pr"), rootPeer, fc, confs[0])
 	authorizer.SetAuthorizer()
-
-	WaitSecond(2)
 	authorizer.QueryAccountPassed(authorizer.Authorizer)
 	logger.Info(color.BlueString("Registered Authorizer PublicKey."))
 
@@ -152,7 +173,6 @@ func main() {
 		authorizer.CreateAccount(creator.Authorizer)
 	}
 
-	WaitSecond(2)
 	for _, creator := range creators {
 		creator.QueryAccountPassed(creator.Authorizer)
 	}
@@ -160,11 +180,10 @@ func main() {
 
 	// 4. Creator がそれぞれ 信頼する Peer を選択する。
 	logger.Info(color.BlueString("================ Scenario 2 :: Degrade 5 Creators  ================"))
-	for i, creator := range creators {
-		go creator.Consign(creator.Authorizer, serversPeer[i%4])
-	}
+	parallel(creators, func(i int, cm *SenderManager) {
+		cm.Consign(cm.Authorizer, serversPeer[i%4])
+	})
 
-	WaitSecond(3)
 	w := &sync.WaitGroup{}
 	for i, cm := range creators {
 		w.Add(1)
@@ -178,31 +197,36 @@ func main() {
 
 	// 5. Creator 同士で信頼(有効辺）を貼る。
 	logger.Info(color.BlueString("=================== Scenario 3 :: Follow Edges  ==================="))
-	for _, cm := range creators {
-		go cm.CreateEdgeStorage(cm.Authorizer)
-	}
+	parallel(creators, func(i int, cm *SenderManager) {
+		cm.CreateEdgeStorage(cm.Authorizer)
+	})
 
-	WaitSecond(2)
+	// i 番目の Creator は i+1 から i+numEdges(i) 番目の Creator を follow する
+	numEdges := func(i int) int {
+		switch {
+		case i < 4:
+			return 4
+		case i < 6:
+			return 3
+		case i < 8:
+			return 2
+		default:
+			return 1
+		}
+	}
 	edges := make([][]model.Object, NUM_CREATORS)
-	for i, cm := range creators {
+	for i := range creators {
 		edges[i] = make([]model.Object, 0)
-		go cm.AddEdge(cm.Authorizer, creators[(i+1)%NUM_CREATORS].Authorizer)
-		edges[i] = append(edges[i], fc.NewObjectBuilder().Address(creators[(i+1)%NUM_CREATORS].Authorizer.AccountId))
-		if i < 8 {
-			go cm.AddEdge(cm.Authorizer, creators[(i+2)%NUM_CREATORS].Authorizer)
-			edges[i] = append(edges[i], fc.NewObjectBuilder().Address(creators[(i+2)%NUM_CREATORS].Authorizer.AccountId))
-		}
-		if i < 6 {
-			go cm.AddEdge(cm.Authorizer, creators[(i+3)%NUM_CREATORS].Authorizer)
-			edges[i] = append(edges[i], fc.NewObjectBuilder().Address(creators[(i+3)%NUM_CREATORS].Authorizer.AccountId))
-		}
-		if i < 4 {
-			go cm.AddEdge(cm.Authorizer, creators[(i+4)%NUM_CREATORS].Authorizer)
-			edges[i] = append(edges[i], fc.NewObjectBuilder().Address(creators[(i+4)%NUM_CREATORS].Authorizer.AccountId))
+		for j := 1; j <= numEdges(i); j++ {
+			edges[i] = append(edges[i], fc.NewObjectBuilder().Address(creators[(i+j)%NUM_CREATORS].Authorizer.AccountId))
 		}
 	}
+	parallel(creators, func(i int, cm *SenderManager) {
+		for j := 1; j <= numEdges(i); j++ {
+			cm.AddEdge(cm.Authorizer, creators[(i+j)%NUM_CREATORS].Authorizer)
+		}
+	})
 
-	WaitSecond(3)
 	w = &sync.WaitGroup{}
 	for i, cm := range creators {
 		w.Add(1)
@@ -234,7 +258,6 @@ func main() {
 	creators[0].ProposeNewAlgorithm(core.ConsensusKey, newCon)
 	creators[0].CreateProslSignStorage()
 
-	WaitSecond(2)
 	creators[0].QueryProslPassed(core.ConsensusKey, newCon)
 	creators[0].QueryProslPassed(core.IncentiveKey, newInc)
 	logger.Info(color.GreenString("===================== :: Passed Scenario 4 :: ====================="))
@@ -249,7 +272,6 @@ func main() {
 		cm.VoteNewConsensus(creators[0].Authorizer, core.ConsensusKey, conStj)
 	}
 
-	WaitSecond(2)
 	creators[0].QueryCollectSigsPassed(core.IncentiveKey, incStj, 9)
 	creators[0].QueryCollectSigsPassed(core.ConsensusKey, conStj, 9)
 	logger.Info(color.GreenString("===================== :: Passed Scenario 5 :: ====================="))
@@ -258,31 +280,21 @@ func main() {
 	logger.Info(color.BlueString("======= Scenario 6 :: CheckAndCommit NewConsensusAlgorithm  ======="))
 	creators[0].CheckAndCommit()
 
-	WaitSecond(2)
 	creators[0].QueryRootProslPassed(incStj.GetStorage())
 	creators[0].QueryRootProslPassed(conStj.GetStorage())
 	logger.Info(color.GreenString("===================== :: Passed Scenario 6 :: ====================="))
 
 	// 9. 合意形成を行うPeerが切り替わる. fin
-	for i := 0; i < 5; i++ {
-		WaitSecond(3)
-		authorizer.QueryAccountsBalances()
-		logger.Info(color.GreenString("===================== :: Waiting 3 seconds :: ====================="))
-	}
+	watchBalances(logger, authorizer, 5)
 
 	// 10. 全クリエータが2を集中フォロー
 	logger.Info(color.BlueString("================== Scenario 7 :: Follow 2 Creator  =================="))
-	WaitSecond(1)
-	for _, cm := range creators {
-		go cm.AddEdge(cm.Authorizer, creators[2].Authorizer)
-	}
+	parallel(creators, func(i int, cm *SenderManager) {
+		cm.AddEdge(cm.Authorizer, creators[2].Authorizer)
+	})
 	logger.Info(color.GreenString("===================== :: Passed Scenario 7 :: ====================="))
 
-	for i := 0; i < 5; i++ {
-		WaitSecond(3)
-		authorizer.QueryAccountsBalances()
-		logger.Info(color.GreenString("===================== :: Waiting 3 seconds :: ====================="))
-	}
+	watchBalances(logger, authorizer, 5)
 
 	// 11. Peer もインセンティブが得られるように変更
 	logger.Info(color.BlueString("=========== Scenario 8 :: Propose NewConsensusAlgorithm2 ==========="))
@@ -298,7 +310,6 @@ func main() {
 		proposer.ProposeNewAlgorithm(core.IncentiveKey, newInc2) // proposer is creators[1]
 		proposer.CreateProslSignStorage()
 
-		WaitSecond(2)
 		proposer.QueryProslPassed(core.IncentiveKey, newInc2)
 		proposer.QueryProslPassed(core.IncentiveKey, newInc2)
 
@@ -310,19 +321,13 @@ func main() {
 			cm.VoteNewConsensus(proposer.Authorizer, core.IncentiveKey, incStj2)
 		}
 
-		WaitSecond(2)
 		proposer.QueryCollectSigsPassed(core.IncentiveKey, incStj2, 9)
 
 		proposer.CheckAndCommitInc()
-		WaitSecond(2)
 		proposer.QueryRootProslPassed(incStj2.GetStorage())
 	}
 	logger.Info(color.GreenString("===================== :: Passed Scenario 8 :: ====================="))
 
 	// 12. Peer にもインセンティブが配られる。
-	for {
-		WaitSecond(3)
-		authorizer.QueryAccountsBalances()
-		logger.Info(color.GreenString("===================== :: Waiting 3 seconds :: ====================="))
-	}
+	watchBalances(logger, authorizer, -1)
 }
@@ -1,12 +1,14 @@
 package gate
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/inconshreveable/log15"
 	"github.com/pkg/errors"
 	"github.com/proskenion/proskenion/core"
 	"github.com/proskenion/proskenion/core/model"
 	"github.com/proskenion/proskenion/repository"
+	"time"
 )
 
 type API struct {
@@ -32,25 +34,137 @@ func (a *API) Write(tx model.Transaction) error {
 		}
 		return errors.Wrapf(repository.ErrProposalTxQueuePush, err.Error())
 	}
+	a.rp.SetTxQueued(tx.Hash())
 	if err := a.gs.GossipTx(tx); err != nil {
 		return errors.Wrap(core.ErrAPIWriteGossipTxError, err.Error())
 	}
 	return nil
 }
 
+// DefaultWriteTimeout は WriteAndWait の timeout が指定されなかった時に待つ時間
+const DefaultWriteTimeout = 30 * time.Second
+
+// WriteAndWait は Write した Transaction の status が Commit の通知で確定するまで待つ。
+// Committed なら Block と Receipt を返し、Rejected, Expired, timeout ならそれぞれの error を返す
+func (a *API) WriteAndWait(tx model.Transaction, timeout time.Duration) (core.WriteResult, error) {
+	if timeout <= 0 {
+		timeout = DefaultWriteTimeout
+	}
+	// Write の前に購読して、その間の Commit を取りこぼさないようにする
+	commitChan, unsubscribe := a.rp.SubscribeCommit()
+	defer unsubscribe()
+	if err := a.Write(tx); err != nil {
+		return core.WriteResult{}, err
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		if status, ok := a.rp.TxStatus(tx.Hash()); ok {
+			switch status.Code {
+			case core.TxStatusCommitted:
+				return a.writeResult(tx.Hash(), status.Height)
+			case core.TxStatusRejected:
+				return core.WriteResult{}, errors.Wrap(core.ErrAPIWriteRejected, status.Reason)
+			case core.TxStatusExpired:
+				return core.WriteResult{}, errors.Wrapf(core.ErrAPIWriteExpired, "height: %d", status.Height)
+			}
+		}
+		select {
+		case <-commitChan:
+		case <-timer.C:
+			return core.WriteResult{}, errors.Wrapf(core.ErrAPIWriteTimeout, "txHash: %x, timeout: %s", tx.Hash(), timeout)
+		}
+	}
+}
+
+func (a *API) writeResult(txHash model.Hash, height int64) (core.WriteResult, error) {
+	blockHash, err := a.rp.BlockHashAt(height)
+	if err != nil {
+		return core.WriteResult{}, err
+	}
+	receipt, err := a.GetReceipt(txHash)
+	if err != nil {
+		return core.WriteResult{}, err
+	}
+	return core.WriteResult{BlockHash: blockHash, Height: height, Receipt: receipt}, nil
+}
+
 func (a *API) Read(query model.Query) (model.QueryResponse, error) {
 	if err := query.Verify(); err != nil {
 		return nil, errors.Wrap(core.ErrAPIQueryVerifyError, err.Error())
 	}
-	wsv, err := a.rp.TopWSV()
+	top, ok := a.rp.Top()
+	if !ok {
+		return nil, errors.Wrap(core.ErrAPIQueryNotFound, "empty blockchain")
+	}
+	// top と同じ Block の WSV を読む
+	wsv, err := a.rp.WSVAt(top.Hash())
 	if err != nil {
 		return nil, fmt.Errorf("Failed APIGate Read, error top WSV: %s", err.Error())
 	}
 	defer wsv.Commit()
+	return a.read(top, wsv, query, true)
+}
+
+// MaxBatchQueries は ReadBatch で 1 度に実行できる Query の数
+const MaxBatchQueries = 100
+
+// ReadBatch は top の Block の WSV を 1 つ開き、全ての Query をその WSV に対して実行する。
+// 失敗した Query はその error を結果とし、他の Query の実行は続ける
+func (a *API) ReadBatch(queries []model.Query) (model.BatchQueryResponse, error) {
+	if len(queries) > MaxBatchQueries {
+		return nil, errors.Wrapf(core.ErrAPIQueryBatchTooLarge, "queries: %d, max: %d", len(queries), MaxBatchQueries)
+	}
+	top, ok := a.rp.Top()
+	if !ok {
+		return nil, errors.Wrap(core.ErrAPIQueryNotFound, "empty blockchain")
+	}
+	wsv, err := a.rp.WSVAt(top.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("Failed APIGate ReadBatch, error WSV: %s", err.Error())
+	}
+	defer wsv.Commit()
+	rets := make([]model.QueryResponse, len(queries))
+	errs := make([]error, len(queries))
+	for i, query := range queries {
+		if err := query.Verify(); err != nil {
+			errs[i] = errors.Wrap(core.ErrAPIQueryVerifyError, err.Error())
+			continue
+		}
+		if err := checkBatchQuery(top, query); err != nil {
+			errs[i] = err
+			continue
+		}
+		// 結果は BatchResponse でまとめて署名する
+		rets[i], errs[i] = a.read(top, wsv, query, false)
+	}
+	return a.qp.BatchResponse(top, rets, errs)
+}
+
+// checkBatchQuery は BatchQuery の Query が top 以外の Block の WSV を読まないことを検証する。
+// at と、top 以外の Block で作られた cursor は使えない
+func checkBatchQuery(top model.Block, query model.Query) error {
+	switch query.GetPayload().GetRequestCode() {
+	case model.BlockObjectCode, model.TransactionObjectCode:
+		return nil
+	}
+	if query.GetPayload().GetAt() != nil {
+		return errors.Wrap(core.ErrAPIQueryValidateError, "at can not be used in batch query")
+	}
+	if cursor := query.GetPayload().GetCursor(); cursor != nil && cursor.GetBlockHash() != nil &&
+		!bytes.Equal(cursor.GetBlockHash(), top.Hash()) {
+		return errors.Wrapf(core.ErrAPIQueryValidateError,
+			"cursor block: %x, top block: %x", cursor.GetBlockHash(), top.Hash())
+	}
+	return nil
+}
+
+// read は top の Block の wsv で Query を検証して実行する。sign なら結果に署名する
+func (a *API) read(top model.Block, wsv core.WSV, query model.Query, sign bool) (model.QueryResponse, error) {
 	if err := a.qv.Validate(wsv, query); err != nil {
 		return nil, errors.Wrap(core.ErrAPIQueryValidateError, err.Error())
 	}
-	res, err := a.qp.Query(wsv, query)
+	res, err := a.query(top, wsv, query, sign)
 	if err != nil {
 		if errors.Cause(err) == core.ErrQueryProcessorNotFound {
 			return nil, errors.Wrap(core.ErrAPIQueryNotFound, err.Error())
@@ -59,3 +173,399 @@ func (a *API) Read(query model.Query) (model.QueryResponse, error) {
 	}
 	return res, nil
 }
+
+func (a *API) query(top model.Block, wsv core.WSV, query model.Query, sign bool) (model.QueryResponse, error) {
+	switch query.GetPayload().GetRequestCode() {
+	case model.BlockObjectCode, model.TransactionObjectCode:
+		return a.queryChain(top, query, sign)
+	}
+	// at が指定されていればその Block の WSV から取得する。
+	// cursor が Block の hash を持つ 2 ページ目以降は、最初のページを取得した Block の WSV から取得する
+	blockHash := top.Hash()
+	at := query.GetPayload().GetAt()
+	if at != nil {
+		hash, err := a.blockHashAt(at)
+		if err != nil {
+			return nil, err
+		}
+		blockHash = hash
+	}
+	if cursor := query.GetPayload().GetCursor(); cursor != nil && cursor.GetBlockHash() != nil {
+		if at != nil && !bytes.Equal(blockHash, cursor.GetBlockHash()) {
+			return nil, errors.Wrapf(core.ErrAPIQueryValidateError,
+				"cursor block: %x, at block: %x", cursor.GetBlockHash(), blockHash)
+		}
+		blockHash = cursor.GetBlockHash()
+	}
+	var reading model.ObjectFinder = wsv
+	// 署名と read_acl は最新の WSV で検証済み。top 以外の Block の WSV から取得する場合は、
+	// 読み込む WSV の read_acl でも検証する (両方で読み込める時のみ取得できる)
+	if !bytes.Equal(blockHash, top.Hash()) {
+		atWSV, err := a.wsvAt(blockHash)
+		if err != nil {
+			return nil, err
+		}
+		defer atWSV.Commit()
+		if err := a.qv.ValidateRead(atWSV, query.GetPayload().GetAuthorizerId(), query.GetPayload().GetFromId()); err != nil {
+			return nil, errors.Wrap(core.ErrAPIQueryValidateError, err.Error())
+		}
+		reading = atWSV
+	}
+	reading = &readingWSV{reading, blockHash}
+	if !sign {
+		return a.qp.QueryUnsigned(reading, query)
+	}
+	return a.qp.Query(reading, query)
+}
+
+// readingWSV は Query で読み込む Block の hash を持つ wsv。nextCursor にその Block の hash を含める
+type readingWSV struct {
+	model.ObjectFinder
+	blockHash model.Hash
+}
+
+func (w *readingWSV) ReadingBlockHash() model.Hash {
+	return w.blockHash
+}
+
+func (a *API) queryChain(top model.Block, query model.Query, sign bool) (model.QueryResponse, error) {
+	if top == nil {
+		return nil, errors.Wrap(core.ErrAPIQueryNotFound, "empty blockchain")
+	}
+	rtx, err := a.rp.Begin()
+	if err != nil {
+		return nil, err
+	}
+	bc, err := rtx.Blockchain(top.Hash())
+	if err != nil {
+		return nil, core.RollBackTx(rtx, fmt.Errorf("Failed APIGate Read, error top Blockchain: %s", err.Error()))
+	}
+	txHistory, err := rtx.TxHistory(top.GetPayload().GetTxHistoryHash())
+	if err != nil {
+		return nil, core.RollBackTx(rtx, fmt.Errorf("Failed APIGate Read, error top TxHistory: %s", err.Error()))
+	}
+	defer txHistory.Commit()
+	if !sign {
+		return a.qp.QueryChainUnsigned(bc, txHistory, top, query)
+	}
+	return a.qp.QueryChain(bc, txHistory, top, query)
+}
+
+// blockHashAt は at で指定された Block の hash を返す
+func (a *API) blockHashAt(at model.QueryAt) (model.Hash, error) {
+	if blockHash := at.GetBlockHash(); blockHash != nil {
+		return blockHash, nil
+	}
+	hash, err := a.rp.BlockHashAt(at.GetHeight())
+	if err != nil {
+		if errors.Cause(err) == core.ErrBlockchainNotFound {
+			return nil, errors.Wrap(core.ErrAPIQueryNotFound, err.Error())
+		}
+		return nil, err
+	}
+	return hash, nil
+}
+
+func (a *API) wsvAt(blockHash model.Hash) (core.WSV, error) {
+	wsv, err := a.rp.WSVAt(blockHash)
+	if err != nil {
+		if errors.Cause(err) == core.ErrBlockchainNotFound {
+			return nil, errors.Wrap(core.ErrAPIQueryNotFound, err.Error())
+		}
+		return nil, err
+	}
+	return wsv, nil
+}
+
+func (a *API) GetReceipt(txHash model.Hash) (model.Receipt, error) {
+	top, ok := a.rp.Top()
+	if !ok {
+		return nil, errors.Wrap(core.ErrAPIReceiptNotFound, "empty blockchain")
+	}
+	rtx, err := a.rp.Begin()
+	if err != nil {
+		return nil, err
+	}
+	txHistory, err := rtx.TxHistory(top.GetPayload().GetTxHistoryHash())
+	if err != nil {
+		return nil, core.RollBackTx(rtx, fmt.Errorf("Failed APIGate GetReceipt, error top TxHistory: %s", err.Error()))
+	}
+	defer txHistory.Commit()
+	receipt, err := txHistory.GetReceipt(txHash)
+	if err != nil {
+		if errors.Cause(err) == core.ErrTxHistoryReceiptNotFound {
+			return nil, errors.Wrap(core.ErrAPIReceiptNotFound, err.Error())
+		}
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// GetTxStatus は Transaction の status を返す。
+// 保持期間を過ぎて status が無い場合でも、TxHistory にあれば height 不明の Committed を返す
+func (a *API) GetTxStatus(txHash model.Hash) (core.TxStatus, error) {
+	if status, ok := a.rp.TxStatus(txHash); ok {
+		return status, nil
+	}
+	top, ok := a.rp.Top()
+	if !ok {
+		return core.TxStatus{}, errors.Wrap(core.ErrAPITxStatusNotFound, "empty blockchain")
+	}
+	rtx, err := a.rp.Begin()
+	if err != nil {
+		return core.TxStatus{}, err
+	}
+	txHistory, err := rtx.TxHistory(top.GetPayload().GetTxHistoryHash())
+	if err != nil {
+		return core.TxStatus{}, core.RollBackTx(rtx, fmt.Errorf("Failed APIGate GetTxStatus, error top TxHistory: %s", err.Error()))
+	}
+	defer txHistory.Commit()
+	if _, err := txHistory.GetTx(txHash); err != nil {
+		if errors.Cause(err) == core.ErrTxHistoryNotFound {
+			return core.TxStatus{}, errors.Wrapf(core.ErrAPITxStatusNotFound, "txHash: %x", txHash)
+		}
+		return core.TxStatus{}, err
+	}
+	return core.TxStatus{Code: core.TxStatusCommitted, Height: -1}, nil
+}
+
+func (a *API) GetProslMetrics(proslId string) (core.ProslMetrics, error) {
+	if _, err := model.NewAddress(proslId); err != nil {
+		return core.ProslMetrics{}, errors.Wrap(core.ErrAPIProslMetricsInvalidArgument, err.Error())
+	}
+	return a.rp.ProslMetrics(proslId), nil
+}
+
+// errSubscribeDone は購読が終了したことを表す
+var errSubscribeDone = fmt.Errorf("subscription is done")
+
+// Subscribe は FromHeight の Block から top まで配信した後、Commit の通知を受ける度に新しい Block まで配信する。
+// 通知は取りこぼしても次の通知で top まで辿るので、配信される Block の height は連続する
+func (a *API) Subscribe(sub core.Subscription, done <-chan struct{}, eventChan chan core.SubscribeEvent) error {
+	if err := validSubscription(sub); err != nil {
+		return errors.Wrap(core.ErrAPISubscribeInvalidArgument, err.Error())
+	}
+	if sub.Code == core.StateSubscribe {
+		if err := a.validateStateSubscribe(sub); err != nil {
+			return err
+		}
+	}
+	// top を読む前に購読して、その間に Commit された Block を取りこぼさないようにする
+	commitChan, unsubscribe := a.rp.SubscribeCommit()
+	defer unsubscribe()
+	if sub.Code == core.TxStatusSubscribe {
+		return a.subscribeTxStatus(sub.TxHash, commitChan, done, eventChan)
+	}
+
+	next := sub.FromHeight
+	if next < 0 {
+		next = 0
+		if top, ok := a.rp.Top(); ok {
+			next = top.GetPayload().GetHeight() + 1
+		}
+	}
+	for {
+		if top, ok := a.rp.Top(); ok {
+			for ; next <= top.GetPayload().GetHeight(); next++ {
+				if err := a.publish(sub, top, next, done, eventChan); err != nil {
+					if err == errSubscribeDone {
+						return nil
+					}
+					return err
+				}
+			}
+		}
+		select {
+		case <-done:
+			return nil
+		case <-commitChan:
+		}
+	}
+}
+
+// subscribeTxStatus は Transaction の status が変わる度に配信し、確定したら終了する
+// status は Commit の時にしか変わらないので Commit の通知を受ける度に確認する
+func (a *API) subscribeTxStatus(txHash model.Hash, commitChan <-chan model.Block, done <-chan struct{}, eventChan chan core.SubscribeEvent) error {
+	var last *core.TxStatus
+	for {
+		status, err := a.GetTxStatus(txHash)
+		if err != nil && errors.Cause(err) != core.ErrAPITxStatusNotFound {
+			return err
+		}
+		if err == nil && (last == nil || *last != status) {
+			last = &status
+			select {
+			case eventChan <- core.SubscribeEvent{Height: status.Height, TxStatus: last}:
+			case <-done:
+				return nil
+			}
+			if status.Final() {
+				return nil
+			}
+		}
+		select {
+		case <-done:
+			return nil
+		case <-commitChan:
+		}
+	}
+}
+
+// validateStateSubscribe は STATE の購読対象の Storage に read_acl が無いことを検証する。
+// Subscription には署名が無いので read_acl のある Storage は購読できない
+func (a *API) validateStateSubscribe(sub core.Subscription) error {
+	wsv, err := a.rp.TopWSV()
+	if err != nil {
+		return err
+	}
+	defer wsv.Commit()
+	return a.validateStateRead(wsv, sub)
+}
+
+func (a *API) validateStateRead(wsv model.ObjectFinder, sub core.Subscription) error {
+	if err := a.qv.ValidateRead(wsv, "", sub.Prefix); err != nil {
+		if errors.Cause(err) == core.ErrQueryProcessorReadDenied {
+			return errors.Wrap(core.ErrAPISubscribeReadDenied, err.Error())
+		}
+		return err
+	}
+	return nil
+}
+
+func validSubscription(sub core.Subscription) error {
+	switch sub.Code {
+	case core.BlockSubscribe:
+		return nil
+	case core.TransactionSubscribe:
+		for _, id := range []string{sub.AuthorizerId, sub.TargetId} {
+			if id == "" {
+				continue
+			}
+			if _, err := model.NewAddress(id); err != nil {
+				return err
+			}
+		}
+		return nil
+	case core.StateSubscribe:
+		address, err := model.NewAddress(sub.Prefix)
+		if err != nil {
+			return err
+		}
+		if address.Storage() == "" {
+			return fmt.Errorf("prefix must have storage: %s", sub.Prefix)
+		}
+		return nil
+	case core.TxStatusSubscribe:
+		if len(sub.TxHash) == 0 {
+			return fmt.Errorf("txHash is empty")
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown subscribe code: %d", sub.Code)
+}
+
+// publish は top までの Blockchain の height の Block から sub に一致する Event を配信する
+func (a *API) publish(sub core.Subscription, top model.Block, height int64, done <-chan struct{}, eventChan chan core.SubscribeEvent) error {
+	rtx, err := a.rp.Begin()
+	if err != nil {
+		return err
+	}
+	bc, err := rtx.Blockchain(top.Hash())
+	if err != nil {
+		return core.RollBackTx(rtx, err)
+	}
+	block, err := bc.GetByHeight(height)
+	if err != nil {
+		return core.RollBackTx(rtx, err)
+	}
+	events := make([]core.SubscribeEvent, 0)
+	switch sub.Code {
+	case core.BlockSubscribe:
+		events = append(events, core.SubscribeEvent{Block: block})
+	case core.TransactionSubscribe:
+		txHistory, err := rtx.TxHistory(top.GetPayload().GetTxHistoryHash())
+		if err != nil {
+			return core.RollBackTx(rtx, err)
+		}
+		txList, err := txHistory.GetTxList(block.GetPayload().GetTxListHash())
+		if err != nil {
+			if errors.Cause(err) != core.ErrTxHistoryNotFound {
+				return core.RollBackTx(rtx, err)
+			}
+			break
+		}
+		for _, tx := range txList.List() {
+			if matchTx(tx, sub) {
+				events = append(events, core.SubscribeEvent{Transaction: tx})
+			}
+		}
+	case core.StateSubscribe:
+		wsv, err := rtx.WSV(block.GetPayload().GetWSVHash())
+		if err != nil {
+			return core.RollBackTx(rtx, err)
+		}
+		// 購読中に read_acl が定義された場合はそれ以降の Block を配信しない
+		if err := a.validateStateRead(wsv, sub); err != nil {
+			return core.RollBackTx(rtx, err)
+		}
+		preWSVHash := model.Hash(nil)
+		if len(block.GetPayload().GetPreBlockHash()) > 0 {
+			preBlock, err := bc.Get(block.GetPayload().GetPreBlockHash())
+			if err != nil {
+				return core.RollBackTx(rtx, err)
+			}
+			preWSVHash = preBlock.GetPayload().GetWSVHash()
+		}
+		obs, err := a.qp.QueryChanges(wsv, preWSVHash, model.MustAddress(sub.Prefix))
+		if err != nil {
+			return core.RollBackTx(rtx, err)
+		}
+		for _, o := range obs {
+			events = append(events, core.SubscribeEvent{Object: o})
+		}
+	}
+	if err := core.CommitTx(rtx); err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		event.Height = height
+		event.BlockHash = block.Hash()
+		select {
+		case eventChan <- event:
+		case <-done:
+			return errSubscribeDone
+		}
+	}
+	return nil
+}
+
+// matchTx は tx が sub の AuthorizerId の command か TargetId を対象とする command を含むか判定する
+// どちらも指定されていなければ全ての tx に一致する
+func matchTx(tx model.Transaction, sub core.Subscription) bool {
+	if sub.AuthorizerId == "" && sub.TargetId == "" {
+		return true
+	}
+	for _, cmd := range tx.GetPayload().GetCommands() {
+		if sub.AuthorizerId != "" && sameAccount(cmd.GetAuthorizerId(), sub.AuthorizerId) {
+			return true
+		}
+		if sub.TargetId != "" && sameAccount(cmd.GetTargetId(), sub.TargetId) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameAccount は 2 つの address が同じ Account のものか判定する
+func sameAccount(a, b string) bool {
+	aa, err := model.NewAddress(a)
+	if err != nil {
+		return false
+	}
+	ba, err := model.NewAddress(b)
+	if err != nil {
+		return false
+	}
+	return aa.AccountId() == ba.AccountId()
+}
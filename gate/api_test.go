@@ -14,7 +14,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/net/context"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestAPI_WriteAndRead(t *testing.T) {
@@ -111,3 +113,667 @@ func TestAPI_WriteAndRead(t *testing.T) {
 		}
 	}
 }
+
+func TestAPI_ReadAt(t *testing.T) {
+	fc := RandomFactory()
+	conf := RandomConfig()
+	rp := repository.NewRepository(RandomDBA(), RandomCryptor(), fc, RandomConfig())
+	queue := repository.NewProposalTxQueueOnMemory(RandomConfig())
+	logger := log15.New(context.TODO())
+	qp := query.NewQueryProcessor(fc, RandomConfig())
+	qv := query.NewQueryValidator(fc, RandomConfig())
+	api := NewAPI(rp, queue, qp, qv, &p2p.MockGossip{}, logger)
+	cm := commit.NewCommitSystem(fc, RandomCryptor(), queue, rp, conf)
+
+	authorizer := NewAccountWithPri("authoirzer@com")
+	GenesisCommitFromAccounts(t, rp, []*AccountWithPri{authorizer})
+	genesis, ok := rp.Top()
+	require.True(t, ok)
+
+	require.NoError(t, api.Write(CreateAccountTx(t, authorizer, "target1@com")))
+	_, _, err := cm.CreateBlock(0)
+	require.NoError(t, err)
+	top, ok := rp.Top()
+	require.True(t, ok)
+	require.Equal(t, int64(1), top.GetPayload().GetHeight())
+
+	for _, c := range []struct {
+		name  string
+		build func(builder model.QueryBuilder) model.QueryBuilder
+		err   error
+	}{
+		{
+			"top",
+			func(builder model.QueryBuilder) model.QueryBuilder { return builder },
+			nil,
+		},
+		{
+			"at height 1",
+			func(builder model.QueryBuilder) model.QueryBuilder { return builder.AtHeight(1) },
+			nil,
+		},
+		{
+			"at top hash",
+			func(builder model.QueryBuilder) model.QueryBuilder { return builder.AtBlockHash(top.Hash()) },
+			nil,
+		},
+		{
+			"at height 0 not created yet",
+			func(builder model.QueryBuilder) model.QueryBuilder { return builder.AtHeight(0) },
+			core.ErrAPIQueryNotFound,
+		},
+		{
+			"at genesis hash not created yet",
+			func(builder model.QueryBuilder) model.QueryBuilder { return builder.AtBlockHash(genesis.Hash()) },
+			core.ErrAPIQueryNotFound,
+		},
+		{
+			"at future height",
+			func(builder model.QueryBuilder) model.QueryBuilder { return builder.AtHeight(2) },
+			core.ErrAPIQueryNotFound,
+		},
+		{
+			"at unknown hash",
+			func(builder model.QueryBuilder) model.QueryBuilder { return builder.AtBlockHash(RandomByte()) },
+			core.ErrAPIQueryNotFound,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			q := c.build(fc.NewQueryBuilder().
+				AuthorizerId(authorizer.AccountId).
+				FromId("target1@com/account").
+				RequestCode(model.AccountObjectCode)).
+				Build()
+			require.NoError(t, q.Sign(authorizer.Pubkey, authorizer.Prikey))
+			res, err := api.Read(q)
+			if c.err != nil {
+				assert.EqualError(t, errors.Cause(err), c.err.Error())
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "target1@com", res.GetObject().GetAccount().GetAccountId())
+			}
+		})
+	}
+
+	// genesis の時点の authorizer は取得できる
+	q := fc.NewQueryBuilder().
+		AuthorizerId(authorizer.AccountId).
+		FromId(authorizer.AccountId + "/account").
+		RequestCode(model.AccountObjectCode).
+		AtHeight(0).
+		Build()
+	require.NoError(t, q.Sign(authorizer.Pubkey, authorizer.Prikey))
+	res, err := api.Read(q)
+	require.NoError(t, err)
+	assert.Equal(t, authorizer.AccountId, res.GetObject().GetAccount().GetAccountId())
+}
+
+func TestAPI_ReadChain(t *testing.T) {
+	fc := RandomFactory()
+	conf := RandomConfig()
+	rp := repository.NewRepository(RandomDBA(), RandomCryptor(), fc, RandomConfig())
+	queue := repository.NewProposalTxQueueOnMemory(RandomConfig())
+	logger := log15.New(context.TODO())
+	qp := query.NewQueryProcessor(fc, RandomConfig())
+	qv := query.NewQueryValidator(fc, RandomConfig())
+	api := NewAPI(rp, queue, qp, qv, &p2p.MockGossip{}, logger)
+	cm := commit.NewCommitSystem(fc, RandomCryptor(), queue, rp, conf)
+
+	authorizer := NewAccountWithPri("authoirzer@com")
+	GenesisCommitFromAccounts(t, rp, []*AccountWithPri{authorizer})
+	genesis, ok := rp.Top()
+	require.True(t, ok)
+
+	tx := CreateAccountTx(t, authorizer, "target1@com")
+	require.NoError(t, api.Write(tx))
+	_, _, err := cm.CreateBlock(0)
+	require.NoError(t, err)
+	top, ok := rp.Top()
+	require.True(t, ok)
+
+	read := func(builder model.QueryBuilder) (model.QueryResponse, error) {
+		q := builder.AuthorizerId(authorizer.AccountId).Build()
+		require.NoError(t, q.Sign(authorizer.Pubkey, authorizer.Prikey))
+		return api.Read(q)
+	}
+
+	t.Run("block top", func(t *testing.T) {
+		res, err := read(fc.NewQueryBuilder().Select("*").RequestCode(model.BlockObjectCode))
+		require.NoError(t, err)
+		assert.Equal(t, top.Hash(), res.GetObject().GetBlock().Hash())
+	})
+	t.Run("block at height", func(t *testing.T) {
+		res, err := read(fc.NewQueryBuilder().Select("*").RequestCode(model.BlockObjectCode).AtHeight(0))
+		require.NoError(t, err)
+		assert.Equal(t, genesis.Hash(), res.GetObject().GetBlock().Hash())
+	})
+	t.Run("block select key", func(t *testing.T) {
+		res, err := read(fc.NewQueryBuilder().Select("height").RequestCode(model.BlockObjectCode).AtBlockHash(top.Hash()))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), res.GetObject().GetI64())
+	})
+	t.Run("block transactions", func(t *testing.T) {
+		res, err := read(fc.NewQueryBuilder().Select(query.TransactionsSelect).RequestCode(model.BlockObjectCode).AtHeight(1))
+		require.NoError(t, err)
+		hashes := make([]model.Hash, 0)
+		for _, o := range res.GetObject().GetList() {
+			hashes = append(hashes, o.GetTransaction().Hash())
+		}
+		assert.Contains(t, hashes, tx.Hash())
+	})
+	t.Run("block not found", func(t *testing.T) {
+		_, err := read(fc.NewQueryBuilder().Select("*").RequestCode(model.BlockObjectCode).AtHeight(2))
+		assert.EqualError(t, errors.Cause(err), core.ErrAPIQueryNotFound.Error())
+	})
+	t.Run("transaction", func(t *testing.T) {
+		res, err := read(fc.NewQueryBuilder().RequestCode(model.TransactionObjectCode).TxHash(tx.Hash()))
+		require.NoError(t, err)
+		assert.Equal(t, tx.Hash(), res.GetObject().GetTransaction().Hash())
+	})
+	t.Run("transaction not found", func(t *testing.T) {
+		_, err := read(fc.NewQueryBuilder().RequestCode(model.TransactionObjectCode).TxHash(RandomByte()))
+		assert.EqualError(t, errors.Cause(err), core.ErrAPIQueryNotFound.Error())
+	})
+	t.Run("transaction empty hash", func(t *testing.T) {
+		_, err := read(fc.NewQueryBuilder().RequestCode(model.TransactionObjectCode))
+		assert.EqualError(t, errors.Cause(err), core.ErrAPIQueryVerifyError.Error())
+	})
+}
+
+// notifyRepository は SubscribeCommit の後に最初に Top が読まれたら registered を閉じる
+type notifyRepository struct {
+	core.Repository
+	registered chan struct{}
+	subscribed bool
+	once       sync.Once
+}
+
+func (r *notifyRepository) SubscribeCommit() (<-chan model.Block, func()) {
+	r.subscribed = true
+	return r.Repository.SubscribeCommit()
+}
+
+func (r *notifyRepository) Top() (model.Block, bool) {
+	top, ok := r.Repository.Top()
+	if r.subscribed {
+		r.once.Do(func() { close(r.registered) })
+	}
+	return top, ok
+}
+
+func TestAPI_Subscribe(t *testing.T) {
+	fc := RandomFactory()
+	conf := RandomConfig()
+	rp := repository.NewRepository(RandomDBA(), RandomCryptor(), fc, RandomConfig())
+	queue := repository.NewProposalTxQueueOnMemory(RandomConfig())
+	logger := log15.New(context.TODO())
+	qp := query.NewQueryProcessor(fc, RandomConfig())
+	qv := query.NewQueryValidator(fc, RandomConfig())
+	api := NewAPI(rp, queue, qp, qv, &p2p.MockGossip{}, logger)
+	cm := commit.NewCommitSystem(fc, RandomCryptor(), queue, rp, conf)
+
+	authorizer := NewAccountWithPri("authoirzer@com")
+	GenesisCommitFromAccounts(t, rp, []*AccountWithPri{authorizer})
+	genesis, ok := rp.Top()
+	require.True(t, ok)
+
+	tx := CreateAccountTx(t, authorizer, "target1@com")
+	require.NoError(t, api.Write(tx))
+	_, _, err := cm.CreateBlock(0)
+	require.NoError(t, err)
+	top, ok := rp.Top()
+	require.True(t, ok)
+
+	// subscribe は n 個の Event を受け取ったら購読を終了する
+	subscribe := func(sub core.Subscription, n int) ([]core.SubscribeEvent, chan error) {
+		done := make(chan struct{})
+		eventChan := make(chan core.SubscribeEvent)
+		errChan := make(chan error, 1)
+		go func() {
+			errChan <- api.Subscribe(sub, done, eventChan)
+		}()
+		events := make([]core.SubscribeEvent, 0, n)
+		for len(events) < n {
+			select {
+			case event := <-eventChan:
+				events = append(events, event)
+			case err := <-errChan:
+				require.NoError(t, err)
+			}
+		}
+		close(done)
+		return events, errChan
+	}
+
+	t.Run("blocks from height", func(t *testing.T) {
+		events, errChan := subscribe(core.Subscription{Code: core.BlockSubscribe, FromHeight: 0}, 2)
+		assert.NoError(t, <-errChan)
+		assert.Equal(t, int64(0), events[0].Height)
+		assert.Equal(t, genesis.Hash(), events[0].Block.Hash())
+		assert.Equal(t, int64(1), events[1].Height)
+		assert.Equal(t, top.Hash(), events[1].BlockHash)
+	})
+	t.Run("transactions by authorizer", func(t *testing.T) {
+		events, errChan := subscribe(core.Subscription{Code: core.TransactionSubscribe, FromHeight: 1, AuthorizerId: authorizer.AccountId}, 1)
+		assert.NoError(t, <-errChan)
+		assert.Equal(t, tx.Hash(), events[0].Transaction.Hash())
+		assert.Equal(t, int64(1), events[0].Height)
+	})
+	t.Run("state changes under prefix", func(t *testing.T) {
+		events, errChan := subscribe(core.Subscription{Code: core.StateSubscribe, FromHeight: 1, Prefix: "target1@com/account"}, 1)
+		assert.NoError(t, <-errChan)
+		assert.Equal(t, "target1@com", events[0].Object.GetAccount().GetAccountId())
+	})
+	t.Run("new blocks after commit", func(t *testing.T) {
+		nrp := &notifyRepository{Repository: rp, registered: make(chan struct{})}
+		napi := NewAPI(nrp, queue, qp, qv, &p2p.MockGossip{}, logger)
+		done := make(chan struct{})
+		eventChan := make(chan core.SubscribeEvent)
+		errChan := make(chan error, 1)
+		go func() {
+			errChan <- napi.Subscribe(core.Subscription{Code: core.TransactionSubscribe, FromHeight: -1, TargetId: "target2@com"}, done, eventChan)
+		}()
+
+		// 購読を始めて top を読んだ後に Commit する
+		select {
+		case <-nrp.registered:
+		case err := <-errChan:
+			require.NoError(t, err)
+		}
+		tx2 := CreateAccountTx(t, authorizer, "target2@com")
+		require.NoError(t, api.Write(tx2))
+		_, _, err := cm.CreateBlock(0)
+		require.NoError(t, err)
+
+		var event core.SubscribeEvent
+		select {
+		case event = <-eventChan:
+		case err := <-errChan:
+			require.NoError(t, err)
+		}
+		close(done)
+		assert.NoError(t, <-errChan)
+		assert.Equal(t, tx2.Hash(), event.Transaction.Hash())
+		assert.Equal(t, int64(2), event.Height)
+	})
+	t.Run("invalid prefix", func(t *testing.T) {
+		err := api.Subscribe(core.Subscription{Code: core.StateSubscribe, Prefix: "authoirzer@com"}, nil, nil)
+		assert.EqualError(t, errors.Cause(err), core.ErrAPISubscribeInvalidArgument.Error())
+	})
+}
+
+func TestAPI_ReadAtReadAcl(t *testing.T) {
+	fc := RandomFactory()
+	rp := repository.NewRepository(RandomDBA(), RandomCryptor(), fc, RandomConfig())
+	queue := repository.NewProposalTxQueueOnMemory(RandomConfig())
+	logger := log15.New(context.TODO())
+	qp := query.NewQueryProcessor(fc, RandomConfig())
+	qv := query.NewQueryValidator(fc, RandomConfig())
+	api := NewAPI(rp, queue, qp, qv, &p2p.MockGossip{}, logger)
+
+	owner := NewAccountWithPri("owner@com")
+	auditor := NewAccountWithPri("auditor@com")
+	GenesisCommitFromAccounts(t, rp, []*AccountWithPri{owner, auditor})
+	// height 1 : auditor role のみ読み込める Storage
+	CommitTxWrapBlock(t, rp, fc, fc.NewTxBuilder().
+		DefineStorage("root@com", "/secret", fc.NewStorageBuilder().Dict(core.ReadAclKey, map[string]model.Object{
+			core.ReadAclRolesKey: fc.NewObjectBuilder().List([]model.Object{fc.NewObjectBuilder().Str("auditor")}),
+		}).Build()).
+		CreateStorage("root@com", owner.AccountId+"/secret").
+		Build())
+	// height 2 : auditor に role を付与する
+	CommitTxWrapBlock(t, rp, fc, fc.NewTxBuilder().
+		GrantPermission("root@com", auditor.AccountId, "auditor", "com").
+		Build())
+
+	for _, c := range []struct {
+		name   string
+		height int64
+		err    error
+	}{
+		{"at height 2", 2, nil},
+		// 最新の WSV では読み込めるが、at の Block の時点では読み込めない
+		{"at height 1 before granted", 1, core.ErrAPIQueryValidateError},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			q := fc.NewQueryBuilder().
+				AuthorizerId(auditor.AccountId).
+				FromId(owner.AccountId + "/secret").
+				RequestCode(model.StorageObjectCode).
+				AtHeight(c.height).
+				Build()
+			require.NoError(t, q.Sign(auditor.Pubkey, auditor.Prikey))
+			_, err := api.Read(q)
+			if c.err != nil {
+				assert.EqualError(t, errors.Cause(err), c.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAPI_ReadCursor(t *testing.T) {
+	fc := RandomFactory()
+	rp := repository.NewRepository(RandomDBA(), RandomCryptor(), fc, RandomConfig())
+	queue := repository.NewProposalTxQueueOnMemory(RandomConfig())
+	logger := log15.New(context.TODO())
+	qp := query.NewQueryProcessor(fc, RandomConfig())
+	qv := query.NewQueryValidator(fc, RandomConfig())
+	api := NewAPI(rp, queue, qp, qv, &p2p.MockGossip{}, logger)
+
+	authorizer := NewAccountWithPri("authoirzer@com")
+	GenesisCommitFromAccounts(t, rp, []*AccountWithPri{authorizer})
+	// height 1
+	CommitTxWrapBlock(t, rp, fc, fc.NewTxBuilder().
+		CreateAccount("root@com", "a@page.com", []model.PublicKey{}, 0).
+		CreateAccount("root@com", "b@page.com", []model.PublicKey{}, 0).
+		Build())
+	first, ok := rp.Top()
+	require.True(t, ok)
+
+	pageQuery := func(cursor model.Cursor, height int64) model.Query {
+		builder := fc.NewQueryBuilder().
+			AuthorizerId(authorizer.AccountId).
+			FromId("page.com/account").
+			Select("*").
+			RequestCode(model.ListObjectCode).
+			Limit(1).
+			CreatedTime(RandomNow())
+		if cursor == nil {
+			builder = builder.Cursor(nil, model.ASC, nil)
+		} else {
+			builder = builder.Cursor(cursor.GetLastKey(), cursor.GetOrder(), cursor.GetBlockHash())
+		}
+		if height > 0 {
+			builder = builder.AtHeight(height)
+		}
+		q := builder.Build()
+		require.NoError(t, q.Sign(authorizer.Pubkey, authorizer.Prikey))
+		return q
+	}
+	res, err := api.Read(pageQuery(nil, 0))
+	require.NoError(t, err)
+	require.Len(t, res.GetObject().GetList(), 1)
+	cursor := res.GetNextCursor()
+	require.NotNil(t, cursor)
+	assert.Equal(t, first.Hash(), cursor.GetBlockHash())
+
+	// height 2 : 次のページを取得する前に残高が変わる
+	CommitTxWrapBlock(t, rp, fc, fc.NewTxBuilder().
+		AddBalance("root@com", "a@page.com", 100).
+		AddBalance("root@com", "b@page.com", 100).
+		Build())
+
+	// 次のページも最初のページを取得した Block から取得する
+	res, err = api.Read(pageQuery(cursor, 0))
+	require.NoError(t, err)
+	require.Len(t, res.GetObject().GetList(), 1)
+	assert.Equal(t, int64(0), res.GetObject().GetList()[0].GetAccount().GetBalance())
+
+	// cursor と異なる Block を at に指定することはできない
+	_, err = api.Read(pageQuery(cursor, 2))
+	assert.EqualError(t, errors.Cause(err), core.ErrAPIQueryValidateError.Error())
+}
+
+func TestAPI_SubscribeReadAcl(t *testing.T) {
+	fc := RandomFactory()
+	rp := repository.NewRepository(RandomDBA(), RandomCryptor(), fc, RandomConfig())
+	queue := repository.NewProposalTxQueueOnMemory(RandomConfig())
+	logger := log15.New(context.TODO())
+	qp := query.NewQueryProcessor(fc, RandomConfig())
+	qv := query.NewQueryValidator(fc, RandomConfig())
+	api := NewAPI(rp, queue, qp, qv, &p2p.MockGossip{}, logger)
+
+	authorizer := NewAccountWithPri("authoirzer@com")
+	GenesisCommitFromAccounts(t, rp, []*AccountWithPri{authorizer})
+	CommitTxWrapBlock(t, rp, fc, fc.NewTxBuilder().
+		DefineStorage("root@com", "/secret", fc.NewStorageBuilder().Dict(core.ReadAclKey, map[string]model.Object{
+			core.ReadAclOwnerKey: fc.NewObjectBuilder().Bool(true),
+		}).Build()).
+		CreateStorage("root@com", authorizer.AccountId+"/secret").
+		Build())
+
+	// Subscription には署名が無いので read_acl のある Storage は購読できない
+	err := api.Subscribe(core.Subscription{Code: core.StateSubscribe, FromHeight: 0, Prefix: authorizer.AccountId + "/secret"}, nil, nil)
+	assert.EqualError(t, errors.Cause(err), core.ErrAPISubscribeReadDenied.Error())
+
+	done := make(chan struct{})
+	close(done)
+	err = api.Subscribe(core.Subscription{Code: core.StateSubscribe, FromHeight: -1, Prefix: authorizer.AccountId + "/account"}, done, nil)
+	assert.NoError(t, err)
+}
+
+func TestAPI_GetTxStatus(t *testing.T) {
+	fc := RandomFactory()
+	conf := RandomConfig()
+	conf.Commit.NumTxInBlock = 1
+	conf.TxStatus.ExpireBlocks = 1
+	rp := repository.NewRepository(RandomDBA(), RandomCryptor(), fc, conf)
+	queue := repository.NewProposalTxQueueOnMemory(conf)
+	logger := log15.New(context.TODO())
+	qp := query.NewQueryProcessor(fc, conf)
+	qv := query.NewQueryValidator(fc, conf)
+	api := NewAPI(rp, queue, qp, qv, &p2p.MockGossip{}, logger)
+	cm := commit.NewCommitSystem(fc, RandomCryptor(), queue, rp, conf)
+
+	authorizer := NewAccountWithPri("authoirzer@com")
+	GenesisCommitFromAccounts(t, rp, []*AccountWithPri{authorizer})
+
+	committed := CreateAccountTx(t, authorizer, "target1@com")
+	// authorizer 以外の鍵で署名した Transaction は Block の生成時に落ちる
+	rejected := fc.NewTxBuilder().
+		CreateAccount(authorizer.AccountId, "target2@com", []model.PublicKey{}, 0).
+		Build()
+	other := NewAccountWithPri("other@com")
+	require.NoError(t, rejected.Sign(other.Pubkey, other.Prikey))
+	// NumTxInBlock を超えたので Block に含まれずに Expired になる
+	expired := CreateAccountTx(t, authorizer, "target3@com")
+	for _, tx := range []model.Transaction{rejected, committed, expired} {
+		require.NoError(t, api.Write(tx))
+	}
+
+	status, err := api.GetTxStatus(committed.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, core.TxStatus{Code: core.TxStatusQueued, Height: 0}, status)
+
+	_, _, err = cm.CreateBlock(0)
+	require.NoError(t, err)
+
+	status, err = api.GetTxStatus(committed.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, core.TxStatus{Code: core.TxStatusCommitted, Height: 1}, status)
+
+	status, err = api.GetTxStatus(rejected.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, core.TxStatusRejected, status.Code)
+	assert.Equal(t, int64(1), status.Height)
+	assert.Contains(t, status.Reason, core.ErrTxValidateNotSignedAuthorizer.Error())
+
+	status, err = api.GetTxStatus(expired.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, core.TxStatus{Code: core.TxStatusExpired, Height: 1}, status)
+
+	// Expired の Transaction は次の Block にも含めない
+	_, _, err = cm.CreateBlock(0)
+	require.NoError(t, err)
+	status, err = api.GetTxStatus(expired.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, core.TxStatusExpired, status.Code)
+
+	// 確定した status は Queued に戻らない
+	rp.SetTxQueued(committed.Hash())
+	status, err = api.GetTxStatus(committed.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, core.TxStatusCommitted, status.Code)
+
+	_, err = api.GetTxStatus(RandomByte())
+	assert.EqualError(t, errors.Cause(err), core.ErrAPITxStatusNotFound.Error())
+
+	t.Run("subscribe final status", func(t *testing.T) {
+		eventChan := make(chan core.SubscribeEvent, 1)
+		err := api.Subscribe(core.Subscription{Code: core.TxStatusSubscribe, TxHash: committed.Hash()}, nil, eventChan)
+		require.NoError(t, err)
+		event := <-eventChan
+		assert.Equal(t, core.TxStatusCommitted, event.TxStatus.Code)
+	})
+}
+
+func TestAPI_WriteAndWait(t *testing.T) {
+	fc := RandomFactory()
+	conf := RandomConfig()
+	rp := repository.NewRepository(RandomDBA(), RandomCryptor(), fc, conf)
+	queue := repository.NewProposalTxQueueOnMemory(conf)
+	logger := log15.New(context.TODO())
+	qp := query.NewQueryProcessor(fc, conf)
+	qv := query.NewQueryValidator(fc, conf)
+	api := NewAPI(rp, queue, qp, qv, &p2p.MockGossip{}, logger)
+	cm := commit.NewCommitSystem(fc, RandomCryptor(), queue, rp, conf)
+
+	authorizer := NewAccountWithPri("authoirzer@com")
+	GenesisCommitFromAccounts(t, rp, []*AccountWithPri{authorizer})
+
+	// writeAndCreateBlock は WriteAndWait で待っている間に Block を生成する
+	writeAndCreateBlock := func(tx model.Transaction) (core.WriteResult, error) {
+		type result struct {
+			res core.WriteResult
+			err error
+		}
+		resChan := make(chan result, 1)
+		go func() {
+			res, err := api.WriteAndWait(tx, 5*time.Second)
+			resChan <- result{res, err}
+		}()
+		for {
+			if _, ok := rp.TxStatus(tx.Hash()); ok {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		_, _, err := cm.CreateBlock(0)
+		require.NoError(t, err)
+		ret := <-resChan
+		return ret.res, ret.err
+	}
+
+	t.Run("committed", func(t *testing.T) {
+		tx := CreateAccountTx(t, authorizer, "target1@com")
+		res, err := writeAndCreateBlock(tx)
+		require.NoError(t, err)
+		top, ok := rp.Top()
+		require.True(t, ok)
+		assert.Equal(t, top.Hash(), res.BlockHash)
+		assert.Equal(t, top.GetPayload().GetHeight(), res.Height)
+		assert.Equal(t, tx.Hash(), res.Receipt.GetTxHash())
+	})
+	t.Run("rejected", func(t *testing.T) {
+		tx := fc.NewTxBuilder().
+			CreateAccount(authorizer.AccountId, "target2@com", []model.PublicKey{}, 0).
+			Build()
+		other := NewAccountWithPri("other@com")
+		require.NoError(t, tx.Sign(other.Pubkey, other.Prikey))
+		_, err := writeAndCreateBlock(tx)
+		assert.EqualError(t, errors.Cause(err), core.ErrAPIWriteRejected.Error())
+	})
+	t.Run("timeout", func(t *testing.T) {
+		tx := CreateAccountTx(t, authorizer, "target3@com")
+		_, err := api.WriteAndWait(tx, 10*time.Millisecond)
+		assert.EqualError(t, errors.Cause(err), core.ErrAPIWriteTimeout.Error())
+	})
+}
+
+func TestAPI_ReadBatch(t *testing.T) {
+	fc := RandomFactory()
+	conf := RandomConfig()
+	rp := repository.NewRepository(RandomDBA(), RandomCryptor(), fc, conf)
+	queue := repository.NewProposalTxQueueOnMemory(conf)
+	logger := log15.New(context.TODO())
+	qp := query.NewQueryProcessor(fc, conf)
+	qv := query.NewQueryValidator(fc, conf)
+	api := NewAPI(rp, queue, qp, qv, &p2p.MockGossip{}, logger)
+
+	_, err := api.ReadBatch(nil)
+	assert.EqualError(t, errors.Cause(err), core.ErrAPIQueryNotFound.Error())
+
+	authorizer := NewAccountWithPri("authoirzer@com")
+	GenesisCommitFromAccounts(t, rp, []*AccountWithPri{authorizer})
+	top, ok := rp.Top()
+	require.True(t, ok)
+
+	newQuery := func(fromId string) model.Query {
+		return fc.NewQueryBuilder().
+			AuthorizerId(authorizer.AccountId).
+			FromId(fromId).
+			RequestCode(model.AccountObjectCode).
+			Build()
+	}
+	found := newQuery(authorizer.AccountId + "/account")
+	require.NoError(t, found.Sign(authorizer.Pubkey, authorizer.Prikey))
+	notFound := newQuery("nobody@com/account")
+	require.NoError(t, notFound.Sign(authorizer.Pubkey, authorizer.Prikey))
+	unsigned := newQuery(authorizer.AccountId + "/account")
+	// 別の Block の WSV を読む Query
+	at := fc.NewQueryBuilder().
+		AuthorizerId(authorizer.AccountId).
+		FromId(authorizer.AccountId + "/account").
+		RequestCode(model.AccountObjectCode).
+		AtHeight(top.GetPayload().GetHeight()).
+		Build()
+	require.NoError(t, at.Sign(authorizer.Pubkey, authorizer.Prikey))
+	cursor := fc.NewQueryBuilder().
+		AuthorizerId(authorizer.AccountId).
+		FromId("com/account").
+		RequestCode(model.ListObjectCode).
+		Limit(1).
+		Cursor(nil, model.ASC, RandomByte()).
+		Build()
+	require.NoError(t, cursor.Sign(authorizer.Pubkey, authorizer.Prikey))
+
+	res, err := api.ReadBatch([]model.Query{found, notFound, unsigned, at, cursor})
+	require.NoError(t, err)
+	assert.NoError(t, res.Verify())
+	assert.Equal(t, top.Hash(), res.GetBlockHash())
+	assert.Equal(t, top.GetPayload().GetHeight(), res.GetHeight())
+
+	results := res.GetResults()
+	require.Len(t, results, 5)
+	assert.Empty(t, results[0].GetError())
+	assert.Equal(t, authorizer.AccountId, results[0].GetResponse().GetObject().GetAccount().GetAccountId())
+	// 各結果は署名せず、BatchQueryResponse の署名でまとめて検証する
+	assert.Empty(t, results[0].GetResponse().GetSignature().GetSignature())
+	assert.Nil(t, results[1].GetResponse())
+	assert.Contains(t, results[1].GetError(), core.ErrAPIQueryNotFound.Error())
+	assert.Nil(t, results[2].GetResponse())
+	assert.Contains(t, results[2].GetError(), core.ErrAPIQueryVerifyError.Error())
+	for _, r := range results[3:] {
+		assert.Nil(t, r.GetResponse())
+		assert.Contains(t, r.GetError(), core.ErrAPIQueryValidateError.Error())
+	}
+
+	_, err = api.ReadBatch(make([]model.Query, MaxBatchQueries+1))
+	assert.EqualError(t, errors.Cause(err), core.ErrAPIQueryBatchTooLarge.Error())
+}
+
+func TestAPI_GetProslMetrics(t *testing.T) {
+	fc := RandomFactory()
+	conf := RandomConfig()
+	rp := repository.NewRepository(RandomDBA(), RandomCryptor(), fc, conf)
+	queue := repository.NewProposalTxQueueOnMemory(conf)
+	logger := log15.New(context.TODO())
+	qp := query.NewQueryProcessor(fc, conf)
+	qv := query.NewQueryValidator(fc, conf)
+	api := NewAPI(rp, queue, qp, qv, &p2p.MockGossip{}, logger)
+	cm := commit.NewCommitSystem(fc, RandomCryptor(), queue, rp, conf)
+
+	authorizer := NewAccountWithPri("authoirzer@com")
+	GenesisCommitFromAccounts(t, rp, []*AccountWithPri{authorizer})
+	_, _, err := cm.CreateBlock(0)
+	require.NoError(t, err)
+
+	metrics, err := api.GetProslMetrics(conf.Prosl.Consensus.Id)
+	require.NoError(t, err)
+	assert.Equal(t, rp.ProslMetrics(conf.Prosl.Consensus.Id), metrics)
+
+	_, err = api.GetProslMetrics("")
+	assert.EqualError(t, errors.Cause(err), core.ErrAPIProslMetricsInvalidArgument.Error())
+}
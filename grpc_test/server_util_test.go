@@ -53,8 +53,8 @@ func SetUpTestServer(t *testing.T, conf *config.Config, s *grpc.Server) {
 
 	pr := prosl.NewProsl(fc, cryptor, conf)
 
-	cmdExecutor.SetField(fc, pr)
-	cmdValidator.SetField(fc, pr)
+	cmdExecutor.SetField(fc, cryptor)
+	cmdValidator.SetField(fc, cryptor)
 
 	qp := query.NewQueryProcessor(fc, conf)
 	qv := query.NewQueryValidator(fc, conf)
@@ -70,8 +70,8 @@ func main() {
 	pr := prosl.NewProsl(fc, cryptor, conf)
 
 	// cmd executor and validator set field.
-	cmdExecutor.SetField(fc, pr)
-	cmdValidator.SetField(fc, pr)
+	cmdExecutor.SetField(fc, cryptor)
+	cmdValidator.SetField(fc, cryptor)
 
 	qp := query.NewQueryProcessor(fc, conf)
 	qv := query.NewQueryValidator(fc, conf)
@@ -1,6 +1,7 @@
 package prosl
 
 import (
+	"github.com/proskenion/proskenion/core"
 	"github.com/proskenion/proskenion/core/model"
 	"github.com/proskenion/proskenion/proto"
 )
@@ -17,6 +18,19 @@ func ReturnCmdProslStateValue(state *ProslStateValue, cmd model.Command) *ProslS
 	return ReturnProslStateValue(state, state.Fc.NewObjectBuilder().Command(cmd))
 }
 
+// deprecatedAuthorizerParam は authorizer_id の過去の綴り誤り。既存の prosl との互換性のために受け付ける
+const deprecatedAuthorizerParam = "authoirzer"
+
+// CheckUnknownParamProslStateValue は command に存在しない引数名が指定された場合のエラーを返す。
+// 有効化前の Chain では既に Chain にある prosl の実行結果を変えない様に、不明な引数名を無視する。
+// wsv の無い実行 (proslv 等) では常にエラーを返す
+func CheckUnknownParamProslStateValue(state *ProslStateValue, cmdName string, key string) *ProslStateValue {
+	if state.Wsv != nil && !core.Activated(state.Wsv, state.Fc.NewEmptyStorage(), core.ProslParamsFeature) {
+		return state
+	}
+	return ReturnErrorProslStateValue(state, proskenion.ErrCode_Sentence, "unknown parameter %s of command %s", key, cmdName)
+}
+
 func ExecuteProslCreateAccount(params map[string]*proskenion.ValueOperator, state *ProslStateValue) *ProslStateValue {
 	builder := state.Fc.NewTxBuilder()
 	var authorizerId string
@@ -25,7 +39,7 @@ func ExecuteProslCreateAccount(params map[string]*proskenion.ValueOperator, stat
 	var quorum int32
 	for key, value := range params {
 		switch key {
-		case "authorizer_id", "authorizer":
+		case "authorizer_id", "authorizer", deprecatedAuthorizerParam:
 			state = ExecuteProslValueOperator(value, state)
 			if state.Err != nil {
 				return state
@@ -49,6 +63,10 @@ func ExecuteProslCreateAccount(params map[string]*proskenion.ValueOperator, stat
 				return state
 			}
 			quorum = state.ReturnObject.GetI32()
+		default:
+			if state = CheckUnknownParamProslStateValue(state, "create_account", key); state.Err != nil {
+				return state
+			}
 		}
 	}
 	return ReturnCmdProslStateValue(state,
@@ -61,7 +79,7 @@ func ExecuteProslTransferBalance(params map[string]*proskenion.ValueOperator, st
 	var balance int64
 	for key, value := range params {
 		switch key {
-		case "authorizer_id", "authorizer":
+		case "authorizer_id", "authorizer", deprecatedAuthorizerParam:
 			state = ExecuteProslValueOperator(value, state)
 			if state.Err != nil {
 				return state
@@ -73,19 +91,22 @@ func ExecuteProslTransferBalance(params map[string]*proskenion.ValueOperator, st
 				return state
 			}
 			targetId = state.ReturnObject.GetAddress()
-
 		case "dest_account_id", "dest", "dest_account":
 			state = ExecuteProslValueOperator(value, state)
 			if state.Err != nil {
 				return state
 			}
-			destAccountId = state.ReturnObject.GetStr()
+			destAccountId = state.ReturnObject.GetAddress()
 		case "balance":
 			state = ExecuteProslValueOperator(value, state)
 			if state.Err != nil {
 				return state
 			}
 			balance = state.ReturnObject.GetI64()
+		default:
+			if state = CheckUnknownParamProslStateValue(state, "transfer_balance", key); state.Err != nil {
+				return state
+			}
 		}
 	}
 	return ReturnCmdProslStateValue(state,
@@ -98,7 +119,7 @@ func ExecuteProslAddBalance(params map[string]*proskenion.ValueOperator, state *
 	var balance int64
 	for key, value := range params {
 		switch key {
-		case "authorizer_id", "authoirzer":
+		case "authorizer_id", "authorizer", deprecatedAuthorizerParam:
 			state = ExecuteProslValueOperator(value, state)
 			if state.Err != nil {
 				return state
@@ -116,6 +137,10 @@ func ExecuteProslAddBalance(params map[string]*proskenion.ValueOperator, state *
 				return state
 			}
 			balance = state.ReturnObject.GetI64()
+		default:
+			if state = CheckUnknownParamProslStateValue(state, "add_balance", key); state.Err != nil {
+				return state
+			}
 		}
 	}
 	return ReturnCmdProslStateValue(state,
@@ -128,7 +153,7 @@ func ExecuteProslAddPublicKeys(params map[string]*proskenion.ValueOperator, stat
 	var publicKeys []model.PublicKey
 	for key, value := range params {
 		switch key {
-		case "authorizer_id", "authoirzer":
+		case "authorizer_id", "authorizer", deprecatedAuthorizerParam:
 			state = ExecuteProslValueOperator(value, state)
 			if state.Err != nil {
 				return state
@@ -146,6 +171,10 @@ func ExecuteProslAddPublicKeys(params map[string]*proskenion.ValueOperator, stat
 				return state
 			}
 			publicKeys = ObjectListToPublicKeys(state.ReturnObject.GetList())
+		default:
+			if state = CheckUnknownParamProslStateValue(state, "add_public_keys", key); state.Err != nil {
+				return state
+			}
 		}
 	}
 	return ReturnCmdProslStateValue(state,
@@ -158,7 +187,7 @@ func ExecuteProslRemovePublicKeys(params map[string]*proskenion.ValueOperator, s
 	var publicKeys []model.PublicKey
 	for key, value := range params {
 		switch key {
-		case "authorizer_id", "authoirzer":
+		case "authorizer_id", "authorizer", deprecatedAuthorizerParam:
 			state = ExecuteProslValueOperator(value, state)
 			if state.Err != nil {
 				return state
@@ -170,13 +199,16 @@ func ExecuteProslRemovePublicKeys(params map[string]*proskenion.ValueOperator, s
 				return state
 			}
 			targetId = state.ReturnObject.GetAddress()
-
 		case "public_keys", "keys":
 			state = ExecuteProslValueOperator(value, state)
 			if state.Err != nil {
 				return state
 			}
 			publicKeys = ObjectListToPublicKeys(state.ReturnObject.GetList())
+		default:
+			if state = CheckUnknownParamProslStateValue(state, "remove_public_keys", key); state.Err != nil {
+				return state
+			}
 		}
 	}
 	return ReturnCmdProslStateValue(state,
@@ -189,7 +221,7 @@ func ExecuteProslSetQuorum(params map[string]*proskenion.ValueOperator, state *P
 	var quorum int32
 	for key, value := range params {
 		switch key {
-		case "authorizer_id", "authoirzer":
+		case "authorizer_id", "authorizer", deprecatedAuthorizerParam:
 			state = ExecuteProslValueOperator(value, state)
 			if state.Err != nil {
 				return state
@@ -201,13 +233,16 @@ func ExecuteProslSetQuorum(params map[string]*proskenion.ValueOperator, state *P
 				return state
 			}
 			targetId = state.ReturnObject.GetAddress()
-
 		case "quorum":
 			state = ExecuteProslValueOperator(value, state)
 			if state.Err != nil {
 				return state
 			}
 			quorum = state.ReturnObject.GetI32()
+		default:
+			if state = CheckUnknownParamProslStateValue(state, "set_quorum", key); state.Err != nil {
+				return state
+			}
 		}
 	}
 	return ReturnCmdProslStateValue(state,
@@ -220,7 +255,7 @@ func ExecuteProslDefineStorage(params map[string]*proskenion.ValueOperator, stat
 	var storage model.Storage
 	for key, value := range params {
 		switch key {
-		case "authorizer_id", "authoirzer":
+		case "authorizer_id", "authorizer", deprecatedAuthorizerParam:
 			state = ExecuteProslValueOperator(value, state)
 			if state.Err != nil {
 				return state
@@ -232,13 +267,16 @@ func ExecuteProslDefineStorage(params map[string]*proskenion.ValueOperator, stat
 				return state
 			}
 			targetId = state.ReturnObject.GetAddress()
-
 		case "storage":
 			state = ExecuteProslValueOperator(value, state)
 			if state.Err != nil {
 				return state
 			}
 			storage = state.ReturnObject.GetStorage()
+		default:
+			if state = CheckUnknownParamProslStateValue(state, "define_storage", key); state.Err != nil {
+				return state
+			}
 		}
 	}
 	return ReturnCmdProslStateValue(state,
@@ -250,7 +288,7 @@ func ExecuteProslCreateStorage(params map[string]*proskenion.ValueOperator, stat
 	var authorizerId, targetId string
 	for key, value := range params {
 		switch key {
-		case "authorizer_id", "authoirzer":
+		case "authorizer_id", "authorizer", deprecatedAuthorizerParam:
 			state = ExecuteProslValueOperator(value, state)
 			if state.Err != nil {
 				return state
@@ -262,6 +300,10 @@ func ExecuteProslCreateStorage(params map[string]*proskenion.ValueOperator, stat
 				return state
 			}
 			targetId = state.ReturnObject.GetAddress()
+		default:
+			if state = CheckUnknownParamProslStateValue(state, "create_storage", key); state.Err != nil {
+				return state
+			}
 		}
 	}
 	return ReturnCmdProslStateValue(state,
@@ -274,7 +316,7 @@ func ExecuteProslUpdateObject(params map[string]*proskenion.ValueOperator, state
 	var object model.Object
 	for key, value := range params {
 		switch key {
-		case "authorizer_id", "authoirzer":
+		case "authorizer_id", "authorizer", deprecatedAuthorizerParam:
 			state = ExecuteProslValueOperator(value, state)
 			if state.Err != nil {
 				return state
@@ -298,6 +340,10 @@ func ExecuteProslUpdateObject(params map[string]*proskenion.ValueOperator, state
 				return state
 			}
 			object = state.ReturnObject
+		default:
+			if state = CheckUnknownParamProslStateValue(state, "update_object", key); state.Err != nil {
+				return state
+			}
 		}
 	}
 	return ReturnCmdProslStateValue(state,
@@ -306,11 +352,11 @@ func ExecuteProslUpdateObject(params map[string]*proskenion.ValueOperator, state
 
 func ExecuteProslAddObject(params map[string]*proskenion.ValueOperator, state *ProslStateValue) *ProslStateValue {
 	builder := state.Fc.NewTxBuilder()
-	var authorizerId, targetId, key string
+	var authorizerId, targetId, k string
 	var object model.Object
 	for key, value := range params {
 		switch key {
-		case "authorizer_id", "authoirzer":
+		case "authorizer_id", "authorizer", deprecatedAuthorizerParam:
 			state = ExecuteProslValueOperator(value, state)
 			if state.Err != nil {
 				return state
@@ -322,69 +368,72 @@ func ExecuteProslAddObject(params map[string]*proskenion.ValueOperator, state *P
 				return state
 			}
 			targetId = state.ReturnObject.GetAddress()
-
 		case "key":
 			state = ExecuteProslValueOperator(value, state)
 			if state.Err != nil {
 				return state
 			}
-			key = state.ReturnObject.GetStr()
-
+			k = state.ReturnObject.GetStr()
 		case "object":
 			state = ExecuteProslValueOperator(value, state)
 			if state.Err != nil {
 				return state
 			}
 			object = state.ReturnObject
+		default:
+			if state = CheckUnknownParamProslStateValue(state, "add_object", key); state.Err != nil {
+				return state
+			}
 		}
 	}
 	return ReturnCmdProslStateValue(state,
-		builder.AddObject(authorizerId, targetId, key, object).Build().GetPayload().GetCommands()[0])
+		builder.AddObject(authorizerId, targetId, k, object).Build().GetPayload().GetCommands()[0])
 }
 
 func ExecuteProslTransferObject(params map[string]*proskenion.ValueOperator, state *ProslStateValue) *ProslStateValue {
 	builder := state.Fc.NewTxBuilder()
-	var authorizerId, targetId, key, destAccountId string
+	var authorizerId, targetId, k, destAccountId string
 	var object model.Object
 	for key, value := range params {
 		switch key {
-		case "authorizer_id", "authoirzer":
+		case "authorizer_id", "authorizer", deprecatedAuthorizerParam:
 			state = ExecuteProslValueOperator(value, state)
 			if state.Err != nil {
 				return state
 			}
 			authorizerId = state.ReturnObject.GetAddress()
-		case "src_wallet_id", "target_id", "target":
+		case "wallet_id", "src_wallet_id", "target_id", "target":
 			state = ExecuteProslValueOperator(value, state)
 			if state.Err != nil {
 				return state
 			}
 			targetId = state.ReturnObject.GetAddress()
-
 		case "key":
 			state = ExecuteProslValueOperator(value, state)
 			if state.Err != nil {
 				return state
 			}
-			key = state.ReturnObject.GetStr()
-
-		case "dest_wallet_id", "dest", "dest_wallet":
+			k = state.ReturnObject.GetStr()
+		case "dest_wallet_id", "dest_account_id", "dest", "dest_wallet":
 			state = ExecuteProslValueOperator(value, state)
 			if state.Err != nil {
 				return state
 			}
-			destAccountId = state.ReturnObject.GetStr()
-
+			destAccountId = state.ReturnObject.GetAddress()
 		case "object":
 			state = ExecuteProslValueOperator(value, state)
 			if state.Err != nil {
 				return state
 			}
 			object = state.ReturnObject
+		default:
+			if state = CheckUnknownParamProslStateValue(state, "transfer_object", key); state.Err != nil {
+				return state
+			}
 		}
 	}
 	return ReturnCmdProslStateValue(state,
-		builder.TransferObject(authorizerId, targetId, key, destAccountId, object).Build().GetPayload().GetCommands()[0])
+		builder.TransferObject(authorizerId, targetId, destAccountId, k, object).Build().GetPayload().GetCommands()[0])
 }
 
 func ExecuteProslAddPeer(params map[string]*proskenion.ValueOperator, state *ProslStateValue) *ProslStateValue {
@@ -393,33 +442,34 @@ func ExecuteProslAddPeer(params map[string]*proskenion.ValueOperator, state *Pro
 	var publicKey model.PublicKey
 	for key, value := range params {
 		switch key {
-		case "authorizer_id", "authoirzer":
+		case "authorizer_id", "authorizer", deprecatedAuthorizerParam:
 			state = ExecuteProslValueOperator(value, state)
 			if state.Err != nil {
 				return state
 			}
 			authorizerId = state.ReturnObject.GetAddress()
-
 		case "peer_id", "target_id", "target":
 			state = ExecuteProslValueOperator(value, state)
 			if state.Err != nil {
 				return state
 			}
 			targetId = state.ReturnObject.GetAddress()
-
 		case "address", "ip":
 			state = ExecuteProslValueOperator(value, state)
 			if state.Err != nil {
 				return state
 			}
 			address = state.ReturnObject.GetStr()
-
 		case "public_key", "key":
 			state = ExecuteProslValueOperator(value, state)
 			if state.Err != nil {
 				return state
 			}
 			publicKey = state.ReturnObject.GetData()
+		default:
+			if state = CheckUnknownParamProslStateValue(state, "add_peer", key); state.Err != nil {
+				return state
+			}
 		}
 	}
 	return ReturnCmdProslStateValue(state,
@@ -431,7 +481,7 @@ func ExecuteProslConsign(params map[string]*proskenion.ValueOperator, state *Pro
 	var authorizerId, targetId, peerId string
 	for key, value := range params {
 		switch key {
-		case "authorizer_id", "authoirzer":
+		case "authorizer_id", "authorizer", deprecatedAuthorizerParam:
 			state = ExecuteProslValueOperator(value, state)
 			if state.Err != nil {
 				return state
@@ -443,13 +493,16 @@ func ExecuteProslConsign(params map[string]*proskenion.ValueOperator, state *Pro
 				return state
 			}
 			targetId = state.ReturnObject.GetAddress()
-
 		case "peer_id", "peer":
 			state = ExecuteProslValueOperator(value, state)
 			if state.Err != nil {
 				return state
 			}
 			peerId = state.ReturnObject.GetAddress()
+		default:
+			if state = CheckUnknownParamProslStateValue(state, "consign", key); state.Err != nil {
+				return state
+			}
 		}
 	}
 	return ReturnCmdProslStateValue(state,
@@ -461,19 +514,22 @@ func ExecuteProslActivatePeer(params map[string]*proskenion.ValueOperator, state
 	var authorizerId, targetId string
 	for key, value := range params {
 		switch key {
-		case "authorizer_id", "authoirzer":
+		case "authorizer_id", "authorizer", deprecatedAuthorizerParam:
 			state = ExecuteProslValueOperator(value, state)
 			if state.Err != nil {
 				return state
 			}
 			authorizerId = state.ReturnObject.GetAddress()
-
 		case "peer_id", "target_id", "target":
 			state = ExecuteProslValueOperator(value, state)
 			if state.Err != nil {
 				return state
 			}
 			targetId = state.ReturnObject.GetAddress()
+		default:
+			if state = CheckUnknownParamProslStateValue(state, "activate_peer", key); state.Err != nil {
+				return state
+			}
 		}
 	}
 	return ReturnCmdProslStateValue(state,
@@ -486,14 +542,13 @@ func ExecuteProslForceUpdateStorage(params map[string]*proskenion.ValueOperator,
 	var storage model.Storage
 	for key, value := range params {
 		switch key {
-		case "authorizer_id", "authoirzer":
+		case "authorizer_id", "authorizer", deprecatedAuthorizerParam:
 			state = ExecuteProslValueOperator(value, state)
 			if state.Err != nil {
 				return state
 			}
 			authorizerId = state.ReturnObject.GetAddress()
-
-		case "peer_id", "target_id", "target":
+		case "wallet_id", "storage_id", "target_id", "target":
 			state = ExecuteProslValueOperator(value, state)
 			if state.Err != nil {
 				return state
@@ -505,8 +560,253 @@ func ExecuteProslForceUpdateStorage(params map[string]*proskenion.ValueOperator,
 				return state
 			}
 			storage = state.ReturnObject.GetStorage()
+		default:
+			if state = CheckUnknownParamProslStateValue(state, "force_update_storage", key); state.Err != nil {
+				return state
+			}
 		}
 	}
 	return ReturnCmdProslStateValue(state,
 		builder.ForceUpdateStorage(authorizerId, targetId, storage).Build().GetPayload().GetCommands()[0])
 }
+
+func ExecuteProslSuspendPeer(params map[string]*proskenion.ValueOperator, state *ProslStateValue) *ProslStateValue {
+	builder := state.Fc.NewTxBuilder()
+	var authorizerId, targetId string
+	for key, value := range params {
+		switch key {
+		case "authorizer_id", "authorizer", deprecatedAuthorizerParam:
+			state = ExecuteProslValueOperator(value, state)
+			if state.Err != nil {
+				return state
+			}
+			authorizerId = state.ReturnObject.GetAddress()
+		case "peer_id", "target_id", "target":
+			state = ExecuteProslValueOperator(value, state)
+			if state.Err != nil {
+				return state
+			}
+			targetId = state.ReturnObject.GetAddress()
+		default:
+			if state = CheckUnknownParamProslStateValue(state, "suspend_peer", key); state.Err != nil {
+				return state
+			}
+		}
+	}
+	return ReturnCmdProslStateValue(state,
+		builder.SuspendPeer(authorizerId, targetId).Build().GetPayload().GetCommands()[0])
+}
+
+func ExecuteProslBanPeer(params map[string]*proskenion.ValueOperator, state *ProslStateValue) *ProslStateValue {
+	builder := state.Fc.NewTxBuilder()
+	var authorizerId, targetId string
+	for key, value := range params {
+		switch key {
+		case "authorizer_id", "authorizer", deprecatedAuthorizerParam:
+			state = ExecuteProslValueOperator(value, state)
+			if state.Err != nil {
+				return state
+			}
+			authorizerId = state.ReturnObject.GetAddress()
+		case "peer_id", "target_id", "target":
+			state = ExecuteProslValueOperator(value, state)
+			if state.Err != nil {
+				return state
+			}
+			targetId = state.ReturnObject.GetAddress()
+		default:
+			if state = CheckUnknownParamProslStateValue(state, "ban_peer", key); state.Err != nil {
+				return state
+			}
+		}
+	}
+	return ReturnCmdProslStateValue(state,
+		builder.BanPeer(authorizerId, targetId).Build().GetPayload().GetCommands()[0])
+}
+
+func ExecuteProslCheckAndCommitProsl(params map[string]*proskenion.ValueOperator, state *ProslStateValue) *ProslStateValue {
+	builder := state.Fc.NewTxBuilder()
+	var authorizerId, targetId string
+	var variables map[string]model.Object
+	for key, value := range params {
+		switch key {
+		case "authorizer_id", "authorizer", deprecatedAuthorizerParam:
+			state = ExecuteProslValueOperator(value, state)
+			if state.Err != nil {
+				return state
+			}
+			authorizerId = state.ReturnObject.GetAddress()
+		case "prosl_id", "wallet_id", "target_id", "target":
+			state = ExecuteProslValueOperator(value, state)
+			if state.Err != nil {
+				return state
+			}
+			targetId = state.ReturnObject.GetAddress()
+		case "variables", "params":
+			state = ExecuteProslValueOperator(value, state)
+			if state.Err != nil {
+				return state
+			}
+			variables = state.ReturnObject.GetDict()
+		default:
+			if state = CheckUnknownParamProslStateValue(state, "check_and_commit_prosl", key); state.Err != nil {
+				return state
+			}
+		}
+	}
+	return ReturnCmdProslStateValue(state,
+		builder.CheckAndCommitProsl(authorizerId, targetId, variables).Build().GetPayload().GetCommands()[0])
+}
+
+func ExecuteProslRevertProsl(params map[string]*proskenion.ValueOperator, state *ProslStateValue) *ProslStateValue {
+	builder := state.Fc.NewTxBuilder()
+	var authorizerId, targetId string
+	var version int64
+	var variables map[string]model.Object
+	for key, value := range params {
+		switch key {
+		case "authorizer_id", "authorizer", deprecatedAuthorizerParam:
+			state = ExecuteProslValueOperator(value, state)
+			if state.Err != nil {
+				return state
+			}
+			authorizerId = state.ReturnObject.GetAddress()
+		case "prosl_id", "wallet_id", "target_id", "target":
+			state = ExecuteProslValueOperator(value, state)
+			if state.Err != nil {
+				return state
+			}
+			targetId = state.ReturnObject.GetAddress()
+		case "version":
+			state = ExecuteProslValueOperator(value, state)
+			if state.Err != nil {
+				return state
+			}
+			version = state.ReturnObject.GetI64()
+		case "variables", "params":
+			state = ExecuteProslValueOperator(value, state)
+			if state.Err != nil {
+				return state
+			}
+			variables = state.ReturnObject.GetDict()
+		default:
+			if state = CheckUnknownParamProslStateValue(state, "revert_prosl", key); state.Err != nil {
+				return state
+			}
+		}
+	}
+	return ReturnCmdProslStateValue(state,
+		builder.RevertProsl(authorizerId, targetId, version, variables).Build().GetPayload().GetCommands()[0])
+}
+
+func ExecuteProslGrantPermission(params map[string]*proskenion.ValueOperator, state *ProslStateValue) *ProslStateValue {
+	builder := state.Fc.NewTxBuilder()
+	var authorizerId, targetId, permission, domain string
+	for key, value := range params {
+		switch key {
+		case "authorizer_id", "authorizer", deprecatedAuthorizerParam:
+			state = ExecuteProslValueOperator(value, state)
+			if state.Err != nil {
+				return state
+			}
+			authorizerId = state.ReturnObject.GetAddress()
+		case "account_id", "target_id", "target":
+			state = ExecuteProslValueOperator(value, state)
+			if state.Err != nil {
+				return state
+			}
+			targetId = state.ReturnObject.GetAddress()
+		case "permission", "role":
+			state = ExecuteProslValueOperator(value, state)
+			if state.Err != nil {
+				return state
+			}
+			permission = state.ReturnObject.GetStr()
+		case "domain":
+			state = ExecuteProslValueOperator(value, state)
+			if state.Err != nil {
+				return state
+			}
+			domain = state.ReturnObject.GetStr()
+		default:
+			if state = CheckUnknownParamProslStateValue(state, "grant_permission", key); state.Err != nil {
+				return state
+			}
+		}
+	}
+	return ReturnCmdProslStateValue(state,
+		builder.GrantPermission(authorizerId, targetId, permission, domain).Build().GetPayload().GetCommands()[0])
+}
+
+func ExecuteProslRevokePermission(params map[string]*proskenion.ValueOperator, state *ProslStateValue) *ProslStateValue {
+	builder := state.Fc.NewTxBuilder()
+	var authorizerId, targetId, permission, domain string
+	for key, value := range params {
+		switch key {
+		case "authorizer_id", "authorizer", deprecatedAuthorizerParam:
+			state = ExecuteProslValueOperator(value, state)
+			if state.Err != nil {
+				return state
+			}
+			authorizerId = state.ReturnObject.GetAddress()
+		case "account_id", "target_id", "target":
+			state = ExecuteProslValueOperator(value, state)
+			if state.Err != nil {
+				return state
+			}
+			targetId = state.ReturnObject.GetAddress()
+		case "permission", "role":
+			state = ExecuteProslValueOperator(value, state)
+			if state.Err != nil {
+				return state
+			}
+			permission = state.ReturnObject.GetStr()
+		case "domain":
+			state = ExecuteProslValueOperator(value, state)
+			if state.Err != nil {
+				return state
+			}
+			domain = state.ReturnObject.GetStr()
+		default:
+			if state = CheckUnknownParamProslStateValue(state, "revoke_permission", key); state.Err != nil {
+				return state
+			}
+		}
+	}
+	return ReturnCmdProslStateValue(state,
+		builder.RevokePermission(authorizerId, targetId, permission, domain).Build().GetPayload().GetCommands()[0])
+}
+
+func ExecuteProslInvokeProsl(params map[string]*proskenion.ValueOperator, state *ProslStateValue) *ProslStateValue {
+	builder := state.Fc.NewTxBuilder()
+	var authorizerId, targetId string
+	var variables map[string]model.Object
+	for key, value := range params {
+		switch key {
+		case "authorizer_id", "authorizer", deprecatedAuthorizerParam:
+			state = ExecuteProslValueOperator(value, state)
+			if state.Err != nil {
+				return state
+			}
+			authorizerId = state.ReturnObject.GetAddress()
+		case "prosl_id", "wallet_id", "target_id", "target":
+			state = ExecuteProslValueOperator(value, state)
+			if state.Err != nil {
+				return state
+			}
+			targetId = state.ReturnObject.GetAddress()
+		case "variables", "params":
+			state = ExecuteProslValueOperator(value, state)
+			if state.Err != nil {
+				return state
+			}
+			variables = state.ReturnObject.GetDict()
+		default:
+			if state = CheckUnknownParamProslStateValue(state, "invoke_prosl", key); state.Err != nil {
+				return state
+			}
+		}
+	}
+	return ReturnCmdProslStateValue(state,
+		builder.InvokeProsl(authorizerId, targetId, variables).Build().GetPayload().GetCommands()[0])
+}
@@ -0,0 +1,283 @@
+package prosl_test
+
+import (
+	"fmt"
+	"github.com/proskenion/proskenion/core"
+	"github.com/proskenion/proskenion/core/model"
+	. "github.com/proskenion/proskenion/prosl"
+	"github.com/proskenion/proskenion/proto"
+	. "github.com/proskenion/proskenion/test_utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func executeCommandYaml(t *testing.T, cmd string) *ProslStateValue {
+	pr, err := ConvertYamlToProtobuf([]byte(fmt.Sprintf("- return:\n%s", cmd)))
+	require.NoError(t, err)
+	return ExecuteProsl(pr, InitProslStateValue(RandomFactory(), nil, nil, RandomCryptor(), RandomConfig()))
+}
+
+func TestExecuteProslCmdOperator(t *testing.T) {
+	fc := RandomFactory()
+	storage := fc.NewStorageBuilder().Int64("a", 1).Build()
+	object := fc.NewObjectBuilder().Int64(1)
+	for _, c := range []struct {
+		name string
+		yaml string
+		exp  model.TxBuilder
+	}{
+		{
+			"create_account",
+			`    create_account:
+      authorizer_id: root@com
+      account_id: target@com
+      public_keys:
+        list:
+          - 0x0102
+      quorum: 1`,
+			fc.NewTxBuilder().CreateAccount("root@com", "target@com", []model.PublicKey{{1, 2}}, 1),
+		},
+		{
+			"add_balance",
+			`    add_balance:
+      authorizer: root@com
+      account_id: target@com
+      balance: 10ll`,
+			fc.NewTxBuilder().AddBalance("root@com", "target@com", 10),
+		},
+		{
+			"transfer_balance",
+			`    transfer_balance:
+      authorizer_id: root@com
+      account_id: target@com
+      dest_account_id: dest@com
+      balance: 10ll`,
+			fc.NewTxBuilder().TransferBalance("root@com", "target@com", "dest@com", 10),
+		},
+		{
+			"add_public_keys",
+			`    add_public_keys:
+      authorizer_id: root@com
+      account_id: target@com
+      public_keys:
+        list:
+          - 0x0102`,
+			fc.NewTxBuilder().AddPublicKeys("root@com", "target@com", []model.PublicKey{{1, 2}}),
+		},
+		{
+			"remove_public_keys",
+			`    remove_public_keys:
+      authorizer_id: root@com
+      account_id: target@com
+      keys:
+        list:
+          - 0x0102`,
+			fc.NewTxBuilder().RemovePublicKeys("root@com", "target@com", []model.PublicKey{{1, 2}}),
+		},
+		{
+			"set_quorum",
+			`    set_quorum:
+      authorizer_id: root@com
+      account_id: target@com
+      quorum: 2`,
+			fc.NewTxBuilder().SetQuorum("root@com", "target@com", 2),
+		},
+		{
+			"define_storage",
+			`    define_storage:
+      authorizer_id: root@com
+      storage_id: /st
+      storage:
+        storage:
+          a: 1ll`,
+			fc.NewTxBuilder().DefineStorage("root@com", "/st", storage),
+		},
+		{
+			"create_storage",
+			`    create_storage:
+      authorizer_id: root@com
+      wallet_id: target@com/st`,
+			fc.NewTxBuilder().CreateStorage("root@com", "target@com/st"),
+		},
+		{
+			"update_object",
+			`    update_object:
+      authorizer_id: root@com
+      wallet_id: target@com/st
+      key: a
+      object: 1ll`,
+			fc.NewTxBuilder().UpdateObject("root@com", "target@com/st", "a", object),
+		},
+		{
+			"add_object",
+			`    add_object:
+      authorizer_id: root@com
+      wallet_id: target@com/st
+      key: a
+      object: 1ll`,
+			fc.NewTxBuilder().AddObject("root@com", "target@com/st", "a", object),
+		},
+		{
+			"transfer_object",
+			`    transfer_object:
+      authorizer_id: root@com
+      wallet_id: target@com/st
+      dest_wallet_id: dest@com/st
+      key: a
+      object: 1ll`,
+			fc.NewTxBuilder().TransferObject("root@com", "target@com/st", "dest@com/st", "a", object),
+		},
+		{
+			"add_peer",
+			`    add_peer:
+      authorizer_id: root@com
+      peer_id: target@peer
+      address: 127.0.0.1:50055
+      public_key: 0x0102`,
+			fc.NewTxBuilder().AddPeer("root@com", "target@peer", "127.0.0.1:50055", model.PublicKey{1, 2}),
+		},
+		{
+			"activate_peer",
+			`    activate_peer:
+      authorizer_id: root@com
+      peer_id: target@peer`,
+			fc.NewTxBuilder().ActivatePeer("root@com", "target@peer"),
+		},
+		{
+			"suspend_peer",
+			`    suspend_peer:
+      authorizer_id: root@com
+      peer_id: target@peer`,
+			fc.NewTxBuilder().SuspendPeer("root@com", "target@peer"),
+		},
+		{
+			"ban_peer",
+			`    BanPeer:
+      authorizer_id: root@com
+      peer_id: target@peer`,
+			fc.NewTxBuilder().BanPeer("root@com", "target@peer"),
+		},
+		{
+			"consign",
+			`    consign:
+      authorizer_id: root@com
+      account_id: target@com
+      peer_id: target@peer`,
+			fc.NewTxBuilder().Consign("root@com", "target@com", "target@peer"),
+		},
+		{
+			"check_and_commit_prosl",
+			`    check_and_commit_prosl:
+      authorizer_id: root@com
+      prosl_id: target@com/prosl
+      variables:
+        map:
+          a: 1ll`,
+			fc.NewTxBuilder().CheckAndCommitProsl("root@com", "target@com/prosl", map[string]model.Object{"a": object}),
+		},
+		{
+			"revert_prosl",
+			`    revert_prosl:
+      authorizer_id: root@com
+      target_id: consensus/prosl
+      version: 1ll
+      variables:
+        map:
+          a: 1ll`,
+			fc.NewTxBuilder().RevertProsl("root@com", "consensus/prosl", 1, map[string]model.Object{"a": object}),
+		},
+		{
+			"invoke_prosl",
+			`    invoke_prosl:
+      authorizer_id: root@com
+      prosl_id: target@com/contract
+      params:
+        map:
+          a: 1ll`,
+			fc.NewTxBuilder().InvokeProsl("root@com", "target@com/contract", map[string]model.Object{"a": object}),
+		},
+		{
+			"force_update_storage",
+			`    force_update_storage:
+      authorizer_id: root@com
+      wallet_id: target@com/st
+      storage:
+        storage:
+          a: 1ll`,
+			fc.NewTxBuilder().ForceUpdateStorage("root@com", "target@com/st", storage),
+		},
+		{
+			"deprecated authoirzer param",
+			`    add_balance:
+      authoirzer: root@com
+      account_id: target@com
+      balance: 10ll`,
+			fc.NewTxBuilder().AddBalance("root@com", "target@com", 10),
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			state := executeCommandYaml(t, c.yaml)
+			require.NoError(t, state.Err)
+			exp := c.exp.Build().GetPayload().GetCommands()[0]
+			assert.Equal(t, exp.Hash(), state.ReturnObject.GetCommand().Hash())
+		})
+	}
+}
+
+func TestExecuteProslCmdOperator_Failed(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		yaml string
+		code proskenion.ErrCode
+		err  error
+	}{
+		{
+			"unknown parameter",
+			`    set_quorum:
+      authoirzer_id: root@com
+      account_id: target@com
+      quorum: 2`,
+			proskenion.ErrCode_Sentence,
+			ErrProslExecuteSentence,
+		},
+		{
+			"unknown command",
+			`    set_qurum:
+      authorizer_id: root@com`,
+			proskenion.ErrCode_UnImplemented,
+			ErrProslExecuteUnImplemented,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			state := executeCommandYaml(t, c.yaml)
+			require.Error(t, state.Err)
+			assert.Equal(t, c.code, state.ErrCode)
+			assert.Contains(t, state.Err.Error(), c.err.Error())
+		})
+	}
+}
+
+func TestExecuteProslCmdOperator_UnknownParamNotActivated(t *testing.T) {
+	fc := RandomFactory()
+	rp := RandomRepository()
+	// prosl_params を有効化していない Chain では不明な引数名を無視する
+	CommitTxWrapBlock(t, rp, fc, fc.NewTxBuilder().
+		CreateAccount("root@com", "root@com", []model.PublicKey{}, 0).
+		Build())
+	wsv, err := rp.TopWSV()
+	require.NoError(t, err)
+	defer core.CommitTx(wsv)
+
+	pr, err := ConvertYamlToProtobuf([]byte(`- return:
+    set_quorum:
+      authorizer_id: root@com
+      authoirzer_id: root@com
+      account_id: target@com
+      quorum: 2`))
+	require.NoError(t, err)
+	state := ExecuteProsl(pr, InitProslStateValue(fc, wsv, nil, RandomCryptor(), RandomConfig()))
+	require.NoError(t, state.Err)
+	exp := fc.NewTxBuilder().SetQuorum("root@com", "target@com", 2).Build().GetPayload().GetCommands()[0]
+	assert.Equal(t, exp.Hash(), state.ReturnObject.GetCommand().Hash())
+}
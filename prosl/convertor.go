@@ -6,6 +6,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/proskenion/proskenion/core/model"
 	"github.com/proskenion/proskenion/proto"
+	"github.com/satellitex/protobuf/proto"
 	"go.uber.org/multierr"
 	"gopkg.in/yaml.v2"
 	"strconv"
@@ -59,6 +60,9 @@ func ProslParseErrCode(code string) proskenion.ErrCode {
 	case "AnythingErrCode":
 		return proskenion.ErrCode_Anything
 	}
+	if c, ok := proskenion.ErrCode_value[code]; ok {
+		return proskenion.ErrCode(c)
+	}
 	return proskenion.ErrCode_Anything
 }
 
@@ -125,6 +129,8 @@ func ProslParseObjectCode(yaml interface{}) (proskenion.ObjectCode, error) {
 	}
 	code := strings.ToLower(s)
 	switch code {
+	case "anything":
+		return proskenion.ObjectCode_AnythingObjectCode, nil
 	case "bool":
 		return proskenion.ObjectCode_BoolObjectCode, nil
 	case "int32":
@@ -153,6 +159,8 @@ func ProslParseObjectCode(yaml interface{}) (proskenion.ObjectCode, error) {
 		return proskenion.ObjectCode_DictObjectCode, nil
 	case "storage":
 		return proskenion.ObjectCode_StorageObjectCode, nil
+	case "megastorage":
+		return proskenion.ObjectCode_MegaStorageObjectCode, nil
 	case "command":
 		return proskenion.ObjectCode_CommandObjectCode, nil
 	case "transaction":
@@ -241,6 +249,12 @@ func ParseProslOperator(yamap map[interface{}]interface{}) (*proskenion.ProslOpe
 				return nil, err
 			}
 			return &proskenion.ProslOperator{Op: &proskenion.ProslOperator_EachOp{EachOp: op}}, nil
+		case "emit":
+			op, err := ParseEmitOperator(value)
+			if err != nil {
+				return nil, err
+			}
+			return &proskenion.ProslOperator{Op: &proskenion.ProslOperator_EmitOp{EmitOp: op}}, nil
 		default:
 			return nil, ProslParseErrOperation(key, yamap)
 		}
@@ -388,6 +402,35 @@ func ParseReturnOperator(yaml interface{}) (*proskenion.ReturnOperator, error) {
 	return ret, nil
 }
 
+func ParseEmitOperator(yaml interface{}) (*proskenion.EmitOperator, error) {
+	if yamap, ok := yaml.(map[interface{}]interface{}); ok {
+		ret := &proskenion.EmitOperator{}
+		for key, value := range yamap {
+			switch key {
+			case "type":
+				if s, ok := value.(string); ok {
+					ret.EventType = s
+				} else {
+					return nil, ProslParseCastError("", value, yaml)
+				}
+			case "attributes":
+				op, err := ParseMapOperator(value)
+				if err != nil {
+					return nil, err
+				}
+				ret.Attributes = op
+			default:
+				return nil, ProslParseErrOperation(key, yaml)
+			}
+		}
+		if ret.EventType == "" {
+			return nil, errors.Wrapf(ErrProslParseArgumentSize, "Must be type operand, %#v", yaml)
+		}
+		return ret, nil
+	}
+	return nil, ProslParseCastError(make(map[interface{}]interface{}), yaml, yaml)
+}
+
 func ParseEachOperator(yaml interface{}) (*proskenion.EachOperator, error) {
 	if yalist, ok := yaml.([]interface{}); ok {
 		if len(yalist) < 2 {
@@ -580,6 +623,12 @@ func ParseValueOperator(yaml interface{}) (*proskenion.ValueOperator, error) {
 					return nil, err
 				}
 				return &proskenion.ValueOperator{Op: &proskenion.ValueOperator_LenOp{op}}, nil
+			case "object":
+				ob, err := ParseObjectOperator(value)
+				if err != nil {
+					return nil, err
+				}
+				return &proskenion.ValueOperator{Op: &proskenion.ValueOperator_Object{Object: ob}}, nil
 			default: // another case, all command
 				op, err := ParseCommandOperator(v)
 				if err != nil {
@@ -602,6 +651,128 @@ func ParseValueOperator(yaml interface{}) (*proskenion.ValueOperator, error) {
 	return &proskenion.ValueOperator{Op: &proskenion.ValueOperator_Object{Object: ob}}, nil
 }
 
+// object:
+//  - type (string)
+//  - value (primitive)
+// or
+// object: 0x... (protobuf binary of Object)
+func ParseObjectOperator(yaml interface{}) (*proskenion.Object, error) {
+	if s, ok := yaml.(string); ok {
+		if !strings.HasPrefix(s, "0x") {
+			return nil, ProslParseCastError("0x", yaml, yaml)
+		}
+		data, err := hex.DecodeString(s[2:])
+		if err != nil {
+			return nil, errors.Wrap(ErrProslParseUnExpectedCastType, err.Error())
+		}
+		ret := &proskenion.Object{}
+		if err := proto.Unmarshal(data, ret); err != nil {
+			return nil, errors.Wrap(ErrProslParseUnExpectedCastType, err.Error())
+		}
+		return ret, nil
+	}
+	yalist, ok := yaml.([]interface{})
+	if !ok {
+		return nil, ProslParseCastError(make([]interface{}, 0), yaml, yaml)
+	}
+	if len(yalist) != 2 {
+		return nil, ProslParseArgumentError(2, len(yalist), yaml)
+	}
+	code, err := ProslParseObjectCode(yalist[0])
+	if err != nil {
+		return nil, err
+	}
+	value := yalist[1]
+	ret := &proskenion.Object{Type: code}
+	switch code {
+	case proskenion.ObjectCode_BoolObjectCode:
+		b, ok := value.(bool)
+		if !ok {
+			return nil, ProslParseCastError(false, value, yaml)
+		}
+		ret.Object = &proskenion.Object_Boolean{b}
+	case proskenion.ObjectCode_Int32ObjectCode:
+		i, err := proslParseInteger(value, 32, false)
+		if err != nil {
+			return nil, err
+		}
+		ret.Object = &proskenion.Object_I32{int32(i)}
+	case proskenion.ObjectCode_Int64ObjectCode:
+		i, err := proslParseInteger(value, 64, false)
+		if err != nil {
+			return nil, err
+		}
+		ret.Object = &proskenion.Object_I64{int64(i)}
+	case proskenion.ObjectCode_Uint32ObjectCode:
+		i, err := proslParseInteger(value, 32, true)
+		if err != nil {
+			return nil, err
+		}
+		ret.Object = &proskenion.Object_U32{uint32(i)}
+	case proskenion.ObjectCode_Uint64ObjectCode:
+		i, err := proslParseInteger(value, 64, true)
+		if err != nil {
+			return nil, err
+		}
+		ret.Object = &proskenion.Object_U64{i}
+	case proskenion.ObjectCode_StringObjectCode:
+		s, ok := value.(string)
+		if !ok {
+			return nil, ProslParseCastError("", value, yaml)
+		}
+		ret.Object = &proskenion.Object_Str{s}
+	case proskenion.ObjectCode_AddressObjectCode:
+		s, ok := value.(string)
+		if !ok {
+			return nil, ProslParseCastError("", value, yaml)
+		}
+		ret.Object = &proskenion.Object_Address{s}
+	case proskenion.ObjectCode_BytesObjectCode:
+		s, ok := value.(string)
+		if !ok || !strings.HasPrefix(s, "0x") {
+			return nil, ProslParseCastError("0x", value, yaml)
+		}
+		data, err := hex.DecodeString(s[2:])
+		if err != nil {
+			return nil, errors.Wrap(ErrProslParseUnExpectedCastType, err.Error())
+		}
+		ret.Object = &proskenion.Object_Data{data}
+	default:
+		return nil, errors.Wrapf(ErrProslParseUnknownObjectCode, "not primitive type : %s", code.String())
+	}
+	return ret, nil
+}
+
+// proslParseInteger は yaml の整数もしくは10進数文字列を bit 幅に収まる値として読む。
+// 符号付きの場合は int64 を uint64 にビットキャストして返す。
+func proslParseInteger(value interface{}, bitSize int, unsigned bool) (uint64, error) {
+	var s string
+	switch v := value.(type) {
+	case int:
+		s = strconv.FormatInt(int64(v), 10)
+	case int64:
+		s = strconv.FormatInt(v, 10)
+	case uint64:
+		s = strconv.FormatUint(v, 10)
+	case string:
+		s = v
+	default:
+		return 0, ProslParseCastError(0, value, value)
+	}
+	if unsigned {
+		ret, err := strconv.ParseUint(s, 10, bitSize)
+		if err != nil {
+			return 0, errors.Wrap(ErrProslParseUnExpectedCastType, err.Error())
+		}
+		return ret, nil
+	}
+	ret, err := strconv.ParseInt(s, 10, bitSize)
+	if err != nil {
+		return 0, errors.Wrap(ErrProslParseUnExpectedCastType, err.Error())
+	}
+	return uint64(ret), nil
+}
+
 func ParseOrderBy(yaml interface{}) (*proskenion.OrderBy, error) {
 	if value, ok := yaml.([]interface{}); ok {
 		orderBy := &proskenion.OrderBy{}
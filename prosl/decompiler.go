@@ -0,0 +1,627 @@
+package prosl
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/proskenion/proskenion/proto"
+	"github.com/satellitex/protobuf/proto"
+	"gopkg.in/yaml.v2"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrConvertProtobufToMap      = fmt.Errorf("Failed Convert protobuf to map")
+	ErrConvertMapToYaml          = fmt.Errorf("Failed Convert map to yaml")
+	ErrProslDecompileNilOperator = fmt.Errorf("Failed Prosl Decompile nil operator")
+	ErrProslDecompileEmptyBlock  = fmt.Errorf("Failed Prosl Decompile empty block")
+)
+
+// 値演算子の予約語。これらと同名のコマンドは command: で包んで出力する。
+var proslValueOperatorKeywords = map[string]struct{}{
+	"query": {}, "transaction": {}, "command": {}, "storage": {}, "map": {}, "list": {},
+	"plus": {}, "minus": {}, "mult": {}, "div": {}, "mod": {}, "or": {}, "and": {}, "xor": {}, "concat": {},
+	"valued": {}, "indexed": {}, "variable": {}, "var": {}, "cast": {},
+	"list_comprehension": {}, "list_comp": {}, "comprehension": {}, "comp": {},
+	"sort": {}, "slice": {}, "is_defined": {}, "verify": {}, "pagerank": {}, "len": {}, "object": {},
+}
+
+func ProslDecompileNilError(name string) error {
+	return errors.Wrapf(ErrProslDecompileNilOperator, "%s is nil", name)
+}
+
+func ProslDecompileEmptyBlockError(name string) error {
+	return errors.Wrapf(ErrProslDecompileEmptyBlock, "%s must have at least one operator", name)
+}
+
+func ProslDecompileObjectCode(code proskenion.ObjectCode) string {
+	return strings.ToLower(strings.TrimSuffix(code.String(), "ObjectCode"))
+}
+
+func ProslDecompileOrderCode(code proskenion.OrderCode) string {
+	if code == proskenion.OrderCode_DESC {
+		return "DESC"
+	}
+	return "ASC"
+}
+
+func DecompileProsl(prosl *proskenion.Prosl) ([]interface{}, error) {
+	ret := make([]interface{}, 0, len(prosl.GetOps()))
+	for _, op := range prosl.GetOps() {
+		v, err := DecompileProslOperator(op)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, v)
+	}
+	return ret, nil
+}
+
+func DecompileProslOperator(op *proskenion.ProslOperator) (map[string]interface{}, error) {
+	switch o := op.GetOp().(type) {
+	case *proskenion.ProslOperator_SetOp:
+		v, err := DecompileSetOperator(o.SetOp)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"set": v}, nil
+	case *proskenion.ProslOperator_IfOp:
+		v, err := decompileConditionalBlock("if", o.IfOp.GetOp(), o.IfOp.GetProsl())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"if": v}, nil
+	case *proskenion.ProslOperator_ElifOp:
+		v, err := decompileConditionalBlock("elif", o.ElifOp.GetOp(), o.ElifOp.GetProsl())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"elif": v}, nil
+	case *proskenion.ProslOperator_ElseOp:
+		v, err := DecompileProsl(o.ElseOp.GetProsl())
+		if err != nil {
+			return nil, err
+		}
+		if len(v) == 0 {
+			return nil, ProslDecompileEmptyBlockError("else")
+		}
+		return map[string]interface{}{"else": v}, nil
+	case *proskenion.ProslOperator_ErrOp:
+		v, err := DecompileProsl(o.ErrOp.GetProsl())
+		if err != nil {
+			return nil, err
+		}
+		if len(v) == 0 {
+			return nil, ProslDecompileEmptyBlockError("err")
+		}
+		return map[string]interface{}{"err": append([]interface{}{o.ErrOp.GetCode().String()}, v...)}, nil
+	case *proskenion.ProslOperator_RequireOp:
+		v, err := DecompileConditionalFormula(o.RequireOp.GetOp())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"require": v}, nil
+	case *proskenion.ProslOperator_AssertOp:
+		v, err := DecompileConditionalFormula(o.AssertOp.GetOp())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"assert": v}, nil
+	case *proskenion.ProslOperator_ReturnOp:
+		v, err := DecompileValueOperator(o.ReturnOp.GetOp())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"return": v}, nil
+	case *proskenion.ProslOperator_EachOp:
+		list, err := DecompileValueOperator(o.EachOp.GetList())
+		if err != nil {
+			return nil, err
+		}
+		do, err := DecompileProsl(o.EachOp.GetDo())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"each": append([]interface{}{list, o.EachOp.GetVariableName()}, do...)}, nil
+	case *proskenion.ProslOperator_EmitOp:
+		v, err := DecompileEmitOperator(o.EmitOp)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"emit": v}, nil
+	}
+	return nil, ProslDecompileNilError("prosl operator")
+}
+
+// emit:
+//  - type: eventType (string)
+//  - attributes: mapOperator
+func DecompileEmitOperator(op *proskenion.EmitOperator) (map[string]interface{}, error) {
+	ret := map[string]interface{}{"type": op.GetEventType()}
+	if op.GetAttributes() != nil {
+		v, err := DecompileMapOperator(op.GetAttributes())
+		if err != nil {
+			return nil, err
+		}
+		ret["attributes"] = v
+	}
+	return ret, nil
+}
+
+// set:
+//  - variableName (string)
+//  - valueOperator (interface{})
+func DecompileSetOperator(op *proskenion.SetOperator) ([]interface{}, error) {
+	v, err := DecompileValueOperator(op.GetValue())
+	if err != nil {
+		return nil, err
+	}
+	return []interface{}{op.GetVariableName(), v}, nil
+}
+
+func decompileConditionalBlock(name string, cond *proskenion.ConditionalFormula, prosl *proskenion.Prosl) ([]interface{}, error) {
+	c, err := DecompileConditionalFormula(cond)
+	if err != nil {
+		return nil, err
+	}
+	body, err := DecompileProsl(prosl)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) == 0 {
+		return nil, ProslDecompileEmptyBlockError(name)
+	}
+	return append([]interface{}{c}, body...), nil
+}
+
+func DecompileValueOperator(op *proskenion.ValueOperator) (interface{}, error) {
+	switch o := op.GetOp().(type) {
+	case *proskenion.ValueOperator_QueryOp:
+		v, err := DecompileQueryOperator(o.QueryOp)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"query": v}, nil
+	case *proskenion.ValueOperator_TxOp:
+		v := make(map[string]interface{})
+		if o.TxOp.GetCommands() != nil {
+			cmds, err := DecompileValueOperator(o.TxOp.GetCommands())
+			if err != nil {
+				return nil, err
+			}
+			v["commands"] = cmds
+		}
+		return map[string]interface{}{"transaction": v}, nil
+	case *proskenion.ValueOperator_CmdOp:
+		return DecompileCommandOperator(o.CmdOp)
+	case *proskenion.ValueOperator_StorageOp:
+		v, err := DecompileMapOperator(o.StorageOp.GetObject())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"storage": v}, nil
+	case *proskenion.ValueOperator_PlusOp:
+		return decompilePolynomial("plus", o.PlusOp.GetOps())
+	case *proskenion.ValueOperator_MinusOp:
+		return decompilePolynomial("minus", o.MinusOp.GetOps())
+	case *proskenion.ValueOperator_MulOp:
+		return decompilePolynomial("mult", o.MulOp.GetOps())
+	case *proskenion.ValueOperator_DivOp:
+		return decompilePolynomial("div", o.DivOp.GetOps())
+	case *proskenion.ValueOperator_ModOp:
+		return decompilePolynomial("mod", o.ModOp.GetOps())
+	case *proskenion.ValueOperator_OrOp:
+		return decompilePolynomial("or", o.OrOp.GetOps())
+	case *proskenion.ValueOperator_AndOp:
+		return decompilePolynomial("and", o.AndOp.GetOps())
+	case *proskenion.ValueOperator_XorOp:
+		return decompilePolynomial("xor", o.XorOp.GetOps())
+	case *proskenion.ValueOperator_ConcatOp:
+		return decompilePolynomial("concat", o.ConcatOp.GetOps())
+	case *proskenion.ValueOperator_ValuedOp:
+		v, err := DecompileValueOperator(o.ValuedOp.GetObject())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"valued": []interface{}{
+			v, ProslDecompileObjectCode(o.ValuedOp.GetType()), o.ValuedOp.GetKey()}}, nil
+	case *proskenion.ValueOperator_IndexedOp:
+		v, err := DecompileValueOperator(o.IndexedOp.GetObject())
+		if err != nil {
+			return nil, err
+		}
+		index, err := DecompileValueOperator(o.IndexedOp.GetIndex())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"indexed": []interface{}{
+			v, ProslDecompileObjectCode(o.IndexedOp.GetType()), index}}, nil
+	case *proskenion.ValueOperator_VariableOp:
+		return map[string]interface{}{"var": o.VariableOp.GetVariableName()}, nil
+	case *proskenion.ValueOperator_Object:
+		return DecompileObject(o.Object)
+	case *proskenion.ValueOperator_ListOp:
+		return DecompileListOperator(o.ListOp)
+	case *proskenion.ValueOperator_MapOp:
+		v, err := DecompileMapOperator(o.MapOp)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"map": v}, nil
+	case *proskenion.ValueOperator_CastOp:
+		v, err := DecompileValueOperator(o.CastOp.GetObject())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"cast": []interface{}{ProslDecompileObjectCode(o.CastOp.GetType()), v}}, nil
+	case *proskenion.ValueOperator_ListComprehensionOp:
+		v, err := DecompileListComprehensionOperator(o.ListComprehensionOp)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"list_comprehension": v}, nil
+	case *proskenion.ValueOperator_SortOp:
+		v, err := DecompileSortOperator(o.SortOp)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"sort": v}, nil
+	case *proskenion.ValueOperator_SliceOp:
+		v, err := DecompileSliceOperator(o.SliceOp)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"slice": v}, nil
+	case *proskenion.ValueOperator_IsDefinedOp:
+		return map[string]interface{}{"is_defined": o.IsDefinedOp.GetVariableName()}, nil
+	case *proskenion.ValueOperator_VerifyOp:
+		v, err := DecompileVerifyOperator(o.VerifyOp)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"verify": v}, nil
+	case *proskenion.ValueOperator_PageRankOp:
+		v, err := DecompilePageRankOperator(o.PageRankOp)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"pagerank": v}, nil
+	case *proskenion.ValueOperator_LenOp:
+		v, err := DecompileValueOperator(o.LenOp.GetList())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"len": v}, nil
+	}
+	return nil, ProslDecompileNilError("value operator")
+}
+
+func decompilePolynomial(name string, ops []*proskenion.ValueOperator) (map[string]interface{}, error) {
+	v, err := DecompilePolynomialOperator(ops)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{name: v}, nil
+}
+
+func DecompilePolynomialOperator(ops []*proskenion.ValueOperator) ([]interface{}, error) {
+	ret := make([]interface{}, 0, len(ops))
+	for _, op := range ops {
+		v, err := DecompileValueOperator(op)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, v)
+	}
+	return ret, nil
+}
+
+// DecompileObject は Object を ProslParsePrimitiveObject で同じ値に戻るリテラルに変換する。
+// 戻らない場合は object: [type, value] もしくは object: 0x... の明示形式を用いる。
+func DecompileObject(o *proskenion.Object) (interface{}, error) {
+	if o == nil {
+		return nil, ProslDecompileNilError("object")
+	}
+	var literal interface{}
+	switch v := o.GetObject().(type) {
+	case *proskenion.Object_Boolean:
+		literal = v.Boolean
+	case *proskenion.Object_I32:
+		literal = int(v.I32)
+	case *proskenion.Object_I64:
+		literal = fmt.Sprintf("%dll", v.I64)
+	case *proskenion.Object_Str:
+		literal = v.Str
+	case *proskenion.Object_Data:
+		literal = "0x" + hex.EncodeToString(v.Data)
+	case *proskenion.Object_Address:
+		literal = v.Address
+	}
+	if literal != nil {
+		if ob, err := ProslParsePrimitiveObject(literal); err == nil && proto.Equal(ob, o) {
+			return literal, nil
+		}
+	}
+
+	// 型付きの明示形式
+	var value interface{}
+	switch v := o.GetObject().(type) {
+	case *proskenion.Object_Boolean:
+		if o.GetType() == proskenion.ObjectCode_BoolObjectCode {
+			value = v.Boolean
+		}
+	case *proskenion.Object_I32:
+		if o.GetType() == proskenion.ObjectCode_Int32ObjectCode {
+			value = int(v.I32)
+		}
+	case *proskenion.Object_I64:
+		if o.GetType() == proskenion.ObjectCode_Int64ObjectCode {
+			value = strconv.FormatInt(v.I64, 10)
+		}
+	case *proskenion.Object_U32:
+		if o.GetType() == proskenion.ObjectCode_Uint32ObjectCode {
+			value = strconv.FormatUint(uint64(v.U32), 10)
+		}
+	case *proskenion.Object_U64:
+		if o.GetType() == proskenion.ObjectCode_Uint64ObjectCode {
+			value = strconv.FormatUint(v.U64, 10)
+		}
+	case *proskenion.Object_Str:
+		if o.GetType() == proskenion.ObjectCode_StringObjectCode {
+			value = v.Str
+		}
+	case *proskenion.Object_Address:
+		if o.GetType() == proskenion.ObjectCode_AddressObjectCode {
+			value = v.Address
+		}
+	case *proskenion.Object_Data:
+		if o.GetType() == proskenion.ObjectCode_BytesObjectCode {
+			value = "0x" + hex.EncodeToString(v.Data)
+		}
+	}
+	if value != nil {
+		typed := []interface{}{ProslDecompileObjectCode(o.GetType()), value}
+		if ob, err := ParseObjectOperator(typed); err == nil && proto.Equal(ob, o) {
+			return map[string]interface{}{"object": typed}, nil
+		}
+	}
+
+	// それ以外(複合型など)は protobuf binary で埋め込む
+	b, err := proto.Marshal(o)
+	if err != nil {
+		return nil, errors.Wrap(ErrConvertProtobufToMap, err.Error())
+	}
+	return map[string]interface{}{"object": "0x" + hex.EncodeToString(b)}, nil
+}
+
+func DecompileQueryOperator(op *proskenion.QueryOperator) (map[string]interface{}, error) {
+	from, err := DecompileValueOperator(op.GetFrom())
+	if err != nil {
+		return nil, err
+	}
+	ret := map[string]interface{}{
+		"select": op.GetSelect(),
+		"type":   ProslDecompileObjectCode(op.GetType()),
+		"from":   from,
+	}
+	if op.GetAuthorizerId() != nil {
+		v, err := DecompileValueOperator(op.GetAuthorizerId())
+		if err != nil {
+			return nil, err
+		}
+		ret["authorizer"] = v
+	}
+	if op.GetWhere() != nil {
+		v, err := DecompileValueOperator(op.GetWhere())
+		if err != nil {
+			return nil, err
+		}
+		ret["where"] = v
+	}
+	if op.GetOrderBy() != nil {
+		ret["order_by"] = DecompileOrderBy(op.GetOrderBy())
+	}
+	if op.GetLimit() != 0 {
+		ret["limit"] = int(op.GetLimit())
+	}
+	return ret, nil
+}
+
+func DecompileOrderBy(orderBy *proskenion.OrderBy) []interface{} {
+	return []interface{}{orderBy.GetKey(), ProslDecompileOrderCode(orderBy.GetOrder())}
+}
+
+func DecompileCommandOperator(op *proskenion.CommandOperator) (map[string]interface{}, error) {
+	params := make(map[string]interface{})
+	for key, value := range op.GetParams() {
+		v, err := DecompileValueOperator(value)
+		if err != nil {
+			return nil, err
+		}
+		params[key] = v
+	}
+	cmd := map[string]interface{}{op.GetCommandName(): params}
+	if _, ok := proslValueOperatorKeywords[op.GetCommandName()]; ok {
+		return map[string]interface{}{"command": cmd}, nil
+	}
+	return cmd, nil
+}
+
+func DecompileListOperator(op *proskenion.ListOperator) ([]interface{}, error) {
+	return DecompilePolynomialOperator(op.GetObject())
+}
+
+func DecompileMapOperator(op *proskenion.MapOperator) (map[string]interface{}, error) {
+	ret := make(map[string]interface{})
+	for key, value := range op.GetObject() {
+		v, err := DecompileValueOperator(value)
+		if err != nil {
+			return nil, err
+		}
+		ret[key] = v
+	}
+	return ret, nil
+}
+
+func DecompileListComprehensionOperator(op *proskenion.ListComprehensionOperator) (map[string]interface{}, error) {
+	list, err := DecompileValueOperator(op.GetList())
+	if err != nil {
+		return nil, err
+	}
+	element, err := DecompileValueOperator(op.GetElement())
+	if err != nil {
+		return nil, err
+	}
+	ret := map[string]interface{}{
+		"list":    list,
+		"var":     op.GetVariableName(),
+		"element": element,
+	}
+	if op.GetIf() != nil {
+		v, err := DecompileConditionalFormula(op.GetIf())
+		if err != nil {
+			return nil, err
+		}
+		ret["if"] = v
+	}
+	return ret, nil
+}
+
+func DecompileSortOperator(op *proskenion.SortOperator) (map[string]interface{}, error) {
+	list, err := DecompileValueOperator(op.GetList())
+	if err != nil {
+		return nil, err
+	}
+	ret := map[string]interface{}{"list": list}
+	if op.GetOrderBy() != nil {
+		ret["order_by"] = DecompileOrderBy(op.GetOrderBy())
+	}
+	if op.GetType() != proskenion.ObjectCode_AnythingObjectCode {
+		ret["type"] = ProslDecompileObjectCode(op.GetType())
+	}
+	if op.GetLimit() != nil {
+		v, err := DecompileValueOperator(op.GetLimit())
+		if err != nil {
+			return nil, err
+		}
+		ret["limit"] = v
+	}
+	return ret, nil
+}
+
+func DecompileSliceOperator(op *proskenion.SliceOperator) (map[string]interface{}, error) {
+	list, err := DecompileValueOperator(op.GetList())
+	if err != nil {
+		return nil, err
+	}
+	ret := map[string]interface{}{"list": list}
+	if op.GetLeft() != nil {
+		v, err := DecompileValueOperator(op.GetLeft())
+		if err != nil {
+			return nil, err
+		}
+		ret["left"] = v
+	}
+	if op.GetRight() != nil {
+		v, err := DecompileValueOperator(op.GetRight())
+		if err != nil {
+			return nil, err
+		}
+		ret["right"] = v
+	}
+	return ret, nil
+}
+
+func DecompileVerifyOperator(op *proskenion.VerifyOperator) (map[string]interface{}, error) {
+	ret := make(map[string]interface{})
+	if op.GetSig() != nil {
+		v, err := DecompileValueOperator(op.GetSig())
+		if err != nil {
+			return nil, err
+		}
+		ret["sig"] = v
+	}
+	if op.GetHash() != nil {
+		v, err := DecompileValueOperator(op.GetHash())
+		if err != nil {
+			return nil, err
+		}
+		ret["hash"] = v
+	}
+	return ret, nil
+}
+
+func DecompilePageRankOperator(op *proskenion.PageRankOperator) (map[string]interface{}, error) {
+	ret := make(map[string]interface{})
+	if op.GetStorages() != nil {
+		v, err := DecompileValueOperator(op.GetStorages())
+		if err != nil {
+			return nil, err
+		}
+		ret["storages"] = v
+	}
+	if op.GetToKey() != nil {
+		v, err := DecompileValueOperator(op.GetToKey())
+		if err != nil {
+			return nil, err
+		}
+		ret["to_key"] = v
+	}
+	if op.GetOutName() != nil {
+		v, err := DecompileValueOperator(op.GetOutName())
+		if err != nil {
+			return nil, err
+		}
+		ret["out_name"] = v
+	}
+	return ret, nil
+}
+
+func DecompileConditionalFormula(op *proskenion.ConditionalFormula) (map[string]interface{}, error) {
+	switch o := op.GetOp().(type) {
+	case *proskenion.ConditionalFormula_Or:
+		return decompilePolynomial("or", o.Or.GetOps())
+	case *proskenion.ConditionalFormula_And:
+		return decompilePolynomial("and", o.And.GetOps())
+	case *proskenion.ConditionalFormula_Not:
+		v, err := DecompileValueOperator(o.Not.GetOp())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"not": v}, nil
+	case *proskenion.ConditionalFormula_Eq:
+		return decompilePolynomial("eq", o.Eq.GetOps())
+	case *proskenion.ConditionalFormula_Ne:
+		return decompilePolynomial("ne", o.Ne.GetOps())
+	case *proskenion.ConditionalFormula_Gt:
+		return decompilePolynomial("gt", o.Gt.GetOps())
+	case *proskenion.ConditionalFormula_Ge:
+		return decompilePolynomial("ge", o.Ge.GetOps())
+	case *proskenion.ConditionalFormula_Lt:
+		return decompilePolynomial("lt", o.Lt.GetOps())
+	case *proskenion.ConditionalFormula_Le:
+		return decompilePolynomial("le", o.Le.GetOps())
+	case *proskenion.ConditionalFormula_VerifyOp:
+		v, err := DecompileVerifyOperator(o.VerifyOp)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"verify": v}, nil
+	}
+	return nil, ProslDecompileNilError("conditional formula")
+}
+
+// ConvertProtobufToYaml は ConvertYamlToProtobuf の逆変換。
+// map のキーは yaml.Marshal によりソートされるため、同じ Prosl からは常に同じ yaml が得られる。
+func ConvertProtobufToYaml(prosl *proskenion.Prosl) ([]byte, error) {
+	yalist, err := DecompileProsl(prosl)
+	if err != nil {
+		return nil, errors.Wrap(ErrConvertProtobufToMap, err.Error())
+	}
+	ret, err := yaml.Marshal(yalist)
+	if err != nil {
+		return nil, errors.Wrap(ErrConvertMapToYaml, err.Error())
+	}
+	return ret, nil
+}
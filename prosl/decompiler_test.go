@@ -0,0 +1,131 @@
+package prosl_test
+
+import (
+	. "github.com/proskenion/proskenion/prosl"
+	"github.com/proskenion/proskenion/proto"
+	"github.com/satellitex/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"testing"
+)
+
+func TestConvertProtobufToYaml_RoundTrip(t *testing.T) {
+	for _, filename := range []string{
+		"./test_yaml/example.yaml",
+		"./test_yaml/genesis.yaml",
+		"./test_yaml/test_1.yaml",
+		"./test_yaml/test_2.yaml",
+		"../test_utils/genesis.yaml",
+		"../test_utils/incentive.yaml",
+		"../test_utils/consensus.yaml",
+		"../test_utils/new_consensus.yaml",
+		"../test_utils/update.yaml",
+		"../test_utils/contract.yaml",
+		"../example/incentive.yaml",
+		"../example/consensus.yaml",
+		"../example/update.yaml",
+		"../example/rep_incentive.yaml",
+		"../example/rep_consensus.yaml",
+		"../example/new_rep_incentive.yaml",
+	} {
+		t.Run(filename, func(t *testing.T) {
+			buf, err := ioutil.ReadFile(filename)
+			require.NoError(t, err)
+			expPr, err := ConvertYamlToProtobuf(buf)
+			require.NoError(t, err)
+
+			yaml, err := ConvertProtobufToYaml(expPr)
+			require.NoError(t, err)
+			actPr, err := ConvertYamlToProtobuf(yaml)
+			require.NoError(t, err)
+			assert.True(t, proto.Equal(expPr, actPr), "%s", string(yaml))
+
+			// canonical
+			yaml2, err := ConvertProtobufToYaml(actPr)
+			require.NoError(t, err)
+			assert.Equal(t, string(yaml), string(yaml2))
+		})
+	}
+}
+
+func TestConvertProtobufToYaml_Objects(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		obj  *proskenion.Object
+	}{
+		{"int32", &proskenion.Object{Type: proskenion.ObjectCode_Int32ObjectCode, Object: &proskenion.Object_I32{-3}}},
+		{"int64", &proskenion.Object{Type: proskenion.ObjectCode_Int64ObjectCode, Object: &proskenion.Object_I64{1 << 40}}},
+		{"uint64", &proskenion.Object{Type: proskenion.ObjectCode_Uint64ObjectCode, Object: &proskenion.Object_U64{1<<64 - 1}}},
+		{"number string", &proskenion.Object{Type: proskenion.ObjectCode_StringObjectCode, Object: &proskenion.Object_Str{"123"}}},
+		{"int64 like string", &proskenion.Object{Type: proskenion.ObjectCode_StringObjectCode, Object: &proskenion.Object_Str{"10ll"}}},
+		{"address like string", &proskenion.Object{Type: proskenion.ObjectCode_StringObjectCode, Object: &proskenion.Object_Str{"root@com"}}},
+		{"bool", &proskenion.Object{Type: proskenion.ObjectCode_BoolObjectCode, Object: &proskenion.Object_Boolean{false}}},
+		{"bytes", &proskenion.Object{Type: proskenion.ObjectCode_BytesObjectCode, Object: &proskenion.Object_Data{[]byte{1, 2, 3}}}},
+		{"list", &proskenion.Object{Type: proskenion.ObjectCode_ListObjectCode, Object: &proskenion.Object_List{
+			&proskenion.ObjectList{List: []*proskenion.Object{
+				{Type: proskenion.ObjectCode_Int32ObjectCode, Object: &proskenion.Object_I32{1}},
+			}}}}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			expPr := &proskenion.Prosl{Ops: []*proskenion.ProslOperator{
+				{Op: &proskenion.ProslOperator_ReturnOp{ReturnOp: &proskenion.ReturnOperator{
+					Op: &proskenion.ValueOperator{Op: &proskenion.ValueOperator_Object{Object: c.obj}},
+				}}},
+			}}
+			yaml, err := ConvertProtobufToYaml(expPr)
+			require.NoError(t, err)
+			actPr, err := ConvertYamlToProtobuf(yaml)
+			require.NoError(t, err)
+			assert.True(t, proto.Equal(expPr, actPr), "%s", string(yaml))
+		})
+	}
+}
+
+func TestConvertProtobufToYaml_ReservedCommandName(t *testing.T) {
+	expPr := &proskenion.Prosl{Ops: []*proskenion.ProslOperator{
+		{Op: &proskenion.ProslOperator_ReturnOp{ReturnOp: &proskenion.ReturnOperator{
+			Op: &proskenion.ValueOperator{Op: &proskenion.ValueOperator_CmdOp{CmdOp: &proskenion.CommandOperator{
+				CommandName: "len",
+				Params:      map[string]*proskenion.ValueOperator{},
+			}}},
+		}}},
+		{Op: &proskenion.ProslOperator_ErrOp{ErrOp: &proskenion.ErrCatchOperator{
+			Code: proskenion.ErrCode_QueryVerify,
+			Prosl: &proskenion.Prosl{Ops: []*proskenion.ProslOperator{
+				{Op: &proskenion.ProslOperator_ReturnOp{ReturnOp: &proskenion.ReturnOperator{
+					Op: &proskenion.ValueOperator{Op: &proskenion.ValueOperator_VariableOp{
+						VariableOp: &proskenion.VariableOperator{VariableName: "a"}}},
+				}}},
+			}},
+		}}},
+	}}
+	yaml, err := ConvertProtobufToYaml(expPr)
+	require.NoError(t, err)
+	actPr, err := ConvertYamlToProtobuf(yaml)
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(expPr, actPr), "%s", string(yaml))
+}
+
+func TestConvertProtobufToYaml_Failed(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		prosl *proskenion.Prosl
+		err   error
+	}{
+		{"nil value", &proskenion.Prosl{Ops: []*proskenion.ProslOperator{
+			{Op: &proskenion.ProslOperator_ReturnOp{ReturnOp: &proskenion.ReturnOperator{}}},
+		}}, ErrProslDecompileNilOperator},
+		{"empty if", &proskenion.Prosl{Ops: []*proskenion.ProslOperator{
+			{Op: &proskenion.ProslOperator_IfOp{IfOp: &proskenion.IfOperator{
+				Op: &proskenion.ConditionalFormula{Op: &proskenion.ConditionalFormula_Eq{Eq: &proskenion.EqFormula{}}},
+			}}},
+		}}, ErrProslDecompileEmptyBlock},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := ConvertProtobufToYaml(c.prosl)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), c.err.Error())
+		})
+	}
+}
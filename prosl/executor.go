@@ -251,6 +251,8 @@ func ExecuteProslOpFormula(op *proskenion.ProslOperator, state *ProslStateValue)
 		state = ExecuteProslReturnOperator(op.GetReturnOp(), state)
 	case *proskenion.ProslOperator_EachOp:
 		state = ExecuteProslEachOperator(op.GetEachOp(), state)
+	case *proskenion.ProslOperator_EmitOp:
+		state = ExecuteProslEmitOperator(op.GetEmitOp(), state)
 	default:
 		return ReturnErrorProslStateValue(state, proskenion.ErrCode_UnImplemented, "unimlemented operator")
 	}
@@ -351,6 +353,38 @@ func ExecuteProslReturnOperator(op *proskenion.ReturnOperator, state *ProslState
 	return ReturnReturnProslStateValue(state)
 }
 
+// variableAddress は names の中で最初に定義されている変数の address を返す
+func variableAddress(state *ProslStateValue, names ...string) string {
+	for _, name := range names {
+		if v, ok := state.Variables[name]; ok && v != nil {
+			return v.GetAddress()
+		}
+	}
+	return ""
+}
+
+func ExecuteProslEmitOperator(op *proskenion.EmitOperator, state *ProslStateValue) *ProslStateValue {
+	if op.GetEventType() == "" {
+		return ReturnErrorProslStateValue(state, proskenion.ErrCode_Sentence, "emit type is empty, %s", op.String())
+	}
+	attributes := make(map[string]model.Object)
+	if op.GetAttributes() != nil {
+		state = ExecuteProslMapOperator(op.GetAttributes(), state)
+		if state.Err != nil {
+			return state
+		}
+		attributes = state.ReturnObject.GetDict()
+	}
+	// wsv が EventEmitter でない場合(consensus 等)は発行しない
+	if em, ok := state.Wsv.(model.EventEmitter); ok {
+		em.Emit(state.Fc.NewEvent(op.GetEventType(),
+			variableAddress(state, core.AuthorizerIdKey, core.InvokerIdKey),
+			variableAddress(state, core.TargetIdKey),
+			attributes))
+	}
+	return ReturnOpProslStateValue(state, AnotherOperator_State)
+}
+
 func ExecuteProslEachOperator(op *proskenion.EachOperator, state *ProslStateValue) *ProslStateValue {
 	state = ExecuteProslValueOperator(op.GetList(), state)
 	if state.Err != nil {
@@ -474,12 +508,17 @@ func ExecuteProslQueryOperator(op *proskenion.QueryOperator, state *ProslStateVa
 	if err := state.Qc.Verify(query); err != nil {
 		return ReturnErrorProslStateValue(state, proskenion.ErrCode_QueryVerify, err.Error())
 	}
-	// WIP : no validate
-	/*
-		if err := state.Qc.Validate(query); err != nil {
+	// Prosl 内の Query は署名を持たないため、読み込み権限のみ検証する。
+	// Command から実行された場合はその authorizer (invoker) の権限も検証する。
+	authorizers := []string{query.GetPayload().GetAuthorizerId()}
+	if caller := variableAddress(state, core.InvokerIdKey, core.AuthorizerIdKey); caller != "" {
+		authorizers = append(authorizers, caller)
+	}
+	for _, authorizerId := range authorizers {
+		if err := state.Qc.ValidateRead(state.Wsv, authorizerId, query.GetPayload().GetFromId()); err != nil {
 			return ReturnErrorProslStateValue(state, proskenion.ErrCode_QueryValidate, err.Error())
 		}
-	*/
+	}
 	ret, err := state.Qc.Query(state.Wsv, query)
 	if err != nil {
 		return ReturnErrorProslStateValue(state, proskenion.ErrCode_Internal, err.Error())
@@ -524,7 +563,7 @@ func ExecuteProslCmdOperator(op *proskenion.CommandOperator, state *ProslStateVa
 		return ExecuteProslAddPublicKeys(op.GetParams(), state)
 	case "removepublickeys":
 		return ExecuteProslRemovePublicKeys(op.GetParams(), state)
-	case "setqurum":
+	case "setquorum":
 		return ExecuteProslSetQuorum(op.GetParams(), state)
 	case "definestorage":
 		return ExecuteProslDefineStorage(op.GetParams(), state)
@@ -542,6 +581,20 @@ func ExecuteProslCmdOperator(op *proskenion.CommandOperator, state *ProslStateVa
 		return ExecuteProslConsign(op.GetParams(), state)
 	case "activatepeer":
 		return ExecuteProslActivatePeer(op.GetParams(), state)
+	case "suspendpeer":
+		return ExecuteProslSuspendPeer(op.GetParams(), state)
+	case "banpeer":
+		return ExecuteProslBanPeer(op.GetParams(), state)
+	case "checkandcommitprosl":
+		return ExecuteProslCheckAndCommitProsl(op.GetParams(), state)
+	case "revertprosl":
+		return ExecuteProslRevertProsl(op.GetParams(), state)
+	case "invokeprosl":
+		return ExecuteProslInvokeProsl(op.GetParams(), state)
+	case "grantpermission":
+		return ExecuteProslGrantPermission(op.GetParams(), state)
+	case "revokepermission":
+		return ExecuteProslRevokePermission(op.GetParams(), state)
 	case "forceupdate", "forceupdatestorage", "updatestorage":
 		return ExecuteProslForceUpdateStorage(op.GetParams(), state)
 	default:
@@ -208,3 +208,40 @@ func TestExecuteProsl(t *testing.T) {
 		Build()
 	testIncentiveExecuteProsl(t, "./test_yaml/test_2.yaml", fc, rp, conf, expTx)
 }
+
+func TestExecuteProslEmitOperator(t *testing.T) {
+	rp, fc, conf := Initalize()
+	wsv, err := rp.TopWSV()
+	require.NoError(t, err)
+	defer core.CommitTx(wsv)
+
+	pr, err := ConvertYamlToProtobuf([]byte(`
+- emit:
+    type: deposit
+    attributes:
+      from:
+        variable: invoker_id
+      amount: 10ll
+- emit:
+    type: done
+- return: true
+`))
+	require.NoError(t, err)
+	params := map[string]model.Object{
+		core.TargetIdKey:  fc.NewObjectBuilder().Address("owner@com/contract"),
+		core.InvokerIdKey: fc.NewObjectBuilder().Address("invoker@com"),
+	}
+	state := ExecuteProsl(pr, InitProslStateValueWithPrams(fc, wsv, nil, RandomCryptor(), conf, params))
+	require.NoError(t, state.Err)
+
+	events := wsv.PopEvents()
+	require.Equal(t, 2, len(events))
+	assert.Equal(t, "deposit", events[0].GetType())
+	assert.Equal(t, "invoker@com", events[0].GetAuthorizerId())
+	assert.Equal(t, "owner@com/contract", events[0].GetTargetId())
+	assert.Equal(t, "invoker@com", events[0].GetAttributes()["from"].GetAddress())
+	assert.Equal(t, int64(10), events[0].GetAttributes()["amount"].GetI64())
+	assert.Equal(t, "done", events[1].GetType())
+	assert.Equal(t, 0, len(events[1].GetAttributes()))
+	assert.Equal(t, 0, len(wsv.PopEvents()))
+}
@@ -29,6 +29,13 @@ func (p *Prosl) ConvertFromYaml(yaml []byte) error {
 	return nil
 }
 
+func (p *Prosl) ConvertToYaml() ([]byte, error) {
+	if p.prosl == nil {
+		return nil, errors.Errorf("Must be prosl setting, from yaml or protobuf binary")
+	}
+	return ConvertProtobufToYaml(p.prosl)
+}
+
 func (p *Prosl) Validate() error {
 	return nil
 }
@@ -0,0 +1,317 @@
+package tester
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/proskenion/proskenion/config"
+	"github.com/proskenion/proskenion/core"
+	"github.com/proskenion/proskenion/core/model"
+	"github.com/proskenion/proskenion/dba"
+	"github.com/proskenion/proskenion/prosl"
+	"github.com/proskenion/proskenion/proto"
+	"github.com/proskenion/proskenion/repository"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrProslTestFixture            = fmt.Errorf("Failed Prosl Test invalid fixture")
+	ErrProslTestUnexpectedReturn   = fmt.Errorf("Failed Prosl Test unexpected return object")
+	ErrProslTestUnexpectedErrCode  = fmt.Errorf("Failed Prosl Test unexpected error code")
+	ErrProslTestUnexpectedExecuted = fmt.Errorf("Failed Prosl Test expected error but executed")
+)
+
+// Fixture は Prosl のテストを宣言的に記述する yaml の構造。
+//
+// top: 実行時の top block
+// accounts, peers, storages: 実行前の WSV
+// prosl: テスト対象の Prosl (ファイルパス、もしくは Prosl そのもの)
+// cases: 引数と期待値の組
+type Fixture struct {
+	Top      *BlockFixture    `yaml:"top"`
+	Accounts []AccountFixture `yaml:"accounts"`
+	Peers    []PeerFixture    `yaml:"peers"`
+	Storages []StorageFixture `yaml:"storages"`
+	Prosl    interface{}      `yaml:"prosl"`
+	Cases    []CaseFixture    `yaml:"cases"`
+}
+
+type BlockFixture struct {
+	Height      int64  `yaml:"height"`
+	CreatedTime int64  `yaml:"created_time"`
+	Round       int32  `yaml:"round"`
+	PreHash     string `yaml:"pre_block_hash"`
+}
+
+type AccountFixture struct {
+	Id             string   `yaml:"id"`
+	Name           string   `yaml:"name"`
+	PublicKeys     []string `yaml:"public_keys"`
+	Quorum         int32    `yaml:"quorum"`
+	Balance        int64    `yaml:"balance"`
+	DelegatePeerId string   `yaml:"delegate_peer_id"`
+}
+
+type PeerFixture struct {
+	Id        string `yaml:"id"`
+	Address   string `yaml:"address"`
+	PublicKey string `yaml:"public_key"`
+	Active    bool   `yaml:"active"`
+	Ban       bool   `yaml:"ban"`
+}
+
+// Object の各値は Prosl の value operator として評価される。
+type StorageFixture struct {
+	Id     string                 `yaml:"id"`
+	Object map[string]interface{} `yaml:"object"`
+}
+
+// Params の各値は Prosl の value operator として評価され、ExecuteWithParams の引数になる。
+type CaseFixture struct {
+	Name   string                 `yaml:"name"`
+	Params map[string]interface{} `yaml:"params"`
+	Expect ExpectFixture          `yaml:"expect"`
+}
+
+// Return は Prosl の value operator として評価された値と Hash で比較される。
+// ErrCode は proskenion.ErrCode の名前(ex. Assertation)。
+type ExpectFixture struct {
+	Return  interface{} `yaml:"return"`
+	ErrCode string      `yaml:"err_code"`
+}
+
+type Result struct {
+	Name string
+	Err  error
+}
+
+func (r *Result) Passed() bool {
+	return r.Err == nil
+}
+
+type ProslTester struct {
+	fc   model.ModelFactory
+	c    core.Cryptor
+	conf *config.Config
+}
+
+func NewProslTester(fc model.ModelFactory, c core.Cryptor, conf *config.Config) *ProslTester {
+	return &ProslTester{fc, c, conf}
+}
+
+func fixtureError(format string, a ...interface{}) error {
+	return errors.Wrapf(ErrProslTestFixture, format, a...)
+}
+
+// RunFile は filename の fixture を読み込み全ての case を実行する。
+// prosl にパスが指定された場合は fixture からの相対パスとして扱う。
+func (t *ProslTester) RunFile(filename string) ([]*Result, error) {
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	fixture := &Fixture{}
+	if err := yaml.Unmarshal(buf, fixture); err != nil {
+		return nil, fixtureError(err.Error())
+	}
+	if path, ok := fixture.Prosl.(string); ok && !filepath.IsAbs(path) {
+		fixture.Prosl = filepath.Join(filepath.Dir(filename), path)
+	}
+	return t.Run(fixture)
+}
+
+func (t *ProslTester) Run(fixture *Fixture) ([]*Result, error) {
+	pr, err := t.loadProsl(fixture.Prosl)
+	if err != nil {
+		return nil, err
+	}
+	top, err := t.buildTop(fixture.Top)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*Result, 0, len(fixture.Cases))
+	for i, cs := range fixture.Cases {
+		name := cs.Name
+		if name == "" {
+			name = fmt.Sprintf("case-%d", i)
+		}
+		ret = append(ret, &Result{name, t.runCase(fixture, pr, top, cs)})
+	}
+	return ret, nil
+}
+
+func (t *ProslTester) loadProsl(yalist interface{}) (*proskenion.Prosl, error) {
+	switch v := yalist.(type) {
+	case string:
+		buf, err := ioutil.ReadFile(v)
+		if err != nil {
+			return nil, err
+		}
+		return prosl.ConvertYamlToProtobuf(buf)
+	case []interface{}:
+		pr, err := prosl.ParseProsl(v)
+		if err != nil {
+			return nil, errors.Wrap(prosl.ErrConvertMapToProtobuf, err.Error())
+		}
+		return pr, nil
+	}
+	return nil, fixtureError("prosl must be file path or prosl list: %#v", yalist)
+}
+
+func (t *ProslTester) buildTop(top *BlockFixture) (model.Block, error) {
+	if top == nil {
+		return nil, nil
+	}
+	preHash, err := decodeHex(top.PreHash)
+	if err != nil {
+		return nil, fixtureError("top pre_block_hash: %s", err.Error())
+	}
+	return t.fc.NewBlockBuilder().
+		Height(top.Height).
+		CreatedTime(top.CreatedTime).
+		Round(top.Round).
+		PreBlockHash(preHash).
+		Build(), nil
+}
+
+func decodeHex(s string) ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(s, "0x"))
+}
+
+// evalValue は yaml の値を Prosl の value operator として評価する。
+func (t *ProslTester) evalValue(wsv model.ObjectFinder, top model.Block, value interface{}) (model.Object, error) {
+	op, err := prosl.ParseValueOperator(value)
+	if err != nil {
+		return nil, err
+	}
+	state := prosl.ExecuteProslValueOperator(op, prosl.InitProslStateValue(t.fc, wsv, top, t.c, t.conf))
+	if state.Err != nil {
+		return nil, state.Err
+	}
+	return state.ReturnObject, nil
+}
+
+func (t *ProslTester) buildWSV(fixture *Fixture, top model.Block) (core.WSV, error) {
+	dtx, err := dba.NewDBAOnMemory().Begin()
+	if err != nil {
+		return nil, err
+	}
+	wsv, err := repository.NewWSV(dtx, t.c, t.fc, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range fixture.Peers {
+		pub, err := decodeHex(p.PublicKey)
+		if err != nil {
+			return nil, fixtureError("peer %s public_key: %s", p.Id, err.Error())
+		}
+		id, err := model.NewAddress(p.Id)
+		if err != nil {
+			return nil, fixtureError("peer id %s: %s", p.Id, err.Error())
+		}
+		peer := t.fc.NewPeer(id.Account()+"@"+id.Domain(), p.Address, pub)
+		if p.Active {
+			peer.Activate()
+		}
+		if p.Ban {
+			peer.Ban()
+		}
+		if err := wsv.Append(model.MustAddress(id.PeerId()), peer); err != nil {
+			return nil, err
+		}
+	}
+	for _, a := range fixture.Accounts {
+		id, err := model.NewAddress(a.Id)
+		if err != nil {
+			return nil, fixtureError("account id %s: %s", a.Id, err.Error())
+		}
+		keys := make([]model.PublicKey, 0, len(a.PublicKeys))
+		for _, k := range a.PublicKeys {
+			pub, err := decodeHex(k)
+			if err != nil {
+				return nil, fixtureError("account %s public_keys: %s", a.Id, err.Error())
+			}
+			keys = append(keys, pub)
+		}
+		name := a.Name
+		if name == "" {
+			name = id.Account()
+		}
+		ac := t.fc.NewAccount(id.Account()+"@"+id.Domain(), name, keys, a.Quorum, a.Balance, a.DelegatePeerId)
+		if err := wsv.Append(model.MustAddress(id.AccountId()), ac); err != nil {
+			return nil, err
+		}
+	}
+	for _, s := range fixture.Storages {
+		id, err := model.NewAddress(s.Id)
+		if err != nil {
+			return nil, fixtureError("storage id %s: %s", s.Id, err.Error())
+		}
+		objects := make(map[string]model.Object)
+		for key, value := range s.Object {
+			o, err := t.evalValue(wsv, top, value)
+			if err != nil {
+				return nil, fixtureError("storage %s[%s]: %s", s.Id, key, err.Error())
+			}
+			objects[key] = o
+		}
+		st := t.fc.NewStorageBuilder().FromMap(objects).Id(s.Id).Build()
+		if err := wsv.Append(id, st); err != nil {
+			return nil, err
+		}
+	}
+	return wsv, nil
+}
+
+func (t *ProslTester) runCase(fixture *Fixture, pr *proskenion.Prosl, top model.Block, cs CaseFixture) error {
+	wsv, err := t.buildWSV(fixture, top)
+	if err != nil {
+		return err
+	}
+	defer wsv.Rollback()
+
+	params := make(map[string]model.Object)
+	for key, value := range cs.Params {
+		o, err := t.evalValue(wsv, top, value)
+		if err != nil {
+			return fixtureError("params[%s]: %s", key, err.Error())
+		}
+		params[key] = o
+	}
+	state := prosl.ExecuteProsl(pr, prosl.InitProslStateValueWithPrams(t.fc, wsv, top, t.c, t.conf, params))
+
+	if cs.Expect.ErrCode != "" {
+		code, ok := proskenion.ErrCode_value[cs.Expect.ErrCode]
+		if !ok {
+			return fixtureError("unknown err_code: %s", cs.Expect.ErrCode)
+		}
+		if state.Err == nil {
+			return errors.Wrapf(ErrProslTestUnexpectedExecuted, "expected err_code: %s, return: %+v",
+				cs.Expect.ErrCode, state.ReturnObject)
+		}
+		if state.ErrCode != proskenion.ErrCode(code) {
+			return errors.Wrapf(ErrProslTestUnexpectedErrCode, "expected: %s, actual: %s, %s",
+				cs.Expect.ErrCode, state.ErrCode.String(), state.Err.Error())
+		}
+		return nil
+	}
+	if state.Err != nil {
+		return errors.Wrapf(ErrProslTestUnexpectedErrCode, "expected no error, actual: %s, %s",
+			state.ErrCode.String(), state.Err.Error())
+	}
+	if cs.Expect.Return == nil {
+		return nil
+	}
+	exp, err := t.evalValue(wsv, top, cs.Expect.Return)
+	if err != nil {
+		return fixtureError("expect return: %s", err.Error())
+	}
+	if state.ReturnObject == nil || !bytes.Equal(exp.Hash(), state.ReturnObject.Hash()) {
+		return errors.Wrapf(ErrProslTestUnexpectedReturn, "expected: %+v, actual: %+v", exp, state.ReturnObject)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package tester_test
+
+import (
+	"github.com/proskenion/proskenion/config"
+	. "github.com/proskenion/proskenion/prosl/tester"
+	. "github.com/proskenion/proskenion/test_utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func newTester() *ProslTester {
+	return NewProslTester(RandomFactory(), RandomCryptor(), config.NewConfig("../../config/config.yaml"))
+}
+
+func TestProslTester_RunFile(t *testing.T) {
+	results, err := newTester().RunFile("./test_yaml/richest_test.yaml")
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	for _, res := range results {
+		assert.True(t, res.Passed(), "%s: %v", res.Name, res.Err)
+	}
+}
+
+func TestProslTester_RunFailed(t *testing.T) {
+	returnOne := []interface{}{
+		map[interface{}]interface{}{"return": 1},
+	}
+	// Assertation の error code で失敗する prosl
+	assertFailed := []interface{}{
+		map[interface{}]interface{}{"assert": map[interface{}]interface{}{"eq": []interface{}{1, 2}}},
+		map[interface{}]interface{}{"return": 1},
+	}
+	for _, c := range []struct {
+		name   string
+		prosl  []interface{}
+		expect ExpectFixture
+		err    error
+	}{
+		{
+			"unexpected return",
+			returnOne,
+			ExpectFixture{Return: 2},
+			ErrProslTestUnexpectedReturn,
+		},
+		{
+			"expected err code but executed",
+			returnOne,
+			ExpectFixture{ErrCode: "Assertation"},
+			ErrProslTestUnexpectedExecuted,
+		},
+		{
+			"unexpected err code",
+			assertFailed,
+			ExpectFixture{ErrCode: "Type"},
+			ErrProslTestUnexpectedErrCode,
+		},
+		{
+			"unknown err code",
+			returnOne,
+			ExpectFixture{ErrCode: "Unknown"},
+			ErrProslTestFixture,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			fixture := &Fixture{
+				Prosl: c.prosl,
+				Cases: []CaseFixture{{Name: c.name, Expect: c.expect}},
+			}
+			results, err := newTester().Run(fixture)
+			require.NoError(t, err)
+			require.Len(t, results, 1)
+			assert.False(t, results[0].Passed())
+			assert.Contains(t, results[0].Err.Error(), c.err.Error())
+		})
+	}
+
+	// 期待した error code で失敗すれば成功
+	results, err := newTester().Run(&Fixture{
+		Prosl: assertFailed,
+		Cases: []CaseFixture{{Name: "expected err code", Expect: ExpectFixture{ErrCode: "Assertation"}}},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Passed(), "%v", results[0].Err)
+}
+
+func TestProslTester_RunInvalidFixture(t *testing.T) {
+	_, err := newTester().Run(&Fixture{Prosl: 1})
+	assert.Error(t, err)
+}
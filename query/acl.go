@@ -0,0 +1,97 @@
+package query
+
+import (
+	"github.com/pkg/errors"
+	"github.com/proskenion/proskenion/core"
+	"github.com/proskenion/proskenion/core/model"
+)
+
+// ValidateRead は fromId の Storage 定義に read_acl があれば、authorizerId がいずれかの規則を満たすか検証する。
+// read_acl は以下の key を持つ Dict で、定義されていない Storage は誰でも読み込める。
+// owner: fromId の Account 自身, domain: fromId と同じ Domain の Account,
+// accounts: 許可する AccountId の List, roles: fromId の Domain に対して付与された Role の List
+func (q *QueryValidator) ValidateRead(wsv model.ObjectFinder, authorizerId string, fromId string) error {
+	id, err := model.NewAddress(fromId)
+	if err != nil || id.Storage() == "" {
+		// Block や Transaction の Query は対象外
+		return nil
+	}
+	defSt := q.fc.NewEmptyStorage()
+	if err := wsv.Query(model.MustAddress("/"+id.Storage()), defSt); err != nil {
+		// 定義されていない Storage は誰でも読み込める
+		if errors.Cause(err) == core.ErrWSVNotFound {
+			return nil
+		}
+		return err
+	}
+	acl := defSt.GetFromKey(core.ReadAclKey)
+	if acl == nil || acl.GetType() != model.DictObjectCode {
+		return nil
+	}
+	authorizer, err := model.NewAddress(authorizerId)
+	if err != nil || !q.allowRead(wsv, acl.GetDict(), authorizer, id) {
+		return errors.Wrapf(core.ErrQueryProcessorReadDenied,
+			"authorizer : %s, from : %s", authorizerId, fromId)
+	}
+	return nil
+}
+
+func (q *QueryValidator) allowRead(wsv model.ObjectFinder, rules map[string]model.Object,
+	authorizer model.Address, id model.Address) bool {
+	if o, ok := rules[core.ReadAclOwnerKey]; ok && o.GetBoolean() {
+		// Range 検索は他の Account の値を含むため owner では許可しない
+		if id.Account() != "" && id.AccountId() == authorizer.AccountId() {
+			return true
+		}
+	}
+	if o, ok := rules[core.ReadAclDomainKey]; ok && o.GetBoolean() {
+		if id.Domain() == authorizer.Domain() {
+			return true
+		}
+	}
+	if o, ok := rules[core.ReadAclAccountsKey]; ok {
+		for _, ac := range o.GetList() {
+			if acId, err := model.NewAddress(objectAddress(ac)); err == nil &&
+				acId.AccountId() == authorizer.AccountId() {
+				return true
+			}
+		}
+	}
+	if o, ok := rules[core.ReadAclRolesKey]; ok && len(o.GetList()) > 0 {
+		granted := q.grantedDomains(wsv, authorizer)
+		for _, role := range o.GetList() {
+			for _, domain := range granted[role.GetStr()] {
+				if core.MatchDomain(domain, id.Domain()) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// grantedDomains は Account の permission Storage に保存された権限(Role)毎の Domain を返す
+func (q *QueryValidator) grantedDomains(wsv model.ObjectFinder, ac model.Address) map[string][]string {
+	ret := make(map[string][]string)
+	permId, err := model.NewAddress(ac.Account() + "@" + ac.Domain() + "/" + core.PermissionStorageName)
+	if err != nil {
+		return ret
+	}
+	st := q.fc.NewEmptyStorage()
+	if err := wsv.Query(permId, st); err != nil {
+		return ret
+	}
+	for name, o := range st.GetObject() {
+		for _, domain := range o.GetList() {
+			ret[name] = append(ret[name], domain.GetStr())
+		}
+	}
+	return ret
+}
+
+func objectAddress(o model.Object) string {
+	if o.GetType() == model.StringObjectCode {
+		return o.GetStr()
+	}
+	return o.GetAddress()
+}
@@ -0,0 +1,217 @@
+package query
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/proskenion/proskenion/core"
+	"github.com/proskenion/proskenion/core/model"
+	"math"
+	"sort"
+	"strings"
+)
+
+// DomainGroupBy は Object の id の domain で分類する groupBy
+const DomainGroupBy = "domain"
+
+// GroupKey は groupBy を指定した集計結果で分類した key の値を保持する key
+const GroupKey = "key"
+
+// aggregateName は集計結果の Dict の key。count(key) の key が空なら count
+func aggregateName(a model.Aggregate) string {
+	var name string
+	switch a.GetCode() {
+	case model.CountAggregate:
+		name = "count"
+	case model.SumAggregate:
+		name = "sum"
+	case model.MinAggregate:
+		name = "min"
+	case model.MaxAggregate:
+		name = "max"
+	default:
+		name = fmt.Sprintf("aggregate%d", a.GetCode())
+	}
+	if a.GetKey() == "" {
+		return name
+	}
+	return fmt.Sprintf("%s(%s)", name, a.GetKey())
+}
+
+func validAggregate(a model.Aggregate) error {
+	switch a.GetCode() {
+	case model.CountAggregate:
+		return nil
+	case model.SumAggregate, model.MinAggregate, model.MaxAggregate:
+		if a.GetKey() == "" {
+			return fmt.Errorf("%s requires key", aggregateName(a))
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown aggregate code: %d", a.GetCode())
+}
+
+// objectInt64 は整数の Object の値を返す。int64 で表せない uint64 は error を返す
+func objectInt64(o model.Object) (int64, bool, error) {
+	switch o.GetType() {
+	case model.Int32ObjectCode:
+		return int64(o.GetI32()), true, nil
+	case model.Int64ObjectCode:
+		return o.GetI64(), true, nil
+	case model.Uint32ObjectCode:
+		return int64(o.GetU32()), true, nil
+	case model.Uint64ObjectCode:
+		if o.GetU64() > math.MaxInt64 {
+			return 0, false, fmt.Errorf("%d overflows int64", o.GetU64())
+		}
+		return int64(o.GetU64()), true, nil
+	}
+	return 0, false, nil
+}
+
+// addInt64 は a + b を返す。int64 で表せない時は error を返す
+func addInt64(a, b int64) (int64, error) {
+	if (b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b) {
+		return 0, fmt.Errorf("%d + %d overflows int64", a, b)
+	}
+	return a + b, nil
+}
+
+// aggregator は 1 つの group の集計途中の値
+type aggregator struct {
+	key    model.Object
+	counts []int64
+	sums   []int64
+	values []model.Object
+}
+
+func newAggregator(key model.Object, n int) *aggregator {
+	return &aggregator{key, make([]int64, n), make([]int64, n), make([]model.Object, n)}
+}
+
+// add は Object を集計に加える。key が無い、または型が合わない値は無視する。
+// sum が int64 で表せない時は ErrQueryProcessorInvalidAggregate を返す
+func (g *aggregator) add(aggs []model.Aggregate, o model.Object) error {
+	for i, a := range aggs {
+		if a.GetKey() == "" {
+			g.counts[i]++
+			continue
+		}
+		v := resolvePath(o, strings.Split(a.GetKey(), "."))
+		if v == nil {
+			continue
+		}
+		switch a.GetCode() {
+		case model.CountAggregate:
+			g.counts[i]++
+		case model.SumAggregate:
+			n, ok, err := objectInt64(v)
+			if err != nil {
+				return errors.Wrapf(core.ErrQueryProcessorInvalidAggregate, "%s: %s", aggregateName(a), err.Error())
+			}
+			if !ok {
+				continue
+			}
+			sum, err := addInt64(g.sums[i], n)
+			if err != nil {
+				return errors.Wrapf(core.ErrQueryProcessorInvalidAggregate, "%s: %s", aggregateName(a), err.Error())
+			}
+			g.sums[i] = sum
+		case model.MinAggregate, model.MaxAggregate:
+			cur := g.values[i]
+			if cur == nil {
+				g.values[i] = v
+			} else if cur.GetType() == v.GetType() {
+				if (a.GetCode() == model.MinAggregate) == model.ObjectLess(v, cur) {
+					g.values[i] = v
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (g *aggregator) result(fc model.ObjectFactory, aggs []model.Aggregate) map[string]model.Object {
+	ret := make(map[string]model.Object)
+	if g.key != nil {
+		ret[GroupKey] = g.key
+	}
+	for i, a := range aggs {
+		switch a.GetCode() {
+		case model.CountAggregate:
+			ret[aggregateName(a)] = fc.NewObjectBuilder().Int64(g.counts[i])
+		case model.SumAggregate:
+			ret[aggregateName(a)] = fc.NewObjectBuilder().Int64(g.sums[i])
+		case model.MinAggregate, model.MaxAggregate:
+			if g.values[i] != nil {
+				ret[aggregateName(a)] = g.values[i]
+			}
+		}
+	}
+	return ret
+}
+
+// groupKey は Object を分類する key の値を返す。存在しなければ nil
+func (q *QueryProcessor) groupKey(o model.Object, groupBy string) model.Object {
+	if groupBy != DomainGroupBy {
+		return resolvePath(o, strings.Split(groupBy, "."))
+	}
+	var id string
+	switch o.GetType() {
+	case model.AccountObjectCode:
+		id = o.GetAccount().GetAccountId()
+	case model.PeerObjectCode:
+		id = o.GetPeer().GetPeerId()
+	case model.StorageObjectCode:
+		id = o.GetStorage().GetId()
+	}
+	address, err := model.NewAddress(id)
+	if err != nil {
+		return nil
+	}
+	return q.fc.NewObjectBuilder().Str(address.Domain())
+}
+
+// aggregate は Object を集計する。
+// groupBy が空なら集計結果の Dict を、そうでなければ key と集計結果の Dict を key 順に並べた List を返す。
+// groupBy の key を持たない Object は集計しない
+func (q *QueryProcessor) aggregate(obs []model.Object, aggs []model.Aggregate, groupBy string) (model.Object, error) {
+	if groupBy == "" {
+		g := newAggregator(nil, len(aggs))
+		for _, o := range obs {
+			if err := g.add(aggs, o); err != nil {
+				return nil, err
+			}
+		}
+		return q.fc.NewObjectBuilder().Dict(g.result(q.fc, aggs)), nil
+	}
+
+	groups := make(map[string]*aggregator)
+	for _, o := range obs {
+		key := q.groupKey(o, groupBy)
+		if key == nil {
+			continue
+		}
+		h := string(key.Hash())
+		if _, ok := groups[h]; !ok {
+			groups[h] = newAggregator(key, len(aggs))
+		}
+		if err := groups[h].add(aggs, o); err != nil {
+			return nil, err
+		}
+	}
+	gs := make([]*aggregator, 0, len(groups))
+	for _, g := range groups {
+		gs = append(gs, g)
+	}
+	sort.Slice(gs, func(i, j int) bool {
+		if gs[i].key.GetType() == gs[j].key.GetType() {
+			return model.ObjectLess(gs[i].key, gs[j].key)
+		}
+		return model.HasherLess(gs[i].key, gs[j].key)
+	})
+	rets := make([]model.Object, 0, len(gs))
+	for _, g := range gs {
+		rets = append(rets, q.fc.NewObjectBuilder().Dict(g.result(q.fc, aggs)))
+	}
+	return q.fc.NewObjectBuilder().List(rets), nil
+}
@@ -19,6 +19,19 @@ func NewQueryProcessor(fc model.ModelFactory, conf *config.Config) core.QueryPro
 }
 
 func (q *QueryProcessor) Query(wsv model.ObjectFinder, query model.Query) (model.QueryResponse, error) {
+	ret, err := q.query(wsv, query)
+	if err != nil {
+		return nil, err
+	}
+	return ret, q.signedResponse(ret)
+}
+
+func (q *QueryProcessor) QueryUnsigned(wsv model.ObjectFinder, query model.Query) (model.QueryResponse, error) {
+	return q.query(wsv, query)
+}
+
+// query は Query を処理し、署名していない QueryResponse を返す
+func (q *QueryProcessor) query(wsv model.ObjectFinder, query model.Query) (model.QueryResponse, error) {
 	id := model.MustAddress(query.GetPayload().GetFromId())
 	var object model.Object
 	if id.Type() == model.WallettAddressType || query.GetPayload().GetRequestCode() != model.ListObjectCode {
@@ -42,7 +55,16 @@ func (q *QueryProcessor) Query(wsv model.ObjectFinder, query model.Query) (model
 			}
 			object = q.selectStorage(storage, query)
 		}
+	} else if cursor := query.GetPayload().GetCursor(); cursor != nil { // Cursor による Range 検索
+		return q.queryPage(wsv, query, cursor)
 	} else { // Range 検索
+		where, err := parseWhere(query.GetPayload().GetWhere())
+		if err != nil {
+			return nil, errors.Wrap(core.ErrQueryProcessorInvalidWhere, err.Error())
+		}
+		if len(query.GetPayload().GetAggregates()) > 0 {
+			return q.queryAggregate(wsv, query, where)
+		}
 		obs := make([]model.Object, 0)
 		switch id.Storage() {
 		case model.AccountStorageName:
@@ -50,7 +72,7 @@ func (q *QueryProcessor) Query(wsv model.ObjectFinder, query model.Query) (model
 			if err != nil {
 				return nil, err
 			}
-			acs = q.limitAccounts(q.orderAccounts(q.whereAccounts(acs, query), query), query)
+			acs = q.limitAccounts(q.orderAccounts(q.whereAccounts(acs, where), query), query)
 			for _, ac := range acs {
 				obs = append(obs, q.selectAccount(ac, query))
 			}
@@ -59,7 +81,7 @@ func (q *QueryProcessor) Query(wsv model.ObjectFinder, query model.Query) (model
 			if err != nil {
 				return nil, err
 			}
-			peers = q.limitPeers(q.orderPeers(q.wherePeers(peers, query), query), query)
+			peers = q.limitPeers(q.orderPeers(q.wherePeers(peers, where), query), query)
 			for _, peer := range peers {
 				obs = append(obs, q.selectPeer(peer, query))
 			}
@@ -68,7 +90,7 @@ func (q *QueryProcessor) Query(wsv model.ObjectFinder, query model.Query) (model
 			if err != nil {
 				return nil, err
 			}
-			storages = q.limitStorages(q.orderStorages(q.whereStorages(storages, query), query), query)
+			storages = q.limitStorages(q.orderStorages(q.whereStorages(storages, where), query), query)
 			for _, storage := range storages {
 				obs = append(obs, q.selectStorage(storage, query))
 			}
@@ -76,12 +98,204 @@ func (q *QueryProcessor) Query(wsv model.ObjectFinder, query model.Query) (model
 		object = q.fc.NewObjectBuilder().List(obs)
 	}
 	ret := q.fc.NewQueryResponseBuilder().Object(object).Build()
-	if err := q.signedResponse(ret); err != nil {
+	return ret, nil
+}
+
+// QueryChain は Block と Transaction を取得する
+// BlockObjectCode : at (指定しなければ top) の Block。select が "transactions" ならその Block の Transaction の List
+// TransactionObjectCode : txHash の Transaction
+func (q *QueryProcessor) QueryChain(bc core.Blockchain, txHistory core.TxHistory, top model.Block, query model.Query) (model.QueryResponse, error) {
+	ret, err := q.queryChain(bc, txHistory, top, query)
+	if err != nil {
+		return nil, err
+	}
+	return ret, q.signedResponse(ret)
+}
+
+func (q *QueryProcessor) QueryChainUnsigned(bc core.Blockchain, txHistory core.TxHistory, top model.Block, query model.Query) (model.QueryResponse, error) {
+	return q.queryChain(bc, txHistory, top, query)
+}
+
+func (q *QueryProcessor) queryChain(bc core.Blockchain, txHistory core.TxHistory, top model.Block, query model.Query) (model.QueryResponse, error) {
+	qp := query.GetPayload()
+	var object model.Object
+	switch qp.GetRequestCode() {
+	case model.BlockObjectCode:
+		block, err := q.blockQuery(bc, top, qp.GetAt())
+		if err != nil {
+			return nil, err
+		}
+		if qp.GetSelect() == TransactionsSelect {
+			txList, err := txHistory.GetTxList(block.GetPayload().GetTxListHash())
+			if err != nil {
+				return nil, errors.Wrap(core.ErrQueryProcessorNotFound, err.Error())
+			}
+			obs := make([]model.Object, 0, txList.Size())
+			for _, tx := range txList.List() {
+				obs = append(obs, q.fc.NewObjectBuilder().Transaction(tx))
+			}
+			object = q.fc.NewObjectBuilder().List(obs)
+		} else {
+			object = q.selectBlock(block, query)
+		}
+	case model.TransactionObjectCode:
+		tx, err := txHistory.GetTx(qp.GetTxHash())
+		if err != nil {
+			return nil, errors.Wrap(core.ErrQueryProcessorNotFound, err.Error())
+		}
+		object = q.fc.NewObjectBuilder().Transaction(tx)
+	default:
+		return nil, errors.Wrapf(core.ErrQueryProcessorQueryObjectCodeNotImplemented,
+			"request code : %d", qp.GetRequestCode())
+	}
+	ret := q.fc.NewQueryResponseBuilder().Object(object).Build()
+	return ret, nil
+}
+
+// QueryChanges は fromId 以下で preWSVHash の WSV から追加・更新された Account, Peer, Storage の Object を返す
+func (q *QueryProcessor) QueryChanges(wsv core.WSV, preWSVHash model.Hash, fromId model.Address) ([]model.Object, error) {
+	rets, err := wsv.QueryChanges(fromId, preWSVHash, q.unmarshalerFactory(fromId))
+	if err != nil {
+		return nil, err
+	}
+	obs := make([]model.Object, 0, len(rets))
+	for _, r := range rets {
+		switch v := r.(type) {
+		case model.Account:
+			obs = append(obs, q.fc.NewObjectBuilder().Account(v))
+		case model.Peer:
+			obs = append(obs, q.fc.NewObjectBuilder().Peer(v))
+		case model.Storage:
+			obs = append(obs, q.fc.NewObjectBuilder().Storage(v))
+		}
+	}
+	return obs, nil
+}
+
+// TransactionsSelect は Block の Transaction の List を取得する select
+const TransactionsSelect = "transactions"
+
+func (q *QueryProcessor) blockQuery(bc core.Blockchain, top model.Block, at model.QueryAt) (model.Block, error) {
+	if at == nil {
+		return top, nil
+	}
+	var block model.Block
+	var err error
+	if at.GetBlockHash() != nil {
+		block, err = bc.Get(at.GetBlockHash())
+	} else {
+		block, err = bc.GetByHeight(at.GetHeight())
+	}
+	if err != nil {
+		return nil, errors.Wrap(core.ErrQueryProcessorNotFound, err.Error())
+	}
+	return block, nil
+}
+
+func (q *QueryProcessor) selectBlock(block model.Block, query model.Query) model.Object {
+	return q.selectObject(q.fc.NewObjectBuilder().Block(block), query.GetPayload().GetSelect())
+}
+
+// queryPage は fromId 以下の Object を key 順に cursor の次から where に一致するものを limit 個取得する。
+// limit 個取得できた場合は、最後に取得した Object の key と wsv の Block の hash を nextCursor として返す
+func (q *QueryProcessor) queryPage(wsv model.ObjectFinder, query model.Query, cursor model.Cursor) (model.QueryResponse, error) {
+	qp := query.GetPayload()
+	where, err := parseWhere(qp.GetWhere())
+	if err != nil {
+		return nil, errors.Wrap(core.ErrQueryProcessorInvalidWhere, err.Error())
+	}
+	limit := int(qp.GetLimit())
+	if limit <= 0 {
+		return nil, errors.Wrapf(core.ErrQueryProcessorInvalidCursor, "limit: %d", limit)
+	}
+	id := model.MustAddress(qp.GetFromId())
+	ufc := q.unmarshalerFactory(id)
+
+	obs := make([]model.Object, 0, limit)
+	lastKey := cursor.GetLastKey()
+	for len(obs) < limit {
+		n := limit - len(obs)
+		res, key, err := wsv.QueryPage(id, lastKey, cursor.GetOrder(), n, ufc)
+		if err != nil {
+			return nil, errors.Wrap(core.ErrQueryProcessorNotFound, err.Error())
+		}
+		for _, r := range res {
+			ob, selected := q.unmarshaledObject(r, query)
+			if where == nil || where.eval(ob) {
+				obs = append(obs, selected)
+			}
+		}
+		if len(res) < n {
+			lastKey = nil
+			break
+		}
+		lastKey = key
+	}
+
+	builder := q.fc.NewQueryResponseBuilder().Object(q.fc.NewObjectBuilder().List(obs))
+	if lastKey != nil {
+		blockHash := cursor.GetBlockHash()
+		if f, ok := wsv.(model.ReadingBlockFinder); ok {
+			blockHash = f.ReadingBlockHash()
+		}
+		builder = builder.NextCursor(lastKey, cursor.GetOrder(), blockHash)
+	}
+	ret := builder.Build()
+	return ret, nil
+}
+
+// queryAggregate は fromId 以下の where に一致する Object を aggregates で集計する
+func (q *QueryProcessor) queryAggregate(wsv model.ObjectFinder, query model.Query, where whereExpr) (model.QueryResponse, error) {
+	qp := query.GetPayload()
+	for _, a := range qp.GetAggregates() {
+		if err := validAggregate(a); err != nil {
+			return nil, errors.Wrap(core.ErrQueryProcessorInvalidAggregate, err.Error())
+		}
+	}
+	id := model.MustAddress(qp.GetFromId())
+	res, err := wsv.QueryAll(id, q.unmarshalerFactory(id))
+	if err != nil {
+		return nil, errors.Wrap(core.ErrQueryProcessorNotFound, err.Error())
+	}
+	obs := make([]model.Object, 0, len(res))
+	for _, r := range res {
+		ob, _ := q.unmarshaledObject(r, query)
+		if where == nil || where.eval(ob) {
+			obs = append(obs, ob)
+		}
+	}
+
+	ob, err := q.aggregate(obs, qp.GetAggregates(), qp.GetGroupBy())
+	if err != nil {
 		return nil, err
 	}
+	ret := q.fc.NewQueryResponseBuilder().Object(ob).Build()
 	return ret, nil
 }
 
+func (q *QueryProcessor) unmarshalerFactory(id model.Address) model.UnmarshalerFactory {
+	switch id.Storage() {
+	case model.AccountStorageName:
+		return model.NewAccountUnmarshalerFactory(q.fc)
+	case model.PeerStorageName:
+		return model.NewPeerUnmarshalerFactory(q.fc)
+	}
+	return model.NewStorageUnmarshalerFactory(q.fc)
+}
+
+// unmarshaledObject は WSV から取得した値の Object と select した Object を返す
+func (q *QueryProcessor) unmarshaledObject(r model.Unmarshaler, query model.Query) (model.Object, model.Object) {
+	switch v := r.(type) {
+	case model.Account:
+		return q.fc.NewObjectBuilder().Account(v), q.selectAccount(v, query)
+	case model.Peer:
+		return q.fc.NewObjectBuilder().Peer(v), q.selectPeer(v, query)
+	case model.Storage:
+		return q.fc.NewObjectBuilder().Storage(v), q.selectStorage(v, query)
+	}
+	return nil, nil
+}
+
 func (q *QueryProcessor) accountObjectQuery(qp model.QueryPayload, wsv model.ObjectFinder) (model.Account, error) {
 	ac := q.fc.NewEmptyAccount()
 	err := wsv.Query(model.MustAddress(qp.GetFromId()), ac)
@@ -110,33 +324,15 @@ func (q *QueryProcessor) storageObjectQuery(qp model.QueryPayload, wsv model.Obj
 }
 
 func (q *QueryProcessor) selectAccount(ac model.Account, query model.Query) model.Object {
-	builder := q.fc.NewObjectBuilder()
-	if query.GetPayload().GetSelect() != "*" {
-		ret := ac.GetFromKey(query.GetPayload().GetSelect())
-		if ret.GetType() != model.AnythingObjectCode {
-			return ret
-		}
-	}
-	return builder.Account(ac)
+	return q.selectObject(q.fc.NewObjectBuilder().Account(ac), query.GetPayload().GetSelect())
 }
 
 func (q *QueryProcessor) selectPeer(peer model.Peer, query model.Query) model.Object {
-	builder := q.fc.NewObjectBuilder()
-	if query.GetPayload().GetSelect() != "*" {
-		ret := peer.GetFromKey(query.GetPayload().GetSelect())
-		if ret.GetType() != model.AnythingObjectCode {
-			return ret
-		}
-	}
-	return builder.Peer(peer)
+	return q.selectObject(q.fc.NewObjectBuilder().Peer(peer), query.GetPayload().GetSelect())
 }
 
 func (q *QueryProcessor) selectStorage(storage model.Storage, query model.Query) model.Object {
-	builder := q.fc.NewObjectBuilder()
-	if ret, ok := storage.GetObject()[query.GetPayload().GetSelect()]; ok {
-		return ret
-	}
-	return builder.Storage(storage)
+	return q.selectObject(q.fc.NewObjectBuilder().Storage(storage), query.GetPayload().GetSelect())
 }
 
 func (q *QueryProcessor) accountObjectQueryRange(qp model.QueryPayload, wsv model.ObjectFinder) ([]model.Account, error) {
@@ -175,16 +371,43 @@ func (q *QueryProcessor) storageObjectQueryRange(qp model.QueryPayload, wsv mode
 	return storages, nil
 }
 
-func (q *QueryProcessor) whereAccounts(acs []model.Account, query model.Query) []model.Account {
-	return acs
+func (q *QueryProcessor) whereAccounts(acs []model.Account, where whereExpr) []model.Account {
+	if where == nil {
+		return acs
+	}
+	ret := make([]model.Account, 0, len(acs))
+	for _, ac := range acs {
+		if where.eval(q.fc.NewObjectBuilder().Account(ac)) {
+			ret = append(ret, ac)
+		}
+	}
+	return ret
 }
 
-func (q *QueryProcessor) wherePeers(peers []model.Peer, query model.Query) []model.Peer {
-	return peers
+func (q *QueryProcessor) wherePeers(peers []model.Peer, where whereExpr) []model.Peer {
+	if where == nil {
+		return peers
+	}
+	ret := make([]model.Peer, 0, len(peers))
+	for _, peer := range peers {
+		if where.eval(q.fc.NewObjectBuilder().Peer(peer)) {
+			ret = append(ret, peer)
+		}
+	}
+	return ret
 }
 
-func (q *QueryProcessor) whereStorages(storages []model.Storage, query model.Query) []model.Storage {
-	return storages
+func (q *QueryProcessor) whereStorages(storages []model.Storage, where whereExpr) []model.Storage {
+	if where == nil {
+		return storages
+	}
+	ret := make([]model.Storage, 0, len(storages))
+	for _, storage := range storages {
+		if where.eval(q.fc.NewObjectBuilder().Storage(storage)) {
+			ret = append(ret, storage)
+		}
+	}
+	return ret
 }
 
 type Accounts struct {
@@ -319,6 +542,21 @@ func (q *QueryProcessor) limitStorages(storages []model.Storage, query model.Que
 	return storages[:query.GetPayload().GetLimit()]
 }
 
+// BatchResponse は i 番目の Query の結果 rets[i] か error errs[i] を並べた BatchQueryResponse に署名して返す
+func (q *QueryProcessor) BatchResponse(top model.Block, rets []model.QueryResponse, errs []error) (model.BatchQueryResponse, error) {
+	builder := q.fc.NewBatchQueryResponseBuilder().
+		BlockHash(top.Hash()).
+		Height(top.GetPayload().GetHeight())
+	for i, ret := range rets {
+		builder = builder.Result(ret, errs[i])
+	}
+	res := builder.Build()
+	if err := res.Sign(q.conf.Peer.PublicKeyBytes(), q.conf.Peer.PrivateKeyBytes()); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
 func (q *QueryProcessor) signedResponse(res model.QueryResponse) error {
 	return res.Sign(q.conf.Peer.PublicKeyBytes(), q.conf.Peer.PrivateKeyBytes())
 }
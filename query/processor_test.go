@@ -1,6 +1,7 @@
 package query_test
 
 import (
+	"fmt"
 	"github.com/pkg/errors"
 	"github.com/proskenion/proskenion/core"
 	"github.com/proskenion/proskenion/core/model"
@@ -9,6 +10,8 @@ import (
 	. "github.com/proskenion/proskenion/test_utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"math"
+	"sort"
 	"testing"
 )
 
@@ -85,3 +88,412 @@ func TestQueryProcessor_Query(t *testing.T) {
 		assert.Equal(t, expctedIds2[i], l.GetAccount().GetAccountId())
 	}
 }
+
+func TestQueryProcessor_QueryWhere(t *testing.T) {
+	fc := RandomFactory()
+	rp := repository.NewRepository(RandomDBA(), RandomCryptor(), fc, RandomConfig())
+	authorizer := NewAccountWithPri("authorizer@com/account")
+	genesisCommit(t, rp, authorizer)
+
+	builder := fc.NewTxBuilder().
+		DefineStorage("root@/root", "/st", fc.NewStorageBuilder().Dict("info", nil).Build())
+	for i := 0; i < 5; i++ {
+		id := fmt.Sprintf("target%d@com", i)
+		builder = builder.
+			AddBalance("root@/root", id, int64(i*10)).
+			CreateStorage("root@/root", id+"/st").
+			UpdateObject("root@/root", id+"/st", "info", fc.NewObjectBuilder().Dict(map[string]model.Object{
+				"level": fc.NewObjectBuilder().Int32(int32(i)),
+				"tags":  fc.NewObjectBuilder().List([]model.Object{fc.NewObjectBuilder().Str(fmt.Sprintf("tag%d", i%2))}),
+			}))
+	}
+	CommitTxWrapBlock(t, rp, fc, builder.Build())
+
+	wsv, err := rp.TopWSV()
+	require.NoError(t, err)
+	defer wsv.Commit()
+	qp := NewQueryProcessor(fc, RandomConfig())
+
+	for _, c := range []struct {
+		name   string
+		fromId string
+		where  string
+		expIds []string
+	}{
+		{
+			"compare",
+			"com/account",
+			"balance >= 20",
+			[]string{"target2@com", "target3@com", "target4@com"},
+		},
+		{
+			"and",
+			"com/account",
+			"balance >= 10 and balance < 30",
+			[]string{"target1@com", "target2@com"},
+		},
+		{
+			"or and not",
+			"com/account",
+			`balance > 100 || !(id contains "target")`,
+			[]string{"authorizer@com"},
+		},
+		{
+			"in",
+			"com/account",
+			"id in ['target0@com', 'target4@com']",
+			[]string{"target0@com", "target4@com"},
+		},
+		{
+			"type mismatch is false",
+			"com/account",
+			"balance == 'a'",
+			[]string{},
+		},
+		{
+			"nested storage",
+			"com/st",
+			"info.level >= 2 and info.tags contains 'tag0'",
+			[]string{"target2@com/st", "target4@com/st"},
+		},
+		{
+			"list index",
+			"com/st",
+			"info.tags.0 == 'tag1'",
+			[]string{"target1@com/st", "target3@com/st"},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			query := fc.NewQueryBuilder().
+				AuthorizerId(authorizer.AccountId).
+				FromId(c.fromId).
+				Select("*").
+				RequestCode(model.ListObjectCode).
+				Where(c.where).
+				OrderBy("id", model.ASC).
+				Limit(100).
+				CreatedTime(RandomNow()).
+				Build()
+			require.NoError(t, NewQueryVerifier().Verify(query))
+			res, err := qp.Query(wsv, query)
+			require.NoError(t, err)
+
+			ids := make([]string, 0)
+			for _, o := range res.GetObject().GetList() {
+				if o.GetType() == model.AccountObjectCode {
+					ids = append(ids, o.GetAccount().GetAccountId())
+				} else {
+					ids = append(ids, o.GetStorage().GetId())
+				}
+			}
+			sort.Strings(ids)
+			assert.Equal(t, c.expIds, ids)
+		})
+	}
+
+	query := fc.NewQueryBuilder().
+		AuthorizerId(authorizer.AccountId).
+		FromId("com/account").
+		Select("*").
+		RequestCode(model.ListObjectCode).
+		Where("balance >=").
+		Build()
+	_, err = qp.Query(wsv, query)
+	assert.EqualError(t, errors.Cause(err), core.ErrQueryProcessorInvalidWhere.Error())
+}
+
+func TestQueryProcessor_QueryCursor(t *testing.T) {
+	fc := RandomFactory()
+	rp := repository.NewRepository(RandomDBA(), RandomCryptor(), fc, RandomConfig())
+	authorizer := NewAccountWithPri("authorizer@com/account")
+	genesisCommit(t, rp, authorizer)
+
+	builder := fc.NewTxBuilder().
+		CreateAccount("root@/root", "target5@com", []model.PublicKey{}, 0).
+		CreateAccount("root@/root", "target6@com", []model.PublicKey{}, 0)
+	for i := 0; i < 7; i++ {
+		builder = builder.AddBalance("root@/root", fmt.Sprintf("target%d@com", i), int64(i*10))
+	}
+	CommitTxWrapBlock(t, rp, fc, builder.Build())
+
+	wsv, err := rp.TopWSV()
+	require.NoError(t, err)
+	defer wsv.Commit()
+	qp := NewQueryProcessor(fc, RandomConfig())
+
+	for _, c := range []struct {
+		name     string
+		where    string
+		order    model.OrderCode
+		limit    int32
+		expPages [][]string
+	}{
+		{
+			"asc",
+			"",
+			model.ASC,
+			3,
+			[][]string{
+				{"authorizer@com", "target0@com", "target1@com"},
+				{"target2@com", "target3@com", "target4@com"},
+				{"target5@com", "target6@com"},
+			},
+		},
+		{
+			"desc",
+			"",
+			model.DESC,
+			3,
+			[][]string{
+				{"target6@com", "target5@com", "target4@com"},
+				{"target3@com", "target2@com", "target1@com"},
+				{"target0@com", "authorizer@com"},
+			},
+		},
+		{
+			"where",
+			"balance >= 30",
+			model.ASC,
+			2,
+			[][]string{
+				{"target3@com", "target4@com"},
+				{"target5@com", "target6@com"},
+				{},
+			},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			var lastKey []byte
+			for i, expIds := range c.expPages {
+				query := fc.NewQueryBuilder().
+					AuthorizerId(authorizer.AccountId).
+					FromId("com/account").
+					Select("id").
+					RequestCode(model.ListObjectCode).
+					Where(c.where).
+					Limit(c.limit).
+					Cursor(lastKey, c.order, nil).
+					CreatedTime(RandomNow()).
+					Build()
+				require.NoError(t, NewQueryVerifier().Verify(query))
+				res, err := qp.Query(wsv, query)
+				require.NoError(t, err)
+
+				ids := make([]string, 0)
+				for _, o := range res.GetObject().GetList() {
+					ids = append(ids, o.GetAddress())
+				}
+				assert.Equal(t, expIds, ids)
+
+				if i == len(c.expPages)-1 {
+					assert.Nil(t, res.GetNextCursor())
+				} else {
+					require.NotNil(t, res.GetNextCursor())
+					assert.Equal(t, c.order, res.GetNextCursor().GetOrder())
+					lastKey = res.GetNextCursor().GetLastKey()
+				}
+			}
+		})
+	}
+
+	query := fc.NewQueryBuilder().
+		AuthorizerId(authorizer.AccountId).
+		FromId("com/account").
+		Select("*").
+		RequestCode(model.ListObjectCode).
+		Cursor(nil, model.ASC, nil).
+		Build()
+	_, err = qp.Query(wsv, query)
+	assert.EqualError(t, errors.Cause(err), core.ErrQueryProcessorInvalidCursor.Error())
+}
+
+func TestQueryProcessor_QueryAggregate(t *testing.T) {
+	fc := RandomFactory()
+	rp := repository.NewRepository(RandomDBA(), RandomCryptor(), fc, RandomConfig())
+	authorizer := NewAccountWithPri("authorizer@com/account")
+	genesisCommit(t, rp, authorizer)
+
+	builder := fc.NewTxBuilder().
+		AddBalance("root@/root", "targeta@pr", 5).
+		AddBalance("root@/root", "targetb@pr", 7)
+	for i := 0; i < 5; i++ {
+		builder = builder.AddBalance("root@/root", fmt.Sprintf("target%d@com", i), int64(i*10))
+	}
+	// int64 で表せない集計
+	builder = builder.
+		CreateAccount("root@/root", "big1@big", []model.PublicKey{}, 0).
+		CreateAccount("root@/root", "big2@big", []model.PublicKey{}, 0).
+		CreateAccount("root@/root", "huge@huge", []model.PublicKey{}, 0).
+		AddBalance("root@/root", "big1@big", math.MaxInt64).
+		AddBalance("root@/root", "big2@big", 1).
+		DefineStorage("root@/root", "/num", fc.NewStorageBuilder().Uint64("n", 0).Build()).
+		CreateStorage("root@/root", "huge@huge/num").
+		UpdateObject("root@/root", "huge@huge/num", "n", fc.NewObjectBuilder().Uint64(math.MaxUint64))
+	CommitTxWrapBlock(t, rp, fc, builder.Build())
+
+	wsv, err := rp.TopWSV()
+	require.NoError(t, err)
+	defer wsv.Commit()
+	qp := NewQueryProcessor(fc, RandomConfig())
+
+	newQuery := func(fromId string, where string, groupBy string, aggs ...model.Aggregate) model.Query {
+		builder := fc.NewQueryBuilder().
+			AuthorizerId(authorizer.AccountId).
+			FromId(fromId).
+			Select("*").
+			RequestCode(model.ListObjectCode).
+			Where(where).
+			GroupBy(groupBy).
+			CreatedTime(RandomNow())
+		for _, a := range aggs {
+			builder = builder.Aggregate(a.GetCode(), a.GetKey())
+		}
+		return builder.Build()
+	}
+
+	t.Run("count and sum", func(t *testing.T) {
+		query := newQuery("com/account", "", "",
+			&aggregate{model.CountAggregate, ""}, &aggregate{model.SumAggregate, "balance"})
+		require.NoError(t, NewQueryVerifier().Verify(query))
+		res, err := qp.Query(wsv, query)
+		require.NoError(t, err)
+		dict := res.GetObject().GetDict()
+		assert.Equal(t, int64(6), dict["count"].GetI64())
+		assert.Equal(t, int64(100), dict["sum(balance)"].GetI64())
+	})
+
+	t.Run("min and max with where", func(t *testing.T) {
+		query := newQuery("com/account", "id contains 'target'", "",
+			&aggregate{model.MinAggregate, "balance"}, &aggregate{model.MaxAggregate, "balance"})
+		res, err := qp.Query(wsv, query)
+		require.NoError(t, err)
+		dict := res.GetObject().GetDict()
+		assert.Equal(t, int64(0), dict["min(balance)"].GetI64())
+		assert.Equal(t, int64(40), dict["max(balance)"].GetI64())
+	})
+
+	t.Run("group by domain", func(t *testing.T) {
+		query := newQuery("/account", "id contains 'target'", DomainGroupBy,
+			&aggregate{model.CountAggregate, ""}, &aggregate{model.SumAggregate, "balance"})
+		require.NoError(t, NewQueryVerifier().Verify(query))
+		res, err := qp.Query(wsv, query)
+		require.NoError(t, err)
+		groups := res.GetObject().GetList()
+		require.Equal(t, 2, len(groups))
+		for i, exp := range []struct {
+			key   string
+			count int64
+			sum   int64
+		}{
+			{"com", 5, 100},
+			{"pr", 3, 12},
+		} {
+			dict := groups[i].GetDict()
+			assert.Equal(t, exp.key, dict[GroupKey].GetStr())
+			assert.Equal(t, exp.count, dict["count"].GetI64())
+			assert.Equal(t, exp.sum, dict["sum(balance)"].GetI64())
+		}
+	})
+
+	t.Run("invalid aggregate", func(t *testing.T) {
+		query := newQuery("com/account", "", "", &aggregate{model.SumAggregate, ""})
+		assert.EqualError(t, errors.Cause(NewQueryVerifier().Verify(query)), ErrQueryVerifyInvalidAggregate.Error())
+		_, err := qp.Query(wsv, query)
+		assert.EqualError(t, errors.Cause(err), core.ErrQueryProcessorInvalidAggregate.Error())
+	})
+
+	t.Run("sum overflows int64", func(t *testing.T) {
+		query := newQuery("big/account", "", "", &aggregate{model.SumAggregate, "balance"})
+		_, err := qp.Query(wsv, query)
+		assert.EqualError(t, errors.Cause(err), core.ErrQueryProcessorInvalidAggregate.Error())
+	})
+
+	t.Run("sum of uint64 over int64", func(t *testing.T) {
+		query := newQuery("huge/num", "", "", &aggregate{model.SumAggregate, "n"})
+		_, err := qp.Query(wsv, query)
+		assert.EqualError(t, errors.Cause(err), core.ErrQueryProcessorInvalidAggregate.Error())
+	})
+}
+
+type aggregate struct {
+	code model.AggregateCode
+	key  string
+}
+
+func (a *aggregate) GetCode() model.AggregateCode {
+	return a.code
+}
+
+func (a *aggregate) GetKey() string {
+	return a.key
+}
+
+func TestQueryProcessor_QuerySelect(t *testing.T) {
+	fc := RandomFactory()
+	rp := repository.NewRepository(RandomDBA(), RandomCryptor(), fc, RandomConfig())
+	authorizer := NewAccountWithPri("authorizer@com/account")
+	genesisCommit(t, rp, authorizer)
+
+	builder := fc.NewTxBuilder().
+		DefineStorage("root@/root", "/st", fc.NewStorageBuilder().Dict("info", nil).Build())
+	for i := 0; i < 2; i++ {
+		id := fmt.Sprintf("target%d@com", i)
+		builder = builder.
+			AddBalance("root@/root", id, int64(i*10)).
+			CreateStorage("root@/root", id+"/st").
+			UpdateObject("root@/root", id+"/st", "info", fc.NewObjectBuilder().Dict(map[string]model.Object{
+				"level": fc.NewObjectBuilder().Int32(int32(i)),
+				"tags":  fc.NewObjectBuilder().List([]model.Object{fc.NewObjectBuilder().Str(fmt.Sprintf("tag%d", i))}),
+			}))
+	}
+	CommitTxWrapBlock(t, rp, fc, builder.Build())
+
+	wsv, err := rp.TopWSV()
+	require.NoError(t, err)
+	defer wsv.Commit()
+	qp := NewQueryProcessor(fc, RandomConfig())
+
+	query := func(fromId string, code model.ObjectCode, sel string) model.Object {
+		q := fc.NewQueryBuilder().
+			AuthorizerId(authorizer.AccountId).
+			FromId(fromId).
+			Select(sel).
+			RequestCode(code).
+			OrderBy("id", model.ASC).
+			Limit(100).
+			Build()
+		res, err := qp.Query(wsv, q)
+		require.NoError(t, err)
+		return res.GetObject()
+	}
+
+	t.Run("single key", func(t *testing.T) {
+		o := query("target1@com/account", model.AccountObjectCode, "balance")
+		assert.Equal(t, int64(10), o.GetI64())
+	})
+	t.Run("single nested path", func(t *testing.T) {
+		o := query("target1@com/st", model.StorageObjectCode, "info.tags.0")
+		assert.Equal(t, "tag1", o.GetStr())
+	})
+	t.Run("not found key is whole object", func(t *testing.T) {
+		o := query("target1@com/account", model.AccountObjectCode, "unknown")
+		assert.Equal(t, "target1@com", o.GetAccount().GetAccountId())
+	})
+	t.Run("fields", func(t *testing.T) {
+		o := query("target1@com/account", model.AccountObjectCode, "id, balance, unknown")
+		dict := o.GetDict()
+		assert.Equal(t, 2, len(dict))
+		assert.Equal(t, "target1@com", dict["id"].GetAddress())
+		assert.Equal(t, int64(10), dict["balance"].GetI64())
+	})
+	t.Run("range fields with nested paths", func(t *testing.T) {
+		o := query("com/st", model.ListObjectCode, "id,info.level,info.tags.0")
+		require.Len(t, o.GetList(), 2)
+		for i, e := range o.GetList() {
+			dict := e.GetDict()
+			assert.Equal(t, fmt.Sprintf("target%d@com/st", i), dict["id"].GetAddress())
+			assert.Equal(t, int32(i), dict["info.level"].GetI32())
+			assert.Equal(t, fmt.Sprintf("tag%d", i), dict["info.tags.0"].GetStr())
+		}
+	})
+}
@@ -0,0 +1,45 @@
+package query
+
+import (
+	"github.com/proskenion/proskenion/core/model"
+	"strings"
+)
+
+// AllSelect は Object 全体を取得する select
+const AllSelect = "*"
+
+// selectPaths は select を "," で区切った path の列に分ける
+func selectPaths(sel string) []string {
+	paths := make([]string, 0)
+	for _, path := range strings.Split(sel, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// selectObject は o から select の path を取得する。path は where と同じく "." で区切って Object を辿る。
+// "*" なら o を、path が 1 つならその値 (無ければ o) を、複数ならその path を key にした値だけの Dict を返す
+func (q *QueryProcessor) selectObject(o model.Object, sel string) model.Object {
+	if sel == AllSelect {
+		return o
+	}
+	paths := selectPaths(sel)
+	if len(paths) == 0 {
+		return o
+	}
+	if len(paths) == 1 && !strings.Contains(sel, ",") {
+		if ret := resolvePath(o, strings.Split(paths[0], ".")); ret != nil {
+			return ret
+		}
+		return o
+	}
+	dict := make(map[string]model.Object)
+	for _, path := range paths {
+		if ret := resolvePath(o, strings.Split(path, ".")); ret != nil {
+			dict[path] = ret
+		}
+	}
+	return q.fc.NewObjectBuilder().Dict(dict)
+}
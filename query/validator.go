@@ -40,5 +40,6 @@ func (q *QueryValidator) Validate(wsv model.ObjectFinder, query model.Query) err
 			"authorizer : %s, expect key : %x",
 			query.GetPayload().GetAuthorizerId(), query.GetSignature().GetPublicKey())
 	}
-	return nil
+	// 読み込み権限チェック
+	return q.ValidateRead(wsv, query.GetPayload().GetAuthorizerId(), query.GetPayload().GetFromId())
 }
@@ -3,6 +3,7 @@ package query_test
 import (
 	"github.com/pkg/errors"
 	"github.com/proskenion/proskenion/core"
+	"github.com/proskenion/proskenion/core/model"
 	. "github.com/proskenion/proskenion/query"
 	"github.com/proskenion/proskenion/repository"
 	. "github.com/proskenion/proskenion/test_utils"
@@ -40,3 +41,90 @@ func TestQueryValidator_Query(t *testing.T) {
 	err = qv.Validate(wsv, q4)
 	assert.EqualError(t, errors.Cause(err), core.ErrQueryProcessorNotExistAuthoirizer.Error())
 }
+
+func TestQueryValidator_ValidateRead(t *testing.T) {
+	fc := RandomFactory()
+	rp := repository.NewRepository(RandomDBA(), RandomCryptor(), fc, RandomConfig())
+	authorizer := NewAccountWithPri("authorizer@com")
+	genesisCommit(t, rp, authorizer)
+
+	privateAcl := map[string]model.Object{
+		core.ReadAclOwnerKey:    fc.NewObjectBuilder().Bool(true),
+		core.ReadAclAccountsKey: fc.NewObjectBuilder().List([]model.Object{fc.NewObjectBuilder().Address("targeta@pr")}),
+		core.ReadAclRolesKey:    fc.NewObjectBuilder().List([]model.Object{fc.NewObjectBuilder().Str("auditor")}),
+	}
+	domainAcl := map[string]model.Object{
+		core.ReadAclDomainKey: fc.NewObjectBuilder().Bool(true),
+	}
+	CommitTxWrapBlock(t, rp, fc, fc.NewTxBuilder().
+		DefineStorage("root@/root", "/private", fc.NewStorageBuilder().Dict(core.ReadAclKey, privateAcl).Build()).
+		DefineStorage("root@/root", "/dom", fc.NewStorageBuilder().Dict(core.ReadAclKey, domainAcl).Build()).
+		CreateStorage("root@/root", "target0@com/private").
+		CreateStorage("root@/root", "target0@com/dom").
+		GrantPermission("root@/root", "targetb@pr", "auditor", "com").
+		GrantPermission("root@/root", "targetc@pr", "auditor", "pr").
+		Build())
+
+	wsv, err := rp.TopWSV()
+	require.NoError(t, err)
+	defer wsv.Commit()
+	qv := NewQueryValidator(fc, RandomConfig())
+
+	for _, c := range []struct {
+		name         string
+		authorizerId string
+		fromId       string
+		err          error
+	}{
+		{"owner", "target0@com", "target0@com/private", nil},
+		{"not owner", "target1@com", "target0@com/private", core.ErrQueryProcessorReadDenied},
+		{"allow list", "targeta@pr", "target0@com/private", nil},
+		{"role", "targetb@pr", "target0@com/private", nil},
+		{"role in another domain", "targetc@pr", "target0@com/private", core.ErrQueryProcessorReadDenied},
+		{"owner range", "target0@com", "com/private", core.ErrQueryProcessorReadDenied},
+		{"domain", "target1@com", "target0@com/dom", nil},
+		{"domain range", "target1@com", "com/dom", nil},
+		{"other domain", "targeta@pr", "target0@com/dom", core.ErrQueryProcessorReadDenied},
+		{"no acl", "targetc@pr", "target0@com/account", nil},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			err := qv.ValidateRead(wsv, c.authorizerId, c.fromId)
+			if c.err != nil {
+				assert.EqualError(t, errors.Cause(err), c.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	// 署名された Query も読み込み権限で検証される
+	q := fc.NewQueryBuilder().
+		AuthorizerId(authorizer.AccountId).
+		FromId("target0@com/private").
+		RequestCode(model.StorageObjectCode).
+		Build()
+	require.NoError(t, q.Sign(authorizer.Pubkey, authorizer.Prikey))
+	assert.EqualError(t, errors.Cause(qv.Validate(wsv, q)), core.ErrQueryProcessorReadDenied.Error())
+}
+
+// errorFinder は全ての Query で err を返す ObjectFinder
+type errorFinder struct {
+	model.ObjectFinder
+	err error
+}
+
+func (f *errorFinder) Query(targetId model.Address, value model.Unmarshaler) error {
+	return f.err
+}
+
+func TestQueryValidator_ValidateReadFailed(t *testing.T) {
+	qv := NewQueryValidator(RandomFactory(), RandomConfig())
+
+	// 定義されていない Storage は誰でも読み込める
+	notFound := &errorFinder{err: errors.Wrap(core.ErrWSVNotFound, "not found")}
+	assert.NoError(t, qv.ValidateRead(notFound, "authorizer@com", "target@com/private"))
+
+	// 定義を読み込めない場合は許可しない
+	failed := &errorFinder{err: errors.New("internal error")}
+	assert.Error(t, qv.ValidateRead(failed, "authorizer@com", "target@com/private"))
+}
@@ -19,6 +19,11 @@ var (
 	ErrQueryVerifyPeerTargetIdNotPeerAddress  = fmt.Errorf("Failed Query Verify targetId is not PeerAddress when get peer object")
 	ErrQueryVerifyAuthorizerIdNotAccountId    = fmt.Errorf("Failed Query Verify authorizerId is not accountId")
 	ErrQueryVerifyFromIdNotIdFormat           = fmt.Errorf("Failed Query Verify fromId is not valid format")
+	ErrQueryVerifyInvalidWhere                = fmt.Errorf("Failed Query Verify where is not valid expression")
+	ErrQueryVerifyInvalidCursor               = fmt.Errorf("Failed Query Verify cursor is not valid")
+	ErrQueryVerifyInvalidAt                   = fmt.Errorf("Failed Query Verify at is not valid block")
+	ErrQueryVerifyEmptyTxHash                 = fmt.Errorf("Failed Query Verify txHash is empty when get transaction object")
+	ErrQueryVerifyInvalidAggregate            = fmt.Errorf("Failed Query Verify aggregate is not valid")
 )
 
 func (q *QueryVerifier) Verify(query model.Query) error {
@@ -28,10 +33,49 @@ func (q *QueryVerifier) Verify(query model.Query) error {
 		return errors.Wrapf(ErrQueryVerifyAuthorizerIdNotAccountId,
 			"authorizerId : %s, must be : %s", qp.GetAuthorizerId(), GetRegexp().VerifyAccountId.String())
 	}
+	if at := qp.GetAt(); at != nil && at.GetBlockHash() == nil && at.GetHeight() < 0 {
+		return errors.Wrapf(ErrQueryVerifyInvalidAt, "height : %d", at.GetHeight())
+	}
+	// Block と Transaction は fromId を使わない
+	switch qp.GetRequestCode() {
+	case model.BlockObjectCode:
+		return nil
+	case model.TransactionObjectCode:
+		if len(qp.GetTxHash()) == 0 {
+			return ErrQueryVerifyEmptyTxHash
+		}
+		return nil
+	}
 	if _, err := model.NewAddress(qp.GetFromId()); err != nil {
 		return errors.Wrapf(ErrQueryVerifyFromIdNotIdFormat,
 			"fromId : %s, not invalid id format", qp.GetFromId())
 	}
+	if _, err := parseWhere(qp.GetWhere()); err != nil {
+		return errors.Wrapf(ErrQueryVerifyInvalidWhere,
+			"where : %s, %s", qp.GetWhere(), err.Error())
+	}
+	for _, a := range qp.GetAggregates() {
+		if err := validAggregate(a); err != nil {
+			return errors.Wrap(ErrQueryVerifyInvalidAggregate, err.Error())
+		}
+	}
+	if qp.GetGroupBy() != "" && len(qp.GetAggregates()) == 0 {
+		return errors.Wrapf(ErrQueryVerifyInvalidAggregate, "groupBy : %s, requires aggregates", qp.GetGroupBy())
+	}
+	if qp.GetCursor() != nil {
+		if len(qp.GetAggregates()) > 0 {
+			return errors.Wrap(ErrQueryVerifyInvalidCursor, "cursor can not be used with aggregates")
+		}
+
+		if qp.GetOrderBy().GetKey() != "" {
+			return errors.Wrapf(ErrQueryVerifyInvalidCursor,
+				"cursor can not be used with orderBy : %s", qp.GetOrderBy().GetKey())
+		}
+		if qp.GetLimit() <= 0 {
+			return errors.Wrapf(ErrQueryVerifyInvalidCursor,
+				"cursor requires positive limit : %d", qp.GetLimit())
+		}
+	}
 
 	/*
 		switch qp.GetRequestCode() {
@@ -16,6 +16,7 @@ func TestNewQueryVerifier(t *testing.T) {
 		targetId     string
 		authorizerId string
 		objectCode   model.ObjectCode
+		where        string
 		err          error
 	}{
 		{
@@ -23,6 +24,7 @@ func TestNewQueryVerifier(t *testing.T) {
 			"target@com",
 			"authorizer@com",
 			model.AccountObjectCode,
+			"",
 			nil,
 		},
 		{
@@ -30,6 +32,7 @@ func TestNewQueryVerifier(t *testing.T) {
 			"target@com",
 			"authoirizer",
 			model.AccountObjectCode,
+			"",
 			ErrQueryVerifyAuthorizerIdNotAccountId,
 		},
 		{
@@ -37,8 +40,33 @@ func TestNewQueryVerifier(t *testing.T) {
 			"com/peer",
 			"authorizer@com",
 			model.PeerObjectCode,
+			"",
 			nil,
 		},
+		{
+			"case 4 valid where",
+			"com/account",
+			"authorizer@com",
+			model.ListObjectCode,
+			`balance >= 10 and (name == "a" or keys contains 0x0102) and not quorum in [1, 2]`,
+			nil,
+		},
+		{
+			"case 5 invalid where",
+			"com/account",
+			"authorizer@com",
+			model.ListObjectCode,
+			"balance >= 10 and",
+			ErrQueryVerifyInvalidWhere,
+		},
+		{
+			"case 6 unterminated string",
+			"com/account",
+			"authorizer@com",
+			model.ListObjectCode,
+			"name == 'a",
+			ErrQueryVerifyInvalidWhere,
+		},
 	} {
 		t.Run(c.name, func(t *testing.T) {
 			query := RandomFactory().NewQueryBuilder().
@@ -46,6 +74,38 @@ func TestNewQueryVerifier(t *testing.T) {
 				AuthorizerId(c.authorizerId).
 				CreatedTime(RandomNow()).
 				RequestCode(c.objectCode).
+				Where(c.where).
+				Build()
+			err := NewQueryVerifier().Verify(query)
+			if c.err != nil {
+				assert.EqualError(t, errors.Cause(err), c.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestQueryVerifier_Cursor(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		orderBy string
+		limit   int32
+		err     error
+	}{
+		{"case 1", "", 10, nil},
+		{"case 2 with orderBy", "balance", 10, ErrQueryVerifyInvalidCursor},
+		{"case 3 no limit", "", 0, ErrQueryVerifyInvalidCursor},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			query := RandomFactory().NewQueryBuilder().
+				FromId("com/account").
+				AuthorizerId("authorizer@com").
+				CreatedTime(RandomNow()).
+				RequestCode(model.ListObjectCode).
+				OrderBy(c.orderBy, model.ASC).
+				Limit(c.limit).
+				Cursor(nil, model.ASC).
 				Build()
 			err := NewQueryVerifier().Verify(query)
 			if c.err != nil {
@@ -0,0 +1,517 @@
+package query
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/proskenion/proskenion/core/model"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrWhereSyntax = fmt.Errorf("Failed Where syntax error")
+)
+
+/*
+where 条件式
+
+	expr       := or
+	or         := and { ("or" | "||") and }
+	and        := not { ("and" | "&&") not }
+	not        := ("not" | "!") not | primary
+	primary    := "(" expr ")" | path op value | path "in" "[" value { "," value } "]" | path "contains" value
+	op         := "==" | "=" | "!=" | "<" | "<=" | ">" | ">="
+	path       := key { "." key }
+	value      := int | "string" | 'string' | 0xhex | true | false
+
+path は Object の key を辿る (Account, Peer, Storage, Dict の key と List の index)。
+存在しない key や型が合わない比較は false になる。
+*/
+type whereExpr interface {
+	eval(o model.Object) bool
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenInt
+	tokenStr
+	tokenBytes
+	tokenOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func isIdentChar(c byte, head bool) bool {
+	if c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') {
+		return true
+	}
+	return !head && '0' <= c && c <= '9'
+}
+
+func tokenizeWhere(where string) ([]token, error) {
+	tokens := make([]token, 0)
+	for i := 0; i < len(where); {
+		c := where[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"' || c == '\'':
+			j := i + 1
+			buf := make([]byte, 0)
+			for ; j < len(where) && where[j] != c; j++ {
+				if where[j] == '\\' && j+1 < len(where) {
+					j++
+				}
+				buf = append(buf, where[j])
+			}
+			if j >= len(where) {
+				return nil, errors.Wrapf(ErrWhereSyntax, "unterminated string at %d", i)
+			}
+			tokens = append(tokens, token{tokenStr, string(buf), i})
+			i = j + 1
+		case c == '0' && i+1 < len(where) && (where[i+1] == 'x' || where[i+1] == 'X'):
+			j := i + 2
+			for ; j < len(where) && strings.IndexByte("0123456789abcdefABCDEF", where[j]) >= 0; j++ {
+			}
+			tokens = append(tokens, token{tokenBytes, where[i+2 : j], i})
+			i = j
+		case c == '-' || ('0' <= c && c <= '9'):
+			j := i + 1
+			for ; j < len(where) && '0' <= where[j] && where[j] <= '9'; j++ {
+			}
+			if where[i:j] == "-" {
+				return nil, errors.Wrapf(ErrWhereSyntax, "unexpected '-' at %d", i)
+			}
+			tokens = append(tokens, token{tokenInt, where[i:j], i})
+			i = j
+		case isIdentChar(c, true):
+			j := i + 1
+			for ; j < len(where) && isIdentChar(where[j], false); j++ {
+			}
+			tokens = append(tokens, token{tokenIdent, where[i:j], i})
+			i = j
+		default:
+			op := ""
+			for _, o := range []string{"==", "!=", "<=", ">=", "&&", "||", "=", "<", ">", "!", "(", ")", "[", "]", ",", "."} {
+				if strings.HasPrefix(where[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, errors.Wrapf(ErrWhereSyntax, "unexpected character '%c' at %d", c, i)
+			}
+			tokens = append(tokens, token{tokenOp, op, i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{tokenEOF, "", len(where)}), nil
+}
+
+type whereParser struct {
+	tokens []token
+	pos    int
+}
+
+// parseWhere は where 条件式を解析する。空文字列は nil (全て通す) を返す
+func parseWhere(where string) (whereExpr, error) {
+	if strings.TrimSpace(where) == "" {
+		return nil, nil
+	}
+	tokens, err := tokenizeWhere(where)
+	if err != nil {
+		return nil, err
+	}
+	p := &whereParser{tokens, 0}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, errors.Wrapf(ErrWhereSyntax, "unexpected '%s' at %d", t.text, t.pos)
+	}
+	return expr, nil
+}
+
+func (p *whereParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *whereParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *whereParser) isKeyword(words ...string) bool {
+	t := p.peek()
+	for _, w := range words {
+		if (t.kind == tokenIdent && strings.ToLower(t.text) == w) || (t.kind == tokenOp && t.text == w) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *whereParser) expect(op string) error {
+	if t := p.next(); t.kind != tokenOp || t.text != op {
+		return errors.Wrapf(ErrWhereSyntax, "expected '%s' but '%s' at %d", op, t.text, t.pos)
+	}
+	return nil
+}
+
+func (p *whereParser) parseOr() (whereExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("or", "||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orExpr{left, right}
+	}
+	return left, nil
+}
+
+func (p *whereParser) parseAnd() (whereExpr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("and", "&&") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &andExpr{left, right}
+	}
+	return left, nil
+}
+
+func (p *whereParser) parseNot() (whereExpr, error) {
+	if p.isKeyword("not", "!") {
+		p.next()
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{expr}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *whereParser) parsePrimary() (whereExpr, error) {
+	if p.isKeyword("(") {
+		p.next()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return expr, nil
+	}
+	path, err := p.parsePath()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case p.isKeyword("in"):
+		p.next()
+		if err := p.expect("["); err != nil {
+			return nil, err
+		}
+		values := make([]interface{}, 0)
+		for {
+			v, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+			if !p.isKeyword(",") {
+				break
+			}
+			p.next()
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		return &inExpr{path, values}, nil
+	case p.isKeyword("contains"):
+		p.next()
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return &containsExpr{path, v}, nil
+	case p.isKeyword("==", "=", "!=", "<", "<=", ">", ">="):
+		op := p.next().text
+		if op == "=" {
+			op = "=="
+		}
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return &compareExpr{path, op, v}, nil
+	}
+	t := p.peek()
+	return nil, errors.Wrapf(ErrWhereSyntax, "expected operator after '%s' but '%s' at %d", strings.Join(path, "."), t.text, t.pos)
+}
+
+func (p *whereParser) parsePath() ([]string, error) {
+	path := make([]string, 0)
+	for {
+		t := p.next()
+		if t.kind != tokenIdent && t.kind != tokenInt {
+			return nil, errors.Wrapf(ErrWhereSyntax, "expected key but '%s' at %d", t.text, t.pos)
+		}
+		path = append(path, t.text)
+		if !p.isKeyword(".") {
+			return path, nil
+		}
+		p.next()
+	}
+}
+
+func (p *whereParser) parseValue() (interface{}, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenInt:
+		v, err := strconv.ParseInt(t.text, 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(ErrWhereSyntax, "invalid integer '%s' at %d", t.text, t.pos)
+		}
+		return v, nil
+	case tokenStr:
+		return t.text, nil
+	case tokenBytes:
+		v, err := hex.DecodeString(t.text)
+		if err != nil {
+			return nil, errors.Wrapf(ErrWhereSyntax, "invalid bytes '0x%s' at %d", t.text, t.pos)
+		}
+		return v, nil
+	case tokenIdent:
+		switch strings.ToLower(t.text) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	}
+	return nil, errors.Wrapf(ErrWhereSyntax, "expected value but '%s' at %d", t.text, t.pos)
+}
+
+type orExpr struct {
+	left, right whereExpr
+}
+
+func (e *orExpr) eval(o model.Object) bool {
+	return e.left.eval(o) || e.right.eval(o)
+}
+
+type andExpr struct {
+	left, right whereExpr
+}
+
+func (e *andExpr) eval(o model.Object) bool {
+	return e.left.eval(o) && e.right.eval(o)
+}
+
+type notExpr struct {
+	expr whereExpr
+}
+
+func (e *notExpr) eval(o model.Object) bool {
+	return !e.expr.eval(o)
+}
+
+type compareExpr struct {
+	path  []string
+	op    string
+	value interface{}
+}
+
+func (e *compareExpr) eval(o model.Object) bool {
+	c, ok := compareObject(resolvePath(o, e.path), e.value)
+	if !ok {
+		return false
+	}
+	switch e.op {
+	case "==":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return false
+}
+
+type inExpr struct {
+	path   []string
+	values []interface{}
+}
+
+func (e *inExpr) eval(o model.Object) bool {
+	target := resolvePath(o, e.path)
+	for _, v := range e.values {
+		if c, ok := compareObject(target, v); ok && c == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+type containsExpr struct {
+	path  []string
+	value interface{}
+}
+
+func (e *containsExpr) eval(o model.Object) bool {
+	target := resolvePath(o, e.path)
+	if target == nil {
+		return false
+	}
+	switch target.GetType() {
+	case model.ListObjectCode:
+		for _, elem := range target.GetList() {
+			if c, ok := compareObject(elem, e.value); ok && c == 0 {
+				return true
+			}
+		}
+	case model.DictObjectCode:
+		if key, ok := e.value.(string); ok {
+			_, ok := target.GetDict()[key]
+			return ok
+		}
+	case model.StringObjectCode, model.AddressObjectCode:
+		if sub, ok := e.value.(string); ok {
+			return strings.Contains(objectString(target), sub)
+		}
+	case model.BytesObjectCode:
+		if sub, ok := e.value.([]byte); ok {
+			return bytes.Contains(target.GetData(), sub)
+		}
+	}
+	return false
+}
+
+// resolvePath は o から path の key を順に辿った Object を返す。存在しなければ nil
+func resolvePath(o model.Object, path []string) model.Object {
+	for _, key := range path {
+		if o == nil {
+			return nil
+		}
+		o = objectField(o, key)
+	}
+	return o
+}
+
+func objectField(o model.Object, key string) model.Object {
+	var ret model.Object
+	switch o.GetType() {
+	case model.AccountObjectCode:
+		ret = o.GetAccount().GetFromKey(key)
+	case model.PeerObjectCode:
+		ret = o.GetPeer().GetFromKey(key)
+	case model.StorageObjectCode:
+		ret = o.GetStorage().GetFromKey(key)
+	case model.BlockObjectCode:
+		ret = o.GetBlock().GetFromKey(key)
+	case model.DictObjectCode:
+		ret = o.GetDict()[key]
+	case model.ListObjectCode:
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || i >= len(o.GetList()) {
+			return nil
+		}
+		ret = o.GetList()[i]
+	}
+	if ret == nil || ret.GetType() == model.AnythingObjectCode {
+		return nil
+	}
+	return ret
+}
+
+func objectString(o model.Object) string {
+	if o.GetType() == model.AddressObjectCode {
+		return o.GetAddress()
+	}
+	return o.GetStr()
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareObject は Object と条件式の値を比較する。型が合わなければ ok = false
+func compareObject(o model.Object, value interface{}) (int, bool) {
+	if o == nil {
+		return 0, false
+	}
+	switch v := value.(type) {
+	case int64:
+		switch o.GetType() {
+		case model.Int32ObjectCode:
+			return compareInt64(int64(o.GetI32()), v), true
+		case model.Int64ObjectCode:
+			return compareInt64(o.GetI64(), v), true
+		case model.Uint32ObjectCode:
+			return compareInt64(int64(o.GetU32()), v), true
+		case model.Uint64ObjectCode:
+			if v < 0 || o.GetU64() > uint64(1<<63-1) {
+				return 1, true
+			}
+			return compareInt64(int64(o.GetU64()), v), true
+		}
+	case string:
+		switch o.GetType() {
+		case model.StringObjectCode, model.AddressObjectCode:
+			return strings.Compare(objectString(o), v), true
+		}
+	case []byte:
+		if o.GetType() == model.BytesObjectCode {
+			return bytes.Compare(o.GetData(), v), true
+		}
+	case bool:
+		if o.GetType() == model.BoolObjectCode {
+			switch {
+			case o.GetBoolean() == v:
+				return 0, true
+			case v:
+				return -1, true
+			}
+			return 1, true
+		}
+	}
+	return 0, false
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"encoding/binary"
 	"github.com/pkg/errors"
 	"github.com/proskenion/proskenion/core"
 	"github.com/proskenion/proskenion/core/model"
@@ -11,13 +12,15 @@ type Blockchain struct {
 	tx      core.DBATx
 	factory model.ModelFactory
 	tree    core.MerklePatriciaTree
+	top     model.Hash
 }
 
 var (
-	BlockChainRootKey     byte = 9
-	BlockChainTopRootKey  byte = 0
-	BlockChainNowRootKey  byte = 1
-	BlockChainNextRootKey byte = 2
+	BlockChainRootKey       byte = 9
+	BlockChainTopRootKey    byte = 0
+	BlockChainNowRootKey    byte = 1
+	BlockChainNextRootKey   byte = 2
+	BlockChainHeightRootKey byte = 3
 )
 
 type ByteWrapper struct {
@@ -46,7 +49,7 @@ func NewBlockchainFromTopBlock(tx core.DBATx, factory model.ModelFactory, crypto
 	if err != nil {
 		return nil, err
 	}
-	return &Blockchain{tx, factory, tree}, nil
+	return &Blockchain{tx, factory, tree, topBlockHash}, nil
 }
 
 func BlockHashToKey(blockHash model.Hash) []byte {
@@ -61,6 +64,13 @@ func BlockHashToNextKey(blockHash model.Hash) []byte {
 	return append([]byte{BlockChainRootKey, BlockChainNextRootKey}, blockHash...)
 }
 
+// height の順に並ぶように big endian で key にする
+func BlockHeightToKey(height int64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(height))
+	return append([]byte{BlockChainRootKey, BlockChainHeightRootKey}, key...)
+}
+
 func (b *Blockchain) Next(blockHash model.Hash) (model.Block, error) {
 	blockKey := BlockHashToNextKey(blockHash)
 	it, err := b.tree.Find(blockKey)
@@ -94,6 +104,43 @@ func (b *Blockchain) Get(blockHash model.Hash) (model.Block, error) {
 	return retBlock, nil
 }
 
+// GetByHeight は height の index から Block を取得する。
+// index を追加する前に Append された Block は index が無いので、top から preBlockHash を辿って探す
+func (b *Blockchain) GetByHeight(height int64) (model.Block, error) {
+	it, err := b.tree.Find(BlockHeightToKey(height))
+	if err != nil {
+		if errors.Cause(err) == core.ErrMerklePatriciaTreeNotFoundKey {
+			return b.walkToHeight(height)
+		}
+		return nil, err
+	}
+	bw := &ByteWrapper{nil}
+	if err := it.Data(bw); err != nil {
+		return nil, errors.Wrap(core.ErrBlockchainQueryUnmarshal, err.Error())
+	}
+	return b.Get(bw.B)
+}
+
+// walkToHeight は top から preBlockHash を辿り height の Block を返す
+func (b *Blockchain) walkToHeight(height int64) (model.Block, error) {
+	blockHash := b.top
+	for blockHash != nil {
+		block, err := b.Get(blockHash)
+		if err != nil {
+			return nil, err
+		}
+		h := block.GetPayload().GetHeight()
+		if h == height {
+			return block, nil
+		}
+		if h < height {
+			break
+		}
+		blockHash = block.GetPayload().GetPreBlockHash()
+	}
+	return nil, errors.Wrapf(core.ErrBlockchainNotFound, "height: %d", height)
+}
+
 // Commit is allowed only Commitable Block, ohterwise panic
 func (b *Blockchain) Append(block model.Block) (err error) {
 	blockHash := block.Hash()
@@ -108,6 +155,10 @@ func (b *Blockchain) Append(block model.Block) (err error) {
 		&ByteWrapper{blockHash}}); err != nil {
 		return err
 	}
+	it, err = b.tree.Upsert(&KVNode{BlockHeightToKey(block.GetPayload().GetHeight()), &ByteWrapper{blockHash}})
+	if err != nil {
+		return err
+	}
 
 	rootHash := it.Hash()
 	if err != nil {
@@ -116,5 +167,6 @@ func (b *Blockchain) Append(block model.Block) (err error) {
 	if err := b.tx.Store(BlockHashToMappingKey(blockHash), &ByteWrapper{rootHash}); err != nil {
 		return err
 	}
+	b.top = blockHash
 	return nil
 }
@@ -5,6 +5,7 @@ import (
 	"github.com/proskenion/proskenion/convertor"
 	"github.com/proskenion/proskenion/core"
 	"github.com/proskenion/proskenion/core/model"
+	"github.com/proskenion/proskenion/datastructure"
 	. "github.com/proskenion/proskenion/repository"
 	. "github.com/proskenion/proskenion/test_utils"
 	"github.com/stretchr/testify/assert"
@@ -136,9 +137,67 @@ func test_Blockchain(t *testing.T, dba core.DBA) {
 			require.NoError(t, err)
 			assert.Equal(t, blocks[i+1].Hash(), nxt.Hash())
 		}
+		for i := 0; i <= limits; i++ {
+			block, err := bc.GetByHeight(int64(i))
+			require.NoError(t, err)
+			assert.Equal(t, blocks[i].Hash(), block.Hash())
+		}
+		_, err = bc.GetByHeight(int64(limits + 1))
+		assert.EqualError(t, errors.Cause(err), core.ErrBlockchainNotFound.Error())
 	})
 }
 
+// legacyNode は height の index を追加する前の Blockchain を作るための KVNode
+type legacyNode struct {
+	key   []byte
+	value model.Marshaler
+}
+
+func (n *legacyNode) Key() []byte              { return n.key }
+func (n *legacyNode) Value() model.Marshaler   { return n.value }
+func (n *legacyNode) Next(cnt int) core.KVNode { return &legacyNode{n.key[cnt:], n.value} }
+
+func TestBlockchain_GetByHeightWithoutIndex(t *testing.T) {
+	tx, err := RandomDBA().Begin()
+	require.NoError(t, err)
+	tree, err := datastructure.NewMerklePatriciaTree(tx, RandomCryptor(), nil, BlockChainRootKey)
+	require.NoError(t, err)
+
+	// height の index が無い Block を 3 つ追加する
+	blocks := make([]model.Block, 0)
+	preHash := model.Hash(nil)
+	for i := 0; i < 3; i++ {
+		block := RandomBlock()
+		block.(*convertor.Block).Block.Payload.PreBlockHash = preHash
+		block.(*convertor.Block).Block.Payload.Height = int64(i)
+		_, err := tree.Upsert(&legacyNode{BlockHashToKey(block.Hash()), block})
+		require.NoError(t, err)
+		it, err := tree.Upsert(&legacyNode{BlockHashToNextKey(preHash), &ByteWrapper{block.Hash()}})
+		require.NoError(t, err)
+		require.NoError(t, tx.Store(BlockHashToMappingKey(block.Hash()), &ByteWrapper{it.Hash()}))
+		blocks = append(blocks, block)
+		preHash = block.Hash()
+	}
+
+	bc, err := NewBlockchainFromTopBlock(tx, RandomFactory(), RandomCryptor(), preHash)
+	require.NoError(t, err)
+	// index のある Block を追加する
+	block := RandomBlock()
+	block.(*convertor.Block).Block.Payload.PreBlockHash = preHash
+	block.(*convertor.Block).Block.Payload.Height = 3
+	require.NoError(t, bc.Append(block))
+	blocks = append(blocks, block)
+
+	for i, expected := range blocks {
+		block, err := bc.GetByHeight(int64(i))
+		require.NoError(t, err)
+		assert.Equal(t, expected.Hash(), block.Hash())
+	}
+	_, err = bc.GetByHeight(4)
+	assert.EqualError(t, errors.Cause(err), core.ErrBlockchainNotFound.Error())
+	require.NoError(t, tx.Commit())
+}
+
 func TestBlockchain(t *testing.T) {
 	dba := RandomDBA()
 	test_Blockchain(t, dba)
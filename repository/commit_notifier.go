@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"github.com/proskenion/proskenion/core/model"
+	"sync"
+)
+
+// commitNotifier は Commit された Block を購読者に通知する
+// 通知は channel に空きが無ければ捨てるので、購読者は受け取った時点の top まで自分で辿る
+type commitNotifier struct {
+	mutex *sync.Mutex
+	subs  map[int]chan model.Block
+	next  int
+}
+
+func newCommitNotifier() *commitNotifier {
+	return &commitNotifier{&sync.Mutex{}, make(map[int]chan model.Block), 0}
+}
+
+func (n *commitNotifier) subscribe() (<-chan model.Block, func()) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	id := n.next
+	n.next++
+	ch := make(chan model.Block, 1)
+	n.subs[id] = ch
+	return ch, func() {
+		n.mutex.Lock()
+		defer n.mutex.Unlock()
+		if _, ok := n.subs[id]; ok {
+			delete(n.subs, id)
+			close(ch)
+		}
+	}
+}
+
+func (n *commitNotifier) notify(block model.Block) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	for _, ch := range n.subs {
+		select {
+		case ch <- block:
+		default:
+		}
+	}
+}
+
+// SubscribeCommit は Commit された Block を受け取る channel と購読を解除する関数を返す
+func (r *Repository) SubscribeCommit() (<-chan model.Block, func()) {
+	return r.notifier.subscribe()
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"github.com/proskenion/proskenion/core"
+	"github.com/proskenion/proskenion/core/model"
+	"github.com/proskenion/proskenion/prosl"
+)
+
+const (
+	// unmarshal 済みの Prosl を保持する数
+	proslCacheLimits = 16
+	// consensus prosl の実行結果を保持する数
+	consensusCacheLimits = 16
+)
+
+type proslBytes []byte
+
+func (b proslBytes) Marshal() ([]byte, error) {
+	return b, nil
+}
+
+// cachedProsl は program hash (prosl のバイト列の hash) で引ける unmarshal 済みの Prosl
+type cachedProsl struct {
+	hash model.Hash
+	core.Prosl
+}
+
+func (c *cachedProsl) Hash() model.Hash {
+	return c.hash
+}
+
+// cachedProslResult は (top block hash, program hash) で引ける prosl の実行結果
+type cachedProslResult struct {
+	hash    model.Hash
+	ret     model.Object
+	failure *proslFailure
+}
+
+func (c *cachedProslResult) Hash() model.Hash {
+	return c.hash
+}
+
+func (r *Repository) proslHash(data []byte) model.Hash {
+	return r.cryptor.Hash(proslBytes(data))
+}
+
+// compileProsl は program hash で cache された Prosl を返す。無ければ unmarshal して cache する
+func (r *Repository) compileProsl(data []byte) (core.Prosl, error) {
+	hash := r.proslHash(data)
+	if c, ok := r.proslCache.Get(hash); ok {
+		return c.(*cachedProsl).Prosl, nil
+	}
+	pr := prosl.NewProsl(r.fc, r.cryptor, r.conf)
+	if err := pr.Unmarshal(data); err != nil {
+		return nil, err
+	}
+	if err := r.proslCache.Set(&cachedProsl{hash, pr}); err != nil {
+		return nil, err
+	}
+	return pr, nil
+}
+
+// executeProslConsensus は top の次の Block 生成者を決める consensus prosl を実行する。
+// 同じ top と program に対する round 毎や ValidateCommit 毎の再計算を避けるため、結果を (top block hash, program hash) 毎に cache する
+func (r *Repository) executeProslConsensus(wsv core.WSV, top model.Block) (model.Object, *proslFailure, error) {
+	proSt := r.fc.NewEmptyStorage()
+	if err := wsv.Query(model.MustAddress(r.conf.Prosl.Consensus.Id), proSt); err != nil {
+		return nil, nil, err
+	}
+	key := r.cryptor.ConcatHash(top.Hash(), r.proslHash(proSt.GetFromKey(core.ProslKey).GetData()))
+	if c, ok := r.consensusCache.Get(key); ok {
+		res := c.(*cachedProslResult)
+		return res.ret, res.failure, nil
+	}
+
+	ret, failure, err := r.executeProslWithFallback(wsv, top, r.conf.Prosl.Consensus.Id, r.checkConsensusProsl)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := r.consensusCache.Set(&cachedProslResult{key, ret, failure}); err != nil {
+		return nil, nil, err
+	}
+	return ret, failure, nil
+}
@@ -0,0 +1,262 @@
+package repository
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/proskenion/proskenion/core"
+	"github.com/proskenion/proskenion/core/model"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// proslMetrics は Prosl の実行失敗の統計を prosl の Id 毎に保持する
+type proslMetrics struct {
+	mutex   *sync.Mutex
+	metrics map[string]core.ProslMetrics
+}
+
+func newProslMetrics() *proslMetrics {
+	return &proslMetrics{&sync.Mutex{}, make(map[string]core.ProslMetrics)}
+}
+
+func (m *proslMetrics) get(id string) core.ProslMetrics {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.metrics[id]
+}
+
+func (m *proslMetrics) add(id string, failure *proslFailure) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	ret := m.metrics[id]
+	ret.Failures++
+	if failure.version == core.ProslFallbackDefault {
+		ret.Defaults++
+	} else {
+		ret.Fallbacks++
+	}
+	m.metrics[id] = ret
+}
+
+func (r *Repository) ProslMetrics(id string) core.ProslMetrics {
+	return r.metrics.get(id)
+}
+
+// proslFailure は有効な prosl の実行失敗と代替した version
+type proslFailure struct {
+	err     error
+	version int64
+}
+
+// proslChecker は prosl の返り値を検査する
+type proslChecker func(wsv core.WSV, ret model.Object) error
+
+func checkIncentiveProsl(wsv core.WSV, ret model.Object) error {
+	if ret == nil || ret.GetTransaction() == nil {
+		return fmt.Errorf("empty incentive tx")
+	}
+	return nil
+}
+
+func (r *Repository) checkConsensusProsl(wsv core.WSV, ret model.Object) error {
+	_, err := r.normalizeDelegatedAccounts(wsv, ret)
+	return err
+}
+
+// normalizeDelegatedAccounts は consensus prosl の返り値を Block 生成順として使える形に正規化する。
+// account 以外、重複した account、存在しない・ban された・active でない Peer に委任している account を除き、
+// 残りが min_size に満たなければ除いた理由と共にエラーを返す
+func (r *Repository) normalizeDelegatedAccounts(wsv core.WSV, ret model.Object) ([]model.Account, error) {
+	if ret == nil {
+		return nil, errors.Wrap(core.ErrRepositoryInvalidConsensus, "empty return")
+	}
+	ps, err := wsv.PeerService()
+	if err != nil {
+		return nil, errors.Wrap(core.ErrRepositoryInvalidConsensus, err.Error())
+	}
+	peers := make(map[string]model.Peer)
+	for _, peer := range ps.List() {
+		peers[peer.GetPeerId()] = peer
+	}
+
+	reasons := make([]string, 0)
+	exists := make(map[string]struct{})
+	acs := make([]model.Account, 0, len(ret.GetList()))
+	for i, o := range ret.GetList() {
+		ac := o.GetAccount()
+		if ac == nil {
+			reasons = append(reasons, fmt.Sprintf("[%d] is not account", i))
+			continue
+		}
+		if _, ok := exists[ac.GetAccountId()]; ok {
+			reasons = append(reasons, fmt.Sprintf("[%d] %s is duplicated", i, ac.GetAccountId()))
+			continue
+		}
+		peer, ok := peers[ac.GetDelegatePeerId()]
+		switch {
+		case !ok:
+			reasons = append(reasons, fmt.Sprintf("[%d] %s delegates to not found peer %s", i, ac.GetAccountId(), ac.GetDelegatePeerId()))
+			continue
+		case peer.GetBan():
+			reasons = append(reasons, fmt.Sprintf("[%d] %s delegates to banned peer %s", i, ac.GetAccountId(), ac.GetDelegatePeerId()))
+			continue
+		case !peer.GetActive():
+			reasons = append(reasons, fmt.Sprintf("[%d] %s delegates to inactive peer %s", i, ac.GetAccountId(), ac.GetDelegatePeerId()))
+			continue
+		}
+		exists[ac.GetAccountId()] = struct{}{}
+		acs = append(acs, ac)
+	}
+
+	minSize := r.consensusMinSize(wsv)
+	if int64(len(acs)) < minSize {
+		return nil, errors.Wrapf(core.ErrRepositoryInvalidConsensus,
+			"valid accounts: %d, min_size: %d, reasons: %s", len(acs), minSize, strings.Join(reasons, ", "))
+	}
+	return acs, nil
+}
+
+// consensusMinSize は consensus prosl の Storage に保存された Block 生成者の最小数を返す。
+// 保存されていない Chain では 1 とする
+func (r *Repository) consensusMinSize(wsv core.WSV) int64 {
+	st := r.fc.NewEmptyStorage()
+	if err := wsv.Query(model.MustAddress(r.conf.Prosl.Consensus.Id), st); err != nil {
+		return 1
+	}
+	if minSize := st.GetFromKey(core.ConsensusMinSizeKey).GetI64(); minSize > 1 {
+		return minSize
+	}
+	return 1
+}
+
+func (r *Repository) executeProsl(wsv core.WSV, top model.Block, data []byte, check proslChecker) (model.Object, error) {
+	pr, err := r.compileProsl(data)
+	if err != nil {
+		return nil, err
+	}
+	ret, vars, err := pr.Execute(wsv, top)
+	if err != nil {
+		return nil, fmt.Errorf("errors: %s\nvariables: %+v\n", err.Error(), vars)
+	}
+	if err := check(wsv, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// fallbackActivated は prosl の実行失敗の記録と過去の version による代替が有効化されているかを返す
+func (r *Repository) fallbackActivated(wsv core.WSV) bool {
+	return core.Activated(wsv, r.fc.NewEmptyStorage(), core.ProslFallbackFeature)
+}
+
+// executeProslWithFallback は id の prosl を実行する。
+// 失敗した場合は履歴の新しい version から順に実行し、最初に成功した version の結果を返す。
+// 全ての version が失敗した場合は nil と fallback_version が ProslFallbackDefault の proslFailure を返す。
+// 有効化前は過去の version で代替せず、失敗したら nil と proslFailure を返す
+func (r *Repository) executeProslWithFallback(wsv core.WSV, top model.Block, id string, check proslChecker) (model.Object, *proslFailure, error) {
+	proSt := r.fc.NewEmptyStorage()
+	if err := wsv.Query(model.MustAddress(id), proSt); err != nil {
+		return nil, nil, err
+	}
+	current := proSt.GetFromKey(core.ProslKey).GetData()
+	ret, err := r.executeProsl(wsv, top, current, check)
+	if err == nil {
+		return ret, nil, nil
+	}
+	failure := &proslFailure{err, core.ProslFallbackDefault}
+	if !r.fallbackActivated(wsv) {
+		return nil, failure, nil
+	}
+
+	histSt := r.fc.NewEmptyStorage()
+	if err := wsv.Query(model.MustAddress(core.ProslHistoryId(id)), histSt); err != nil {
+		if errors.Cause(err) != core.ErrWSVNotFound {
+			return nil, nil, err
+		}
+		return nil, failure, nil
+	}
+	versions := histSt.GetFromKey(core.ProslVersionsKey).GetList()
+	for i := len(versions) - 1; i >= 0; i-- {
+		v := versions[i].GetDict()
+		data := v[core.ProslKey].GetData()
+		if bytes.Equal(data, current) {
+			continue
+		}
+		if ret, err := r.executeProsl(wsv, top, data, check); err == nil {
+			failure.version = v[core.ProslVersionKey].GetI64()
+			return ret, failure, nil
+		}
+	}
+	return nil, failure, nil
+}
+
+// recordProslFailure は prosl の実行失敗を log に出力し、id の Storage に記録する。
+// 有効化前の Chain では Storage に記録しない
+func (r *Repository) recordProslFailure(wsv core.WSV, id string, height int64, failure *proslFailure) error {
+	r.logger.Warn("Prosl Error", "id", id, "height", height, "fallback_version", failure.version, "err", failure.err.Error())
+	r.metrics.add(id, failure)
+	if !r.fallbackActivated(wsv) {
+		return nil
+	}
+
+	proSt := r.fc.NewEmptyStorage()
+	if err := wsv.Query(model.MustAddress(id), proSt); err != nil {
+		return err
+	}
+	newSt := r.fc.NewStorageBuilder().
+		From(proSt).
+		Int64(core.ProslFailureCountKey, proSt.GetFromKey(core.ProslFailureCountKey).GetI64()+1).
+		Int64(core.ProslFailureHeightKey, height).
+		Int64(core.ProslFallbackVersionKey, failure.version).
+		Build()
+	return wsv.Append(model.MustAddress(id), newSt)
+}
+
+// checkProslConsensus は top の次の Block 生成者を決める consensus prosl を実行し、失敗したら記録する
+func (r *Repository) checkProslConsensus(wsv core.WSV, top model.Block, height int64) error {
+	_, failure, err := r.executeProslConsensus(wsv, top)
+	if err != nil {
+		if errors.Cause(err) == core.ErrWSVNotFound {
+			return nil
+		}
+		return err
+	}
+	if failure == nil {
+		return nil
+	}
+	return r.recordProslFailure(wsv, r.conf.Prosl.Consensus.Id, height, failure)
+}
+
+// defaultDelegatedAccounts は consensus prosl の全 version が失敗した場合の built-in の Block 生成順。
+// active かつ ban されていない Peer (active な Peer が無ければ ban されていない Peer) を PeerId 順に並べる
+func (r *Repository) defaultDelegatedAccounts(wsv core.WSV) ([]model.Account, error) {
+	ps, err := wsv.PeerService()
+	if err != nil {
+		return nil, err
+	}
+	actives := make([]model.Peer, 0)
+	unbans := make([]model.Peer, 0)
+	for _, peer := range ps.List() {
+		if peer.GetBan() {
+			continue
+		}
+		unbans = append(unbans, peer)
+		if peer.GetActive() {
+			actives = append(actives, peer)
+		}
+	}
+	peers := actives
+	if len(peers) == 0 {
+		peers = unbans
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].GetPeerId() < peers[j].GetPeerId()
+	})
+	acs := make([]model.Account, 0, len(peers))
+	for _, peer := range peers {
+		acs = append(acs, r.fc.NewAccountBuilder().DelegatePeerId(peer.GetPeerId()).Build())
+	}
+	return acs, nil
+}
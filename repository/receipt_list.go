@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"github.com/proskenion/proskenion/core"
+	"github.com/proskenion/proskenion/core/model"
+	"github.com/proskenion/proskenion/datastructure"
+)
+
+type ReceiptList struct {
+	tree     core.MerkleTree
+	receipts []model.Receipt
+}
+
+func NewReceiptList(cryptor core.Cryptor) core.ReceiptList {
+	return &ReceiptList{datastructure.NewAccumulateHash(cryptor), make([]model.Receipt, 0)}
+}
+
+func (r *ReceiptList) Push(receipt model.Receipt) error {
+	r.receipts = append(r.receipts, receipt)
+	return r.tree.Push(receipt)
+}
+
+func (r *ReceiptList) Hash() model.Hash {
+	return r.tree.Hash()
+}
+
+func (r *ReceiptList) List() []model.Receipt {
+	return r.receipts
+}
@@ -3,10 +3,12 @@ package repository
 import (
 	"bytes"
 	"fmt"
+	"github.com/inconshreveable/log15"
 	"github.com/pkg/errors"
 	"github.com/proskenion/proskenion/config"
 	"github.com/proskenion/proskenion/core"
 	"github.com/proskenion/proskenion/core/model"
+	"github.com/proskenion/proskenion/datastructure"
 	"github.com/proskenion/proskenion/prosl"
 	"io/ioutil"
 )
@@ -21,6 +23,14 @@ type Repository struct {
 
 	TopBlock model.Block
 	Height   int64
+
+	metrics        *proslMetrics
+	notifier       *commitNotifier
+	txStatus       *txStatusStore
+	proslCache     core.CacheMap
+	consensusCache core.CacheMap
+
+	logger log15.Logger
 }
 
 type PeerWithPriKey struct {
@@ -40,7 +50,9 @@ func NewRepository(dba core.DBA, cryptor core.Cryptor, fc model.ModelFactory, co
 	if conf.Peer.Active {
 		me.Activate()
 	}
-	return &Repository{dba, cryptor, fc, me, conf, nil, 0}
+	return &Repository{dba, cryptor, fc, me, conf, nil, 0, newProslMetrics(), newCommitNotifier(), newTxStatusStore(conf),
+		datastructure.NewCacheMap(proslCacheLimits), datastructure.NewCacheMap(consensusCacheLimits),
+		log15.New("peerId", conf.Peer.Id, "module", "repository")}
 }
 
 func (r *Repository) Begin() (core.RepositoryTx, error) {
@@ -80,37 +92,76 @@ func (r *Repository) TopWSV() (core.WSV, error) {
 	return wsv, err
 }
 
-func (r *Repository) GetDelegatedAccounts() ([]model.Account, error) {
+// WSVAt は blockHash の Block を commit した時点の WSV を取得する
+// 過去の MerklePatriciaTree の node は DB に残っているので、Block の wsvHash を root にして開ける
+func (r *Repository) WSVAt(blockHash model.Hash) (core.WSV, error) {
 	top, ok := r.Top()
 	if !ok {
-		panic("Failed Repository error empty top")
+		return nil, errors.Wrap(core.ErrBlockchainNotFound, "empty blockchain")
 	}
-	wsv, err := r.TopWSV()
+	rtx, err := r.Begin()
 	if err != nil {
 		return nil, err
 	}
-	defer core.CommitTx(wsv)
-	st := proslStorage(r.fc)
-	id := model.MustAddress(r.conf.Prosl.Consensus.Id)
-	if err := wsv.Query(id, st); err != nil {
+	bc, err := rtx.Blockchain(top.Hash())
+	if err != nil {
+		return nil, core.RollBackTx(rtx, err)
+	}
+	block, err := bc.Get(blockHash)
+	if err != nil {
+		return nil, core.RollBackTx(rtx, err)
+	}
+	wsv, err := rtx.WSV(block.GetPayload().GetWSVHash())
+	if err != nil {
+		return nil, core.RollBackTx(rtx, err)
+	}
+	return wsv, nil
+}
+
+// BlockHashAt は height の Block の hash を index から取得する
+func (r *Repository) BlockHashAt(height int64) (model.Hash, error) {
+	top, ok := r.Top()
+	if !ok {
+		return nil, errors.Wrap(core.ErrBlockchainNotFound, "empty blockchain")
+	}
+	rtx, err := r.Begin()
+	if err != nil {
 		return nil, err
 	}
+	bc, err := rtx.Blockchain(top.Hash())
+	if err != nil {
+		return nil, core.RollBackTx(rtx, err)
+	}
+	block, err := bc.GetByHeight(height)
+	if err != nil {
+		return nil, core.RollBackTx(rtx, err)
+	}
+	if err := rtx.Commit(); err != nil {
+		return nil, err
+	}
+	return block.Hash(), nil
+}
 
-	prData := st.GetFromKey(core.ProslKey).GetData()
-	pr := prosl.NewProsl(r.fc, r.cryptor, r.conf)
-	if err := pr.Unmarshal(prData); err != nil {
+func (r *Repository) GetDelegatedAccounts() ([]model.Account, error) {
+	top, ok := r.Top()
+	if !ok {
+		panic("Failed Repository error empty top")
+	}
+	wsv, err := r.TopWSV()
+	if err != nil {
 		return nil, err
 	}
-	ret, vars, err := pr.Execute(wsv, top)
+	defer core.CommitTx(wsv)
+
+	// 失敗した場合は過去の version、それも失敗したら built-in default の順で Block を生成する
+	ret, _, err := r.executeProslConsensus(wsv, top)
 	if err != nil {
-		return nil, fmt.Errorf("errors: %s\nvariables: %+v\n", err.Error(), vars)
+		return nil, err
 	}
-	list := ret.GetList()
-	acs := make([]model.Account, 0, len(list))
-	for _, ac := range list {
-		acs = append(acs, ac.GetAccount())
+	if ret == nil {
+		return r.defaultDelegatedAccounts(wsv)
 	}
-	return acs, nil
+	return r.normalizeDelegatedAccounts(wsv, ret)
 }
 
 func (r *Repository) loadMPTrees(dtx core.RepositoryTx, preBlock model.Block, preBlockHash model.Hash) (core.Blockchain, core.WSV, core.TxHistory, model.Block, error) {
@@ -135,37 +186,112 @@ func (r *Repository) loadMPTrees(dtx core.RepositoryTx, preBlock model.Block, pr
 	return bc, wsv, txHistory, preBlock, nil
 }
 
-// Incentive Prosl exeucute (fource execute)
-func (r *Repository) executeProslIncentive(wsv core.WSV, top model.Block) error {
-	// 1. get prosl
-	proSt := r.fc.NewEmptyStorage()
-	if err := wsv.Query(model.MustAddress(r.conf.Prosl.Incentive.Id), proSt); err != nil {
-		return nil
+// activatePendingProsl は activation_height が height 以下の pending_prosl を prosl に切り替える
+// 全ての Peer が Block の先頭で同じ様に切り替えるので、Block の途中で Prosl が変わることはない
+func (r *Repository) activatePendingProsl(wsv core.WSV, height int64) error {
+	for _, id := range []string{
+		r.conf.Prosl.Incentive.Id,
+		r.conf.Prosl.Consensus.Id,
+		r.conf.Prosl.Update.Id,
+	} {
+		proSt := r.fc.NewEmptyStorage()
+		if err := wsv.Query(model.MustAddress(id), proSt); err != nil {
+			if errors.Cause(err) == core.ErrWSVNotFound {
+				continue
+			}
+			return err
+		}
+		pending := proSt.GetFromKey(core.PendingProslKey).GetData()
+		if len(pending) == 0 || proSt.GetFromKey(core.ActivationHeightKey).GetI64() > height {
+			continue
+		}
+		txHash := proSt.GetFromKey(core.PendingTxHashKey).GetData()
+		newSt := r.fc.NewStorageBuilder().
+			From(proSt).
+			Data(core.ProslKey, pending).
+			Data(core.PendingProslKey, nil).
+			Int64(core.ActivationHeightKey, 0).
+			Data(core.PendingTxHashKey, nil).
+			Build()
+		if err := wsv.Append(model.MustAddress(id), newSt); err != nil {
+			return err
+		}
+		if err := r.appendProslHistory(wsv, id, pending, height, txHash); err != nil {
+			return err
+		}
 	}
-	pr := prosl.NewProsl(r.fc, r.cryptor, r.conf)
-	proslByte := proSt.GetFromKey(core.ProslKey).GetData()
-	if err := pr.Unmarshal(proslByte); err != nil {
+	return nil
+}
+
+// appendProslHistory は id の ProSL の履歴に新しい version を追加する。
+// prosl_upgrade の有効化前の Chain では履歴を残さない
+func (r *Repository) appendProslHistory(wsv core.WSV, id string, proslData []byte, height int64, txHash model.Hash) error {
+	if !core.Activated(wsv, r.fc.NewEmptyStorage(), core.ProslUpgradeFeature) {
 		return nil
 	}
-	// 2. execute incentive prosl
-	ret, vars, err := pr.Execute(wsv, top)
+	histId := model.MustAddress(core.ProslHistoryId(id))
+	histSt := r.fc.NewEmptyStorage()
+	if err := wsv.Query(histId, histSt); err != nil {
+		if errors.Cause(err) != core.ErrWSVNotFound {
+			return err
+		}
+		histSt = r.fc.NewStorageBuilder().Id(histId.Id()).Build()
+	}
+	if _, err := r.compileProsl(proslData); err != nil {
+		return err
+	}
+	versions := histSt.GetFromKey(core.ProslVersionsKey).GetList()
+	versions = append(versions, r.fc.NewObjectBuilder().Dict(map[string]model.Object{
+		core.ProslVersionKey:     r.fc.NewObjectBuilder().Int64(int64(len(versions))),
+		core.ProslHashKey:        r.fc.NewObjectBuilder().Data(r.proslHash(proslData)),
+		core.ProslKey:            r.fc.NewObjectBuilder().Data(proslData),
+		core.ActivationHeightKey: r.fc.NewObjectBuilder().Int64(height),
+		core.TxHashKey:           r.fc.NewObjectBuilder().Data(txHash),
+	}))
+	newSt := r.fc.NewStorageBuilder().
+		From(histSt).
+		List(core.ProslVersionsKey, versions).
+		Build()
+	return wsv.Append(histId, newSt)
+}
+
+// Incentive Prosl exeucute (fource execute)
+// executeProslIncentive は incentive prosl を実行し、返された tx を実行する。
+// incentive prosl と tx の command が発行したイベントは incentive tx の Receipt として返す。incentive tx が無ければ nil を返す
+func (r *Repository) executeProslIncentive(wsv core.WSV, top model.Block) (model.Receipt, error) {
+	// consensus prosl 等が発行したイベントは Receipt に含めない
+	wsv.PopEvents()
+	// 1. execute incentive prosl (prosl_fallback の有効化後は失敗したら過去の version で代替、全て失敗したら incentive なし)
+	ret, failure, err := r.executeProslWithFallback(wsv, top, r.conf.Prosl.Incentive.Id, checkIncentiveProsl)
 	if err != nil {
-		fmt.Printf("Incentive Prosl Error\nvariables: %+v, error: %s\n", vars, err.Error())
-	} else if ret == nil || ret.GetTransaction() == nil {
-		fmt.Printf("Incentive Prosl Error\nvariables: %+v, error: empty incentive tx\n", vars)
-	} else {
-		// 3. execute incentive tx
-		for _, cmd := range ret.GetTransaction().GetPayload().GetCommands() {
-			if err := cmd.Execute(wsv); err != nil {
-				return fmt.Errorf("Incentive Tx Error\n%s\n%+v", err.Error(), ret.GetTransaction())
-			}
+		// incentive prosl の無い Chain では incentive なし
+		if errors.Cause(err) == core.ErrWSVNotFound {
+			return nil, nil
+		}
+		r.logger.Error("Incentive Prosl Error", "height", top.GetPayload().GetHeight()+1, "err", err.Error())
+		return nil, err
+	}
+	if failure != nil {
+		// 2. record failure on chain
+		if err := r.recordProslFailure(wsv, r.conf.Prosl.Incentive.Id, top.GetPayload().GetHeight()+1, failure); err != nil {
+			return nil, err
 		}
-		// TODO prosl execute save to get tx
-		//if err := txHistory.Append(ret.GetTransaction()); err != nil {
-		//	return fmt.Errorf("Incentive Tx Append Error\n%s\n%+v", err.Error(), ret.GetTransaction())
-		//}
 	}
-	return nil
+	if ret == nil {
+		wsv.PopEvents()
+		return nil, nil
+	}
+	// 3. execute incentive tx
+	for _, cmd := range ret.GetTransaction().GetPayload().GetCommands() {
+		if err := cmd.Execute(wsv); err != nil {
+			return nil, fmt.Errorf("Incentive Tx Error\n%s\n%+v", err.Error(), ret.GetTransaction())
+		}
+	}
+	// TODO prosl execute save to get tx
+	//if err := txHistory.Append(ret.GetTransaction()); err != nil {
+	//	return fmt.Errorf("Incentive Tx Append Error\n%s\n%+v", err.Error(), ret.GetTransaction())
+	//}
+	return r.fc.NewReceipt(ret.GetTransaction().Hash(), wsv.PopEvents()), nil
 }
 
 func (r *Repository) appendAndUpdateBlock(bc core.Blockchain, block model.Block) error {
@@ -196,46 +322,104 @@ func (r *Repository) CreateBlock(queue core.ProposalTxQueue, round int32, now in
 		return nil, nil, err
 	}
 
+	wsv.SetExecutingHeight(preBlock.GetPayload().GetHeight() + 1)
+
+	// record failure of consensus prosl which decided this block creator.
+	if err := r.checkProslConsensus(wsv, preBlock, preBlock.GetPayload().GetHeight()+1); err != nil {
+		return nil, nil, core.RollBackTx(dtx, err)
+	}
+
+	// activate pending prosl at this height.
+	if err := r.activatePendingProsl(wsv, preBlock.GetPayload().GetHeight()+1); err != nil {
+		return nil, nil, core.RollBackTx(dtx, err)
+	}
+
 	// execute incentive prosl transaction. (fource execute)
-	if err := r.executeProslIncentive(wsv, preBlock); err != nil {
+	incReceipt, err := r.executeProslIncentive(wsv, preBlock)
+	if err != nil {
 		return nil, nil, core.RollBackTx(dtx, err)
 	}
 
 	txList := NewTxList(r.cryptor, r.fc)
+	receipts := NewReceiptList(r.cryptor)
+	// incentive tx の Receipt は先頭に置く
+	if incReceipt != nil {
+		if err := receipts.Push(incReceipt); err != nil {
+			return nil, nil, core.RollBackTx(dtx, err)
+		}
+	}
+	// validation で落ちた tx の hash と error
+	rejected := make(map[string]error)
 	// ProposalTxQueue から valid な Tx をとってきて hoge る
 	for txList.Size() < r.conf.Commit.NumTxInBlock {
 		tx, ok := queue.Pop()
 		if !ok {
 			break
 		}
+		// Expired になった tx は Block に含めずに捨てる
+		if status, ok := r.txStatus.get(tx.Hash()); ok && status.Code == core.TxStatusExpired {
+			continue
+		}
+		// skip された tx のイベントは Receipt に含めない
+		wsv.PopEvents()
+		wsv.SetExecutingTxHash(tx.Hash())
+		// tx 実行前の WSV の hash
+		preHash := wsv.Hash()
 		// tx を構築
 		if err := tx.Validate(wsv, txHistory); err != nil {
+			rejected[string(tx.Hash())] = err
 			goto txskip
 		}
-		for _, cmd := range tx.GetPayload().GetCommands() {
-			if err := cmd.Validate(wsv); err != nil {
-				goto txskip
+		if core.Activated(wsv, r.fc.NewEmptyStorage(), core.StatefulValidationFeature) {
+			// 各 command は直前の command までを実行した状態で Validate する。
+			// Validate 又は Execute で落ちた tx は実行前の状態に戻して Block に含めない
+			for _, cmd := range tx.GetPayload().GetCommands() {
+				err := cmd.Validate(wsv)
+				if err == nil {
+					err = cmd.Execute(wsv)
+				}
+				if err != nil {
+					if err := wsv.Revert(preHash); err != nil {
+						return nil, nil, core.RollBackTx(dtx, err)
+					}
+					rejected[string(tx.Hash())] = err
+					goto txskip
+				}
 			}
-		}
-		// TODO Validate -> Execute -> Validate とやりたいけどTargetIdの情報だけOnMemoryに取り出して云々やる必要がある。
-		for _, cmd := range tx.GetPayload().GetCommands() {
-			if err := cmd.Execute(wsv); err != nil {
-				return nil, nil, core.RollBackTx(dtx, err)
+		} else {
+			// 有効化前は全ての command を Validate してから Execute する
+			for _, cmd := range tx.GetPayload().GetCommands() {
+				if err := cmd.Validate(wsv); err != nil {
+					rejected[string(tx.Hash())] = err
+					goto txskip
+				}
+			}
+			for _, cmd := range tx.GetPayload().GetCommands() {
+				if err := cmd.Execute(wsv); err != nil {
+					return nil, nil, core.RollBackTx(dtx, err)
+				}
 			}
 		}
 		if err := txList.Push(tx); err != nil {
 			return nil, nil, core.RollBackTx(dtx, err)
 		}
+		if err := receipts.Push(r.fc.NewReceipt(tx.Hash(), wsv.PopEvents())); err != nil {
+			return nil, nil, core.RollBackTx(dtx, err)
+		}
 
 	txskip:
 	}
 	if err := txHistory.Append(txList); err != nil {
 		return nil, nil, core.RollBackTx(dtx, err)
 	}
+	if err := txHistory.AppendReceipts(receipts); err != nil {
+		return nil, nil, core.RollBackTx(dtx, err)
+	}
 
 	newBlock := r.fc.NewBlockBuilder().
 		Round(round).
 		TxListHash(txList.Hash()).
+		ReceiptsHash(receipts.Hash()).
 		TxHistoryHash(txHistory.Hash()).
 		WSVHash(wsv.Hash()).
 		CreatedTime(now).
@@ -250,7 +434,7 @@ func (r *Repository) CreateBlock(queue core.ProposalTxQueue, round int32, now in
 	if err := r.appendAndUpdateBlock(bc, newBlock); err != nil {
 		return nil, nil, core.RollBackTx(dtx, err)
 	}
-	return newBlock, txList, core.CommitTx(dtx)
+	return newBlock, txList, r.commitBlock(dtx, newBlock, txList, rejected)
 }
 
 func (r *Repository) Commit(block model.Block, txList core.TxList) (err error) {
@@ -266,33 +450,77 @@ func (r *Repository) Commit(block model.Block, txList core.TxList) (err error) {
 		return err
 	}
 
+	wsv.SetExecutingHeight(block.GetPayload().GetHeight())
+
+	// record failure of consensus prosl which decided this block creator.
+	if err := r.checkProslConsensus(wsv, preBlock, block.GetPayload().GetHeight()); err != nil {
+		return core.RollBackTx(dtx, err)
+	}
+
+	// activate pending prosl at this height.
+	if err := r.activatePendingProsl(wsv, block.GetPayload().GetHeight()); err != nil {
+		return core.RollBackTx(dtx, err)
+	}
+
 	// Incentive Prosl exeucute (fource execute)
-	if err := r.executeProslIncentive(wsv, preBlock); err != nil {
+	incReceipt, err := r.executeProslIncentive(wsv, preBlock)
+	if err != nil {
 		return core.RollBackTx(dtx, err)
 	}
 
 	// transactions execute
+	receipts := NewReceiptList(r.cryptor)
+	if incReceipt != nil {
+		if err := receipts.Push(incReceipt); err != nil {
+			return core.RollBackTx(dtx, err)
+		}
+	}
 	for _, tx := range txList.List() {
+		wsv.PopEvents()
+		wsv.SetExecutingTxHash(tx.Hash())
 		if err := tx.Validate(wsv, txHistory); err != nil {
 			return core.RollBackTx(dtx, err)
 		}
-		for _, cmd := range tx.GetPayload().GetCommands() {
-			if err := cmd.Validate(wsv); err != nil {
-				return core.RollBackTx(dtx, err)
+		// CreateBlock と同一条件下で実行する
+		if core.Activated(wsv, r.fc.NewEmptyStorage(), core.StatefulValidationFeature) {
+			for _, cmd := range tx.GetPayload().GetCommands() {
+				if err := cmd.Validate(wsv); err != nil {
+					return core.RollBackTx(dtx, err)
+				}
+				if err := cmd.Execute(wsv); err != nil {
+					return core.RollBackTx(dtx, err)
+				}
 			}
-		}
-		// TODO CreateBlock と同一条件下で実行しなければならないので
-		for _, cmd := range tx.GetPayload().GetCommands() {
-			if err := cmd.Execute(wsv); err != nil {
-				return core.RollBackTx(dtx, err)
+		} else {
+			for _, cmd := range tx.GetPayload().GetCommands() {
+				if err := cmd.Validate(wsv); err != nil {
+					return core.RollBackTx(dtx, err)
+				}
 			}
+			for _, cmd := range tx.GetPayload().GetCommands() {
+				if err := cmd.Execute(wsv); err != nil {
+					return core.RollBackTx(dtx, err)
+				}
+			}
+		}
+		if err := receipts.Push(r.fc.NewReceipt(tx.Hash(), wsv.PopEvents())); err != nil {
+			return core.RollBackTx(dtx, err)
 		}
 	}
 	if err := txHistory.Append(txList); err != nil {
 		return core.RollBackTx(dtx, err)
 	}
+	if err := txHistory.AppendReceipts(receipts); err != nil {
+		return core.RollBackTx(dtx, err)
+	}
 
 	// hash check
+	// receiptsHash は機能が有効化された Block から検証する。それ以前の Block は receiptsHash を持たない
+	if core.Activated(wsv, r.fc.NewEmptyStorage(), core.ReceiptsFeature) &&
+		!bytes.Equal(block.GetPayload().GetReceiptsHash(), receipts.Hash()) {
+		return core.RollBackTx(dtx,
+			errors.Wrapf(core.ErrRepositoryReceiptsHash, "expected: %x, actual: %x", block.GetPayload().GetReceiptsHash(), receipts.Hash()))
+	}
 	if !bytes.Equal(block.GetPayload().GetTxHistoryHash(), txHistory.Hash()) {
 		return core.RollBackTx(dtx,
 			errors.Errorf("not equaled txHistory Hash, expected: %x, actual: %x", block.GetPayload().GetTxHistoryHash(), txHistory.Hash()))
@@ -306,13 +534,19 @@ func (r *Repository) Commit(block model.Block, txList core.TxList) (err error) {
 	if err := r.appendAndUpdateBlock(bc, block); err != nil {
 		return core.RollBackTx(dtx, err)
 	}
-	return core.CommitTx(dtx)
+	return r.commitBlock(dtx, block, txList, nil)
 }
 
 func proslStorage(fc model.ModelFactory) model.Storage {
 	return fc.NewStorageBuilder().
 		Data(core.ProslKey, nil).
 		Str(core.ProslTypeKey, "none").
+		Data(core.PendingProslKey, nil).
+		Int64(core.ActivationHeightKey, 0).
+		Data(core.PendingTxHashKey, nil).
+		Int64(core.ProslFailureCountKey, 0).
+		Int64(core.ProslFailureHeightKey, 0).
+		Int64(core.ProslFallbackVersionKey, 0).
 		Build()
 }
 
@@ -327,6 +561,15 @@ func (r *Repository) getProslBytes(filename string, pr core.Prosl) ([]byte, erro
 	return pr.Marshal()
 }
 
+// ActivationStorage は新しい Chain で全ての機能を genesis から有効にする Storage
+func ActivationStorage(fc model.ModelFactory) model.Storage {
+	builder := fc.NewStorageBuilder()
+	for _, feature := range core.Features {
+		builder = builder.Set(feature, fc.NewObjectBuilder().Bool(true))
+	}
+	return builder.Build()
+}
+
 func (r *Repository) genesisProslSetting() (model.Transaction, error) {
 	proSt := proslStorage(r.fc)
 	pr := prosl.NewProsl(r.fc, r.cryptor, r.conf)
@@ -359,6 +602,10 @@ func (r *Repository) genesisProslSetting() (model.Transaction, error) {
 			r.fc.NewObjectBuilder().Str(core.ConsensusKey)).
 		UpdateObject(r.conf.Root.Id, r.conf.Prosl.Update.Id, core.ProslTypeKey,
 			r.fc.NewObjectBuilder().Str(core.UpdateKey)).
+		UpdateObject(r.conf.Root.Id, r.conf.Prosl.Consensus.Id, core.ConsensusMinSizeKey,
+			r.fc.NewObjectBuilder().Int64(int64(r.conf.Prosl.Consensus.MinSize))).
+		DefineStorage(r.conf.Root.Id, core.ActivationStorageId, ActivationStorage(r.fc)).
+		CreateStorage(r.conf.Root.Id, core.ActivationId).
 		CreatedTime(0).
 		Build(), nil
 }
@@ -394,16 +641,45 @@ func (r *Repository) GenesisCommit(txList core.TxList) (err error) {
 	}
 
 	// transactions execute (no validate)
+	receipts := NewReceiptList(r.cryptor)
 	for _, tx := range txList.List() {
+		wsv.SetExecutingTxHash(tx.Hash())
 		for _, cmd := range tx.GetPayload().GetCommands() {
 			if err := cmd.Execute(wsv); err != nil {
 				return core.RollBackTx(dtx, err)
 			}
 		}
+		if err := receipts.Push(r.fc.NewReceipt(tx.Hash(), wsv.PopEvents())); err != nil {
+			return core.RollBackTx(dtx, err)
+		}
+	}
+	// genesis の ProSL を履歴の version 0 とする
+	for _, id := range []string{
+		r.conf.Prosl.Incentive.Id,
+		r.conf.Prosl.Consensus.Id,
+		r.conf.Prosl.Update.Id,
+	} {
+		proSt := r.fc.NewEmptyStorage()
+		if err := wsv.Query(model.MustAddress(id), proSt); err != nil {
+			if errors.Cause(err) == core.ErrWSVNotFound {
+				continue
+			}
+			return core.RollBackTx(dtx, err)
+		}
+		pr := proSt.GetFromKey(core.ProslKey).GetData()
+		if pr == nil {
+			continue
+		}
+		if err := r.appendProslHistory(wsv, id, pr, 0, genTx.Hash()); err != nil {
+			return core.RollBackTx(dtx, err)
+		}
 	}
 	if err := txHistory.Append(txList); err != nil {
 		return core.RollBackTx(dtx, err)
 	}
+	if err := txHistory.AppendReceipts(receipts); err != nil {
+		return core.RollBackTx(dtx, err)
+	}
 
 	// hash check and block 生成
 	wsvHash := wsv.Hash()
@@ -417,6 +693,7 @@ func (r *Repository) GenesisCommit(txList core.TxList) (err error) {
 	genesisBlock := r.fc.NewBlockBuilder().
 		CreatedTime(0).
 		TxListHash(txList.Hash()).
+		ReceiptsHash(receipts.Hash()).
 		PreBlockHash(nil).
 		TxHistoryHash(txHistoryHash).
 		WSVHash(wsvHash).
@@ -431,7 +708,24 @@ func (r *Repository) GenesisCommit(txList core.TxList) (err error) {
 	// top ブロックを更新
 	r.Height = genesisBlock.GetPayload().GetHeight()
 	r.TopBlock = genesisBlock
-	return core.CommitTx(dtx)
+	return r.commitBlock(dtx, genesisBlock, txList, nil)
+}
+
+// commitBlock は dtx を Commit し、成功すれば Transaction の status を更新して Block を購読者に通知する
+func (r *Repository) commitBlock(dtx core.RepositoryTx, block model.Block, txList core.TxList, rejected map[string]error) error {
+	if err := core.CommitTx(dtx); err != nil {
+		return err
+	}
+	height := block.GetPayload().GetHeight()
+	for _, tx := range txList.List() {
+		r.txStatus.set(tx.Hash(), core.TxStatus{Code: core.TxStatusCommitted, Height: height})
+	}
+	for hash, err := range rejected {
+		r.txStatus.set(model.Hash(hash), core.TxStatus{Code: core.TxStatusRejected, Height: height, Reason: err.Error()})
+	}
+	r.txStatus.update(height)
+	r.notifier.notify(block)
+	return nil
 }
 
 type RepositoryTx struct {
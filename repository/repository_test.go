@@ -1,6 +1,7 @@
 package repository_test
 
 import (
+	"github.com/pkg/errors"
 	"github.com/proskenion/proskenion/core"
 	"github.com/proskenion/proskenion/core/model"
 	. "github.com/proskenion/proskenion/repository"
@@ -66,3 +67,440 @@ func TestRepository_GetDelegatedAccounts(t *testing.T) {
 	assert.Equal(t, "root@peer", acs[0].GetDelegatePeerId())
 	assert.Equal(t, "root@peer", acs[1].GetDelegatePeerId())
 }
+
+func TestRepository_Receipts(t *testing.T) {
+	fc := RandomFactory()
+	rp := NewRepository(RandomDBA(), RandomCryptor(), fc, RandomConfig())
+	require.NoError(t, rp.GenesisCommit(RandomGenesisTxList(t)))
+
+	accountId := RandomStr() + "@com"
+	tx := fc.NewTxBuilder().
+		CreateAccount("authorizer@com", accountId, []model.PublicKey{}, 0).
+		AddBalance("authorizer@com", accountId, 100).
+		CreatedTime(RandomNow()).Build()
+	queue := RandomQueue()
+	require.NoError(t, queue.Push(tx))
+	newBlock, newTxList, err := rp.CreateBlock(queue, 0, RandomNow())
+	require.NoError(t, err)
+
+	// incentive prosl (test_utils/incentive.yaml) の tx の Receipt が先頭に入る
+	incTx := fc.NewTxBuilder().
+		AddBalance("root@com", "incentive@com", 10000).
+		CreatedTime(0).Build()
+	receipts := NewReceiptList(RandomCryptor())
+	require.NoError(t, receipts.Push(fc.NewReceipt(incTx.Hash(), []model.Event{
+		fc.NewEvent("add_balance", "root@com", "incentive@com",
+			map[string]model.Object{"balance": fc.NewObjectBuilder().Int64(10000)}),
+	})))
+	require.NoError(t, receipts.Push(fc.NewReceipt(tx.Hash(), []model.Event{
+		fc.NewEvent("create_account", "authorizer@com", accountId,
+			map[string]model.Object{"quorum": fc.NewObjectBuilder().Int32(0)}),
+		fc.NewEvent("add_balance", "authorizer@com", accountId,
+			map[string]model.Object{"balance": fc.NewObjectBuilder().Int64(100)}),
+	})))
+	assert.Equal(t, receipts.Hash(), newBlock.GetPayload().GetReceiptsHash())
+
+	rtx, err := rp.Begin()
+	require.NoError(t, err)
+	txHistory, err := rtx.TxHistory(newBlock.GetPayload().GetTxHistoryHash())
+	require.NoError(t, err)
+	receipt, err := txHistory.GetReceipt(tx.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, receipts.List()[1].Hash(), receipt.Hash())
+	events := receipt.GetEvents()
+	require.Equal(t, 2, len(events))
+	assert.Equal(t, "add_balance", events[1].GetType())
+	assert.Equal(t, int64(100), events[1].GetAttributes()["balance"].GetI64())
+
+	incReceipt, err := txHistory.GetReceipt(incTx.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, receipts.List()[0].Hash(), incReceipt.Hash())
+
+	_, err = txHistory.GetReceipt(RandomByte())
+	assert.EqualError(t, errors.Cause(err), core.ErrTxHistoryReceiptNotFound.Error())
+	require.NoError(t, rtx.Commit())
+
+	// receipts hash が異なる block は commit できない
+	rp2 := NewRepository(RandomDBA(), RandomCryptor(), fc, RandomConfig())
+	require.NoError(t, rp2.GenesisCommit(RandomGenesisTxList(t)))
+	invalidBlock := fc.NewBlockBuilder().
+		Round(newBlock.GetPayload().GetRound()).
+		TxListHash(newBlock.GetPayload().GetTxListHash()).
+		ReceiptsHash(RandomByte()).
+		TxHistoryHash(newBlock.GetPayload().GetTxHistoryHash()).
+		WSVHash(newBlock.GetPayload().GetWSVHash()).
+		CreatedTime(newBlock.GetPayload().GetCreatedTime()).
+		Height(newBlock.GetPayload().GetHeight()).
+		PreBlockHash(newBlock.GetPayload().GetPreBlockHash()).
+		Build()
+	err = rp2.Commit(invalidBlock, newTxList)
+	assert.EqualError(t, errors.Cause(err), core.ErrRepositoryReceiptsHash.Error())
+	require.NoError(t, rp2.Commit(newBlock, newTxList))
+}
+
+func TestRepository_ReceiptsNotActivated(t *testing.T) {
+	fc := RandomFactory()
+	conf := RandomConfig()
+	rp := NewRepository(RandomDBA(), RandomCryptor(), fc, conf)
+	require.NoError(t, rp.GenesisCommit(RandomGenesisTxList(t)))
+	// receipts の検証を有効化していない Chain
+	CommitTxWrapBlock(t, rp, fc, fc.NewTxBuilder().
+		UpdateObject(conf.Root.Id, core.ActivationId, core.ReceiptsFeature, fc.NewObjectBuilder().Bool(false)).
+		Build())
+
+	queue := RandomQueue()
+	require.NoError(t, queue.Push(fc.NewTxBuilder().
+		CreateAccount("authorizer@com", RandomStr()+"@com", []model.PublicKey{}, 0).
+		CreatedTime(RandomNow()).Build()))
+	newBlock, newTxList, err := rp.CreateBlock(queue, 0, RandomNow())
+	require.NoError(t, err)
+
+	// receiptsHash が異なっても Commit できる
+	forkBlock := fc.NewBlockBuilder().
+		Round(newBlock.GetPayload().GetRound()).
+		TxListHash(newBlock.GetPayload().GetTxListHash()).
+		ReceiptsHash(RandomByte()).
+		TxHistoryHash(newBlock.GetPayload().GetTxHistoryHash()).
+		WSVHash(newBlock.GetPayload().GetWSVHash()).
+		CreatedTime(newBlock.GetPayload().GetCreatedTime()).
+		Height(newBlock.GetPayload().GetHeight()).
+		PreBlockHash(newBlock.GetPayload().GetPreBlockHash()).
+		Build()
+	assert.NoError(t, rp.Commit(forkBlock, newTxList))
+}
+
+func TestRepository_CreateBlock_RejectInvalidCommand(t *testing.T) {
+	fc := RandomFactory()
+	rp := NewRepository(RandomDBA(), RandomCryptor(), fc, RandomConfig())
+	require.NoError(t, rp.GenesisCommit(RandomGenesisTxList(t)))
+
+	// 2 番目の command が Validate で落ちるので 1 番目の command の実行も取り消される
+	accountId := RandomStr() + "@com"
+	rejected := fc.NewTxBuilder().
+		CreateAccount("authorizer@com", accountId, []model.PublicKey{}, 0).
+		TransferBalance("authorizer@com", accountId, "authorizer@com", 100).
+		CreatedTime(RandomNow()).Build()
+	committed := fc.NewTxBuilder().
+		CreateAccount("authorizer@com", accountId, []model.PublicKey{}, 0).
+		AddBalance("authorizer@com", accountId, 100).
+		CreatedTime(RandomNow()).Build()
+	queue := RandomQueue()
+	require.NoError(t, queue.Push(rejected))
+	require.NoError(t, queue.Push(committed))
+	newBlock, newTxList, err := rp.CreateBlock(queue, 0, RandomNow())
+	require.NoError(t, err)
+	require.Equal(t, 1, newTxList.Size())
+	assert.Equal(t, committed.Hash(), newTxList.List()[0].Hash())
+
+	rtx, err := rp.Begin()
+	require.NoError(t, err)
+	wsv, err := rtx.WSV(newBlock.GetPayload().GetWSVHash())
+	require.NoError(t, err)
+	ac := fc.NewEmptyAccount()
+	require.NoError(t, wsv.Query(model.MustAddress(accountId+"/"+model.AccountStorageName), ac))
+	assert.Equal(t, int64(100), ac.GetBalance())
+	require.NoError(t, rtx.Commit())
+
+	// 同じ Block を他の Peer でも Commit できる
+	rp2 := NewRepository(RandomDBA(), RandomCryptor(), fc, RandomConfig())
+	require.NoError(t, rp2.GenesisCommit(RandomGenesisTxList(t)))
+	require.NoError(t, rp2.Commit(newBlock, newTxList))
+	sameRepositoryTop(t, rp2, newBlock)
+}
+
+func TestRepository_CreateBlock_RejectFailedCommand(t *testing.T) {
+	fc := RandomFactory()
+	rp := NewRepository(RandomDBA(), RandomCryptor(), fc, RandomConfig())
+	require.NoError(t, rp.GenesisCommit(RandomGenesisTxList(t)))
+	CommitTxWrapBlock(t, rp, fc, fc.NewTxBuilder().
+		DefineStorage("authorizer@com", "/broken", fc.NewStorageBuilder().
+			Data(core.ProslKey, []byte{0xff, 0xff, 0xff}).Build()).
+		CreateStorage("authorizer@com", "authorizer@com/broken").
+		Build())
+
+	// 2 番目の command は Validate を通るが Execute で落ちるので、1 番目の command の実行も取り消される
+	accountId := RandomStr() + "@com"
+	rejected := fc.NewTxBuilder().
+		CreateAccount("authorizer@com", accountId, []model.PublicKey{}, 0).
+		InvokeProsl("authorizer@com", "authorizer@com/broken", nil).
+		CreatedTime(RandomNow()).Build()
+	committed := fc.NewTxBuilder().
+		CreateAccount("authorizer@com", accountId, []model.PublicKey{}, 0).
+		AddBalance("authorizer@com", accountId, 100).
+		CreatedTime(RandomNow()).Build()
+	queue := RandomQueue()
+	require.NoError(t, queue.Push(rejected))
+	require.NoError(t, queue.Push(committed))
+	newBlock, newTxList, err := rp.CreateBlock(queue, 0, RandomNow())
+	require.NoError(t, err)
+	require.Equal(t, 1, newTxList.Size())
+	assert.Equal(t, committed.Hash(), newTxList.List()[0].Hash())
+
+	wsv, err := rp.TopWSV()
+	require.NoError(t, err)
+	ac := fc.NewEmptyAccount()
+	require.NoError(t, wsv.Query(model.MustAddress(accountId+"/"+model.AccountStorageName), ac))
+	assert.Equal(t, int64(100), ac.GetBalance())
+	require.NoError(t, core.CommitTx(wsv))
+	assert.Equal(t, newBlock.Hash(), MusTop(rp).Hash())
+}
+
+func TestRepository_CreateBlock_StatefulNotActivated(t *testing.T) {
+	fc := RandomFactory()
+	conf := RandomConfig()
+	rp := NewRepository(RandomDBA(), RandomCryptor(), fc, conf)
+	require.NoError(t, rp.GenesisCommit(RandomGenesisTxList(t)))
+	// Command 毎の Validate, Execute を有効化していない Chain
+	CommitTxWrapBlock(t, rp, fc, fc.NewTxBuilder().
+		UpdateObject(conf.Root.Id, core.ActivationId, core.StatefulValidationFeature, fc.NewObjectBuilder().Bool(false)).
+		DefineStorage("authorizer@com", "/broken", fc.NewStorageBuilder().
+			Data(core.ProslKey, []byte{0xff, 0xff, 0xff}).Build()).
+		CreateStorage("authorizer@com", "authorizer@com/broken").
+		Build())
+
+	// 有効化前は Execute で落ちた tx を取り除かずに Block の作成に失敗する
+	queue := RandomQueue()
+	require.NoError(t, queue.Push(fc.NewTxBuilder().
+		CreateAccount("authorizer@com", RandomStr()+"@com", []model.PublicKey{}, 0).
+		InvokeProsl("authorizer@com", "authorizer@com/broken", nil).
+		CreatedTime(RandomNow()).Build()))
+	_, _, err := rp.CreateBlock(queue, 0, RandomNow())
+	assert.Error(t, err)
+}
+
+func TestRepository_ActivatePendingProsl(t *testing.T) {
+	fc := RandomFactory()
+	conf := RandomConfig()
+	rp := NewRepository(RandomDBA(), RandomCryptor(), fc, conf)
+	require.NoError(t, rp.GenesisCommit(RandomGenesisTxList(t)))
+
+	consensusId := model.MustAddress(conf.Prosl.Consensus.Id)
+	queryConsensus := func() model.Storage {
+		wsv, err := rp.TopWSV()
+		require.NoError(t, err)
+		defer core.CommitTx(wsv)
+		st := fc.NewEmptyStorage()
+		require.NoError(t, wsv.Query(consensusId, st))
+		return st
+	}
+	oldPr := queryConsensus().GetFromKey(core.ProslKey).GetData()
+	newPr := ConvertYamlFileToProtoBinary(t, "../test_utils/new_consensus.yaml")
+
+	// height 1 : height 3 で有効になる pending prosl を保存
+	CommitTxWrapBlock(t, rp, fc, fc.NewTxBuilder().
+		UpdateObject(conf.Root.Id, consensusId.Id(), core.PendingProslKey, fc.NewObjectBuilder().Data(newPr)).
+		UpdateObject(conf.Root.Id, consensusId.Id(), core.ActivationHeightKey, fc.NewObjectBuilder().Int64(3)).
+		Build())
+
+	// height 2 : まだ切り替わらない
+	_, _, err := rp.CreateBlock(RandomQueue(), 0, RandomNow())
+	require.NoError(t, err)
+	st := queryConsensus()
+	assert.Equal(t, oldPr, st.GetFromKey(core.ProslKey).GetData())
+	assert.Equal(t, newPr, st.GetFromKey(core.PendingProslKey).GetData())
+	assert.Equal(t, int64(3), st.GetFromKey(core.ActivationHeightKey).GetI64())
+
+	// height 3 : Block の先頭で切り替わる
+	_, _, err = rp.CreateBlock(RandomQueue(), 0, RandomNow())
+	require.NoError(t, err)
+	st = queryConsensus()
+	assert.Equal(t, newPr, st.GetFromKey(core.ProslKey).GetData())
+	assert.Empty(t, st.GetFromKey(core.PendingProslKey).GetData())
+	assert.Equal(t, int64(0), st.GetFromKey(core.ActivationHeightKey).GetI64())
+
+	// genesis の prosl が version 0、切り替えた prosl が version 1 として履歴に残る
+	wsv, err := rp.TopWSV()
+	require.NoError(t, err)
+	histSt := fc.NewEmptyStorage()
+	require.NoError(t, wsv.Query(model.MustAddress(core.ProslHistoryId(consensusId.Id())), histSt))
+	require.NoError(t, core.CommitTx(wsv))
+	versions := histSt.GetFromKey(core.ProslVersionsKey).GetList()
+	require.Len(t, versions, 2)
+	for i, c := range []struct {
+		pr     []byte
+		height int64
+	}{
+		{oldPr, 0},
+		{newPr, 3},
+	} {
+		v := versions[i].GetDict()
+		assert.Equal(t, int64(i), v[core.ProslVersionKey].GetI64())
+		assert.Equal(t, c.pr, v[core.ProslKey].GetData())
+		assert.Equal(t, c.height, v[core.ActivationHeightKey].GetI64())
+		assert.NotEmpty(t, v[core.ProslHashKey].GetData())
+	}
+}
+
+func TestRepository_ActivatePendingProslWithoutUpgrade(t *testing.T) {
+	fc := RandomFactory()
+	conf := RandomConfig()
+	rp := NewRepository(RandomDBA(), RandomCryptor(), fc, conf)
+	require.NoError(t, rp.GenesisCommit(RandomGenesisTxList(t)))
+
+	consensusId := model.MustAddress(conf.Prosl.Consensus.Id)
+	newPr := ConvertYamlFileToProtoBinary(t, "../test_utils/new_consensus.yaml")
+
+	// height 1 : prosl_upgrade を無効にして次の Block で有効になる pending prosl を保存
+	CommitTxWrapBlock(t, rp, fc, fc.NewTxBuilder().
+		UpdateObject(conf.Root.Id, core.ActivationId, core.ProslUpgradeFeature, fc.NewObjectBuilder().Bool(false)).
+		UpdateObject(conf.Root.Id, consensusId.Id(), core.PendingProslKey, fc.NewObjectBuilder().Data(newPr)).
+		UpdateObject(conf.Root.Id, consensusId.Id(), core.ActivationHeightKey, fc.NewObjectBuilder().Int64(2)).
+		Build())
+
+	// height 2 : 切り替わるが履歴には残らない
+	_, _, err := rp.CreateBlock(RandomQueue(), 0, RandomNow())
+	require.NoError(t, err)
+	wsv, err := rp.TopWSV()
+	require.NoError(t, err)
+	st := fc.NewEmptyStorage()
+	require.NoError(t, wsv.Query(consensusId, st))
+	histSt := fc.NewEmptyStorage()
+	require.NoError(t, wsv.Query(model.MustAddress(core.ProslHistoryId(consensusId.Id())), histSt))
+	require.NoError(t, core.CommitTx(wsv))
+	assert.Equal(t, newPr, st.GetFromKey(core.ProslKey).GetData())
+	assert.Len(t, histSt.GetFromKey(core.ProslVersionsKey).GetList(), 1)
+}
+
+func TestRepository_ProslFallback(t *testing.T) {
+	fc := RandomFactory()
+	conf := RandomConfig()
+	rp := NewRepository(RandomDBA(), RandomCryptor(), fc, conf)
+	require.NoError(t, rp.GenesisCommit(RandomGenesisTxList(t)))
+
+	consensusId := model.MustAddress(conf.Prosl.Consensus.Id)
+	queryConsensus := func() model.Storage {
+		wsv, err := rp.TopWSV()
+		require.NoError(t, err)
+		defer core.CommitTx(wsv)
+		st := fc.NewEmptyStorage()
+		require.NoError(t, wsv.Query(consensusId, st))
+		return st
+	}
+	pr := RandomProsl()
+	require.NoError(t, pr.ConvertFromYaml([]byte("- return: 1")))
+	brokenPr, err := pr.Marshal()
+	require.NoError(t, err)
+
+	// height 1 : consensus prosl が account の list を返さなくなる
+	CommitTxWrapBlock(t, rp, fc, fc.NewTxBuilder().
+		UpdateObject(conf.Root.Id, consensusId.Id(), core.ProslKey, fc.NewObjectBuilder().Data(brokenPr)).
+		Build())
+
+	// 履歴の version 0 (genesis) で代替する
+	acs, err := rp.GetDelegatedAccounts()
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(acs))
+
+	// height 2 : 失敗が記録される
+	_, _, err = rp.CreateBlock(RandomQueue(), 0, RandomNow())
+	require.NoError(t, err)
+	st := queryConsensus()
+	assert.Equal(t, int64(1), st.GetFromKey(core.ProslFailureCountKey).GetI64())
+	assert.Equal(t, int64(2), st.GetFromKey(core.ProslFailureHeightKey).GetI64())
+	assert.Equal(t, int64(0), st.GetFromKey(core.ProslFallbackVersionKey).GetI64())
+	assert.Equal(t, core.ProslMetrics{Failures: 1, Fallbacks: 1}, rp.ProslMetrics(consensusId.Id()))
+
+	// height 3 : 代替できる version が無くなる
+	// 履歴は予約された Storage なので、書き込み権限の検証を無効にしてから消す
+	CommitTxWrapBlock(t, rp, fc, fc.NewTxBuilder().
+		UpdateObject(conf.Root.Id, core.ActivationId, core.PermissionFeature, fc.NewObjectBuilder().Bool(false)).
+		UpdateObject(conf.Root.Id, core.ProslHistoryId(consensusId.Id()), core.ProslVersionsKey,
+			fc.NewObjectBuilder().List([]model.Object{})).
+		Build())
+
+	// built-in default の Block 生成順
+	acs, err = rp.GetDelegatedAccounts()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(acs))
+	assert.Equal(t, "root@peer", acs[0].GetDelegatePeerId())
+
+	// height 4
+	_, _, err = rp.CreateBlock(RandomQueue(), 0, RandomNow())
+	require.NoError(t, err)
+	st = queryConsensus()
+	assert.Equal(t, int64(2), st.GetFromKey(core.ProslFailureCountKey).GetI64())
+	assert.Equal(t, int64(4), st.GetFromKey(core.ProslFailureHeightKey).GetI64())
+	assert.Equal(t, core.ProslFallbackDefault, st.GetFromKey(core.ProslFallbackVersionKey).GetI64())
+	assert.Equal(t, core.ProslMetrics{Failures: 2, Fallbacks: 1, Defaults: 1}, rp.ProslMetrics(consensusId.Id()))
+}
+
+func TestRepository_ProslFallbackNotActivated(t *testing.T) {
+	fc := RandomFactory()
+	conf := RandomConfig()
+	rp := NewRepository(RandomDBA(), RandomCryptor(), fc, conf)
+	require.NoError(t, rp.GenesisCommit(RandomGenesisTxList(t)))
+
+	consensusId := model.MustAddress(conf.Prosl.Consensus.Id)
+	pr := RandomProsl()
+	require.NoError(t, pr.ConvertFromYaml([]byte("- return: 1")))
+	brokenPr, err := pr.Marshal()
+	require.NoError(t, err)
+
+	// height 1 : 代替を有効化していない Chain で consensus prosl が account の list を返さなくなる
+	CommitTxWrapBlock(t, rp, fc, fc.NewTxBuilder().
+		UpdateObject(conf.Root.Id, core.ActivationId, core.ProslFallbackFeature, fc.NewObjectBuilder().Bool(false)).
+		UpdateObject(conf.Root.Id, consensusId.Id(), core.ProslKey, fc.NewObjectBuilder().Data(brokenPr)).
+		Build())
+
+	// 履歴の version で代替せずに built-in default の Block 生成順を使う
+	acs, err := rp.GetDelegatedAccounts()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(acs))
+	assert.Equal(t, "root@peer", acs[0].GetDelegatePeerId())
+
+	// height 2 : 失敗は記録されない
+	_, _, err = rp.CreateBlock(RandomQueue(), 0, RandomNow())
+	require.NoError(t, err)
+	wsv, err := rp.TopWSV()
+	require.NoError(t, err)
+	st := fc.NewEmptyStorage()
+	require.NoError(t, wsv.Query(consensusId, st))
+	require.NoError(t, core.CommitTx(wsv))
+	assert.Equal(t, int64(0), st.GetFromKey(core.ProslFailureCountKey).GetI64())
+	assert.Equal(t, int64(0), st.GetFromKey(core.ProslFailureHeightKey).GetI64())
+	assert.Equal(t, core.ProslMetrics{Failures: 1, Defaults: 1}, rp.ProslMetrics(consensusId.Id()))
+}
+
+func TestRepository_GetDelegatedAccounts_Normalize(t *testing.T) {
+	fc := RandomFactory()
+	conf := RandomConfig()
+	rp := NewRepository(RandomDBA(), RandomCryptor(), fc, conf)
+	require.NoError(t, rp.GenesisCommit(RandomGenesisTxList(t)))
+
+	// 不正な Peer に委任している account は除かれる
+	CommitTxWrapBlock(t, rp, fc, fc.NewTxBuilder().
+		AddPeer(conf.Root.Id, "inactive@peer", "127.0.0.1:50056", RandomPublicKey()).
+		AddPeer(conf.Root.Id, "banned@peer", "127.0.0.1:50057", RandomPublicKey()).
+		ActivatePeer(conf.Root.Id, "banned@peer").
+		BanPeer(conf.Root.Id, "banned@peer").
+		CreateAccount(conf.Root.Id, "inactive@com", []model.PublicKey{}, 0).
+		CreateAccount(conf.Root.Id, "banned@com", []model.PublicKey{}, 0).
+		CreateAccount(conf.Root.Id, "none@com", []model.PublicKey{}, 0).
+		Consign(conf.Root.Id, "inactive@com", "inactive@peer").
+		Consign(conf.Root.Id, "banned@com", "banned@peer").
+		AddBalance(conf.Root.Id, "inactive@com", 100).
+		AddBalance(conf.Root.Id, "banned@com", 100).
+		AddBalance(conf.Root.Id, "none@com", 100).
+		Build())
+
+	acs, err := rp.GetDelegatedAccounts()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(acs))
+	for _, ac := range acs {
+		assert.Equal(t, "root@peer", ac.GetDelegatePeerId())
+	}
+
+	// Chain 上の min_size に満たなければ失敗として built-in default の順になる
+	CommitTxWrapBlock(t, rp, fc, fc.NewTxBuilder().
+		AddBalance(conf.Root.Id, "none@com", 100).
+		UpdateObject(conf.Root.Id, conf.Prosl.Consensus.Id, core.ConsensusMinSizeKey, fc.NewObjectBuilder().Int64(3)).
+		Build())
+	acs, err = rp.GetDelegatedAccounts()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(acs))
+	assert.Equal(t, "root@peer", acs[0].GetDelegatePeerId())
+
+	_, _, err = rp.CreateBlock(RandomQueue(), 0, RandomNow())
+	require.NoError(t, err)
+	assert.Equal(t, core.ProslMetrics{Failures: 1, Defaults: 1}, rp.ProslMetrics(conf.Prosl.Consensus.Id))
+}
@@ -29,9 +29,10 @@ func (t *TxIndexed) Unmarshal(b []byte) error {
 }
 
 var (
-	TxHistoryRootKey       byte = 1
-	TxHistoryTxRootKey     byte = 0
-	TxHistoryTxListRootKey byte = 1
+	TxHistoryRootKey        byte = 1
+	TxHistoryTxRootKey      byte = 0
+	TxHistoryTxListRootKey  byte = 1
+	TxHistoryReceiptRootKey byte = 2
 )
 
 func NewTxHistory(tx core.DBATx, factory model.ModelFactory, cryptor core.Cryptor, rootHash model.Hash) (core.TxHistory, error) {
@@ -54,6 +55,10 @@ func TxListHashToKey(txHash model.Hash) []byte {
 	return append([]byte{TxHistoryRootKey, TxHistoryTxListRootKey}, txHash...)
 }
 
+func ReceiptTxHashToKey(txHash model.Hash) []byte {
+	return append([]byte{TxHistoryRootKey, TxHistoryReceiptRootKey}, txHash...)
+}
+
 // GetTxList gets txList from txHash
 func (w *TxHistory) GetTxList(txListHash model.Hash) (core.TxList, error) {
 	txListKey := TxListHashToKey(txListHash)
@@ -91,7 +96,7 @@ func (w *TxHistory) GetTx(txHash model.Hash) (model.Transaction, error) {
 	if err != nil {
 		return nil, err
 	}
-	if len(txList.List()) >= retTxIndexed.Index {
+	if retTxIndexed.Index < 0 || len(txList.List()) <= retTxIndexed.Index {
 		return nil,
 			fmt.Errorf("Failed GetTx index out of range. len is %d, but index %d.", len(txList.List()), retTxIndexed.Index)
 	}
@@ -113,6 +118,32 @@ func (w *TxHistory) Append(txList core.TxList) error {
 	return nil
 }
 
+// GetReceipt gets receipt from txHash
+func (w *TxHistory) GetReceipt(txHash model.Hash) (model.Receipt, error) {
+	it, err := w.tree.Find(ReceiptTxHashToKey(txHash))
+	if err != nil {
+		if errors.Cause(err) == core.ErrMerklePatriciaTreeNotFoundKey {
+			return nil, errors.Wrap(core.ErrTxHistoryReceiptNotFound, err.Error())
+		}
+		return nil, err
+	}
+	retReceipt := w.factory.NewEmptyReceipt()
+	if err = it.Data(retReceipt); err != nil {
+		return nil, errors.Wrap(core.ErrTxHistoryQueryUnmarshal, err.Error())
+	}
+	return retReceipt, nil
+}
+
+// AppendReceipts appends receipts of txs
+func (w *TxHistory) AppendReceipts(receipts core.ReceiptList) error {
+	for _, receipt := range receipts.List() {
+		if _, err := w.tree.Upsert(&KVNode{ReceiptTxHashToKey(receipt.GetTxHash()), receipt}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Commit appenging nodes
 func (w *TxHistory) Commit() error {
 	if err := w.tx.Commit(); err != nil {
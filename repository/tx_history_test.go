@@ -21,6 +21,12 @@ func test_TxHistory_Upserts(t *testing.T, txHistory core.TxHistory, txList core.
 	retTxList, err := txHistory.GetTxList(hash)
 	require.NoError(t, err)
 	assert.Equal(t, txList.Hash(), retTxList.Hash())
+
+	for _, tx := range txList.List() {
+		retTx, err := txHistory.GetTx(tx.Hash())
+		require.NoError(t, err)
+		assert.Equal(t, tx.Hash(), retTx.Hash())
+	}
 }
 
 func test_TxHistory(t *testing.T, dba core.DBA, TxHistory core.TxHistory) {
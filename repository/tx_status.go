@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"github.com/proskenion/proskenion/config"
+	"github.com/proskenion/proskenion/core"
+	"github.com/proskenion/proskenion/core/model"
+	"sync"
+)
+
+// txStatusStore はこの Peer で観測した Transaction の status を保持する
+type txStatusStore struct {
+	mutex    *sync.Mutex
+	statuses map[string]core.TxStatus
+
+	expire    int64
+	retention int64
+}
+
+func newTxStatusStore(conf *config.Config) *txStatusStore {
+	return &txStatusStore{&sync.Mutex{}, make(map[string]core.TxStatus),
+		conf.TxStatus.ExpireBlocks, conf.TxStatus.RetentionBlocks}
+}
+
+func (s *txStatusStore) get(txHash model.Hash) (core.TxStatus, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	ret, ok := s.statuses[string(txHash)]
+	return ret, ok
+}
+
+// set は status を更新する。確定した status を Queued に戻さず、Committed を他の status で上書きしない
+func (s *txStatusStore) set(txHash model.Hash, status core.TxStatus) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if old, ok := s.statuses[string(txHash)]; ok {
+		if old.Code == core.TxStatusCommitted && status.Code != core.TxStatusCommitted {
+			return
+		}
+		if old.Final() && !status.Final() {
+			return
+		}
+	}
+	s.statuses[string(txHash)] = status
+}
+
+// update は height の Block の Commit 時に、expire を過ぎた Queued を Expired にし、retention を過ぎた確定済みの status を削除する
+func (s *txStatusStore) update(height int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for key, status := range s.statuses {
+		if !status.Final() {
+			if s.expire > 0 && height-status.Height >= s.expire {
+				s.statuses[key] = core.TxStatus{Code: core.TxStatusExpired, Height: height}
+			}
+			continue
+		}
+		if s.retention > 0 && height-status.Height > s.retention {
+			delete(s.statuses, key)
+		}
+	}
+}
+
+// TxStatus は この Peer で観測した Transaction の status を返す
+func (r *Repository) TxStatus(txHash model.Hash) (core.TxStatus, bool) {
+	return r.txStatus.get(txHash)
+}
+
+// SetTxQueued は Transaction を ProposalTxQueue に入れたことを記録する
+func (r *Repository) SetTxQueued(txHash model.Hash) {
+	r.txStatus.set(txHash, core.TxStatus{Code: core.TxStatusQueued, Height: r.Height})
+}
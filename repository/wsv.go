@@ -9,10 +9,15 @@ import (
 )
 
 type WSV struct {
-	tx   core.DBATx
-	tree core.MerklePatriciaTree
-	fc   model.ObjectFactory
-	ps   core.PeerService
+	tx      core.DBATx
+	cryptor core.Cryptor
+	tree    core.MerklePatriciaTree
+	fc      model.ObjectFactory
+	ps      core.PeerService
+
+	events []model.Event
+	txHash model.Hash
+	height int64
 }
 
 var WsvRootKey byte = 0
@@ -23,10 +28,11 @@ func NewWSV(tx core.DBATx, cryptor core.Cryptor, fc model.ObjectFactory, rootHas
 		return nil, err
 	}
 	return &WSV{
-		tx:   tx,
-		tree: tree,
-		fc:   fc,
-		ps:   NewPeerService(cryptor),
+		tx:      tx,
+		cryptor: cryptor,
+		tree:    tree,
+		fc:      fc,
+		ps:      NewPeerService(cryptor),
 	}, nil
 }
 
@@ -76,6 +82,88 @@ func (w *WSV) QueryAll(fromId model.Address, ufc model.UnmarshalerFactory) ([]mo
 	return rets, nil
 }
 
+// QueryPage は fromId 以下の値を key 順に lastKey の次から limit 個取得する
+// lastKey は WsvRootKey を除いた MerklePatriciaTree の key で、返り値の key も同じ形式である
+func (w *WSV) QueryPage(fromId model.Address, lastKey []byte, order model.OrderCode, limit int, ufc model.UnmarshalerFactory) ([]model.Unmarshaler, []byte, error) {
+	prefix := makeWSVId(fromId)
+	if _, err := w.tree.Search(prefix); err != nil {
+		if errors.Cause(err) == core.ErrMerklePatriciaTreeNotSearchKey {
+			return nil, nil, errors.Wrapf(core.ErrWSVNotFound, "fromId: %s, err: %s", fromId.Id(), err.Error())
+		}
+		return nil, nil, err
+	}
+	var from []byte
+	if lastKey != nil {
+		from = append([]byte{WsvRootKey}, lastKey...)
+	}
+	leafs, last, err := w.tree.SubLeafsFrom(prefix, from, limit, order == model.DESC)
+	if err != nil {
+		return nil, nil, err
+	}
+	rets := make([]model.Unmarshaler, 0, len(leafs))
+	for _, leaf := range leafs {
+		unm := ufc.CreateUnmarshaler()
+		if err = leaf.Data(unm); err != nil {
+			return nil, nil, errors.Wrap(core.ErrWSVQueryUnmarshal, err.Error())
+		}
+		rets = append(rets, unm)
+	}
+	if last != nil {
+		last = last[1:]
+	}
+	return rets, last, nil
+}
+
+// QueryChanges は fromId 以下の値のうち、preHash を root とする WSV から追加・更新された値を取得する
+// fromId 以下の node の hash が変わっていなければ leaf を辿らない
+func (w *WSV) QueryChanges(fromId model.Address, preHash model.Hash, ufc model.UnmarshalerFactory) ([]model.Unmarshaler, error) {
+	prefix := makeWSVId(fromId)
+	it, err := w.tree.Search(prefix)
+	if err != nil {
+		if errors.Cause(err) == core.ErrMerklePatriciaTreeNotSearchKey {
+			return nil, nil
+		}
+		return nil, err
+	}
+	preTree, err := datastructure.NewMerklePatriciaTree(w.tx, w.cryptor, preHash, WsvRootKey)
+	if err != nil {
+		return nil, err
+	}
+	preLeafs := make(map[string]struct{})
+	preIt, err := preTree.Search(prefix)
+	if err == nil {
+		if bytes.Equal(preIt.Hash(), it.Hash()) {
+			return nil, nil
+		}
+		leafs, err := preIt.SubLeafs()
+		if err != nil {
+			return nil, err
+		}
+		for _, leaf := range leafs {
+			preLeafs[string(leaf.Hash())] = struct{}{}
+		}
+	} else if errors.Cause(err) != core.ErrMerklePatriciaTreeNotSearchKey {
+		return nil, err
+	}
+
+	leafs, err := it.SubLeafs()
+	if err != nil {
+		return nil, err
+	}
+	rets := make([]model.Unmarshaler, 0)
+	for _, leaf := range leafs {
+		if _, ok := preLeafs[string(leaf.Hash())]; ok {
+			continue
+		}
+		unm := ufc.CreateUnmarshaler()
+		if err = leaf.Data(unm); err != nil {
+			return nil, errors.Wrap(core.ErrWSVQueryUnmarshal, err.Error())
+		}
+		rets = append(rets, unm)
+	}
+	return rets, nil
+}
+
 // PeerService gets value from targetId
 func (w *WSV) PeerService() (core.PeerService, error) {
 	peerRoot, err := w.tree.Search(makeWSVId(model.MustAddress("/" + model.PeerStorageName)))
@@ -132,6 +220,48 @@ func (w *WSV) Append(targetId model.Address, value model.Marshaler) error {
 	return err
 }
 
+// Revert discards values appended after the state of hash
+func (w *WSV) Revert(hash model.Hash) error {
+	tree, err := datastructure.NewMerklePatriciaTree(w.tx, w.cryptor, hash, WsvRootKey)
+	if err != nil {
+		return err
+	}
+	w.tree = tree
+	return nil
+}
+
+// Emit stacks event of executing transaction
+func (w *WSV) Emit(event model.Event) {
+	w.events = append(w.events, event)
+}
+
+// PopEvents gets emitted events and clears them
+func (w *WSV) PopEvents() []model.Event {
+	ret := w.events
+	w.events = nil
+	return ret
+}
+
+// ExecutingTxHash gets hash of executing transaction
+func (w *WSV) ExecutingTxHash() model.Hash {
+	return w.txHash
+}
+
+// SetExecutingTxHash sets hash of executing transaction
+func (w *WSV) SetExecutingTxHash(txHash model.Hash) {
+	w.txHash = txHash
+}
+
+// ExecutingHeight gets height of executing block
+func (w *WSV) ExecutingHeight() int64 {
+	return w.height
+}
+
+// SetExecutingHeight sets height of executing block
+func (w *WSV) SetExecutingHeight(height int64) {
+	w.height = height
+}
+
 // Commit appenging nodes
 func (w *WSV) Commit() error {
 	if err := w.tx.Commit(); err != nil {
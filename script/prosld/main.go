@@ -0,0 +1,130 @@
+// prosld は Prosl の protobuf binary を yaml に逆変換する。
+// -i でローカルの binary (hex 文字列でも可) を、それ以外はピアから現在有効な Prosl を取得して変換する。
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/jessevdk/go-flags"
+	"github.com/proskenion/proskenion/client"
+	"github.com/proskenion/proskenion/config"
+	"github.com/proskenion/proskenion/convertor"
+	"github.com/proskenion/proskenion/core"
+	"github.com/proskenion/proskenion/core/model"
+	"github.com/proskenion/proskenion/crypto"
+	"github.com/proskenion/proskenion/prosl"
+	"github.com/proskenion/proskenion/query"
+	"io/ioutil"
+	"log"
+	"strings"
+	"time"
+)
+
+var opts struct {
+	Config     string `short:"c" long:"config" description:"A config file" value-name:"FILE" default:"config/config.yaml"`
+	Type       string `short:"t" long:"type" description:"Prosl type (incentive|consensus|update)" value-name:"TYPE" default:"incentive"`
+	Authorizer string `short:"a" long:"authorizer" description:"Query authorizer account id" value-name:"ID"`
+	Key        string `short:"k" long:"key" description:"Authorizer key file prefix made by keygen (FILE.pub, FILE.pri)" value-name:"FILE"`
+	Input      string `short:"i" long:"input" description:"Decompile a local prosl protobuf binary (raw or hex) instead of querying" value-name:"FILE"`
+	Output     string `short:"o" long:"output" description:"Save yaml to file" value-name:"FILE" default-mask:"-"`
+}
+
+func decodeHex(data []byte) ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(data)), "0x"))
+}
+
+func readHexFile(filename string) []byte {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ret, err := decodeHex(data)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return ret
+}
+
+// readProslFile は prosl の binary を読み込む。
+// key file と同じ hex 文字列 (0x は省略可) ならば decode し、そうでなければ raw binary として扱う
+func readProslFile(filename string) []byte {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if ret, err := decodeHex(data); err == nil {
+		return ret
+	}
+	return data
+}
+
+func proslId(conf *config.Config, pType string) string {
+	switch pType {
+	case core.IncentiveKey:
+		return conf.Prosl.Incentive.Id
+	case core.ConsensusKey:
+		return conf.Prosl.Consensus.Id
+	case core.UpdateKey:
+		return conf.Prosl.Update.Id
+	}
+	log.Fatalf("unknown prosl type: %s", pType)
+	return ""
+}
+
+func fetchProsl(fc model.ModelFactory, conf *config.Config) []byte {
+	if opts.Authorizer == "" || opts.Key == "" {
+		log.Fatal("authorizer and key are required to query prosl")
+	}
+	c, err := client.NewAPIClient(config.NewPeerFromConf(fc, conf.Peer), fc)
+	if err != nil {
+		log.Fatal(err)
+	}
+	q := fc.NewQueryBuilder().
+		AuthorizerId(opts.Authorizer).
+		FromId(proslId(conf, opts.Type)).
+		CreatedTime(time.Now().UnixNano()).
+		RequestCode(model.StorageObjectCode).
+		Build()
+	if err := q.Sign(readHexFile(opts.Key+".pub"), readHexFile(opts.Key+".pri")); err != nil {
+		log.Fatal(err)
+	}
+	res, err := c.Read(q)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return res.GetObject().GetStorage().GetFromKey(core.ProslKey).GetData()
+}
+
+func main() {
+	_, err := flags.Parse(&opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cryptor := crypto.NewEd25519Sha256Cryptor()
+	fc := convertor.NewModelFactory(cryptor, nil, nil, query.NewQueryVerifier())
+	conf := config.NewConfig(opts.Config)
+
+	var binary []byte
+	if opts.Input != "" {
+		binary = readProslFile(opts.Input)
+	} else {
+		binary = fetchProsl(fc, conf)
+	}
+
+	pr := prosl.NewProsl(fc, cryptor, conf)
+	if err := pr.Unmarshal(binary); err != nil {
+		log.Fatal(err)
+	}
+	yaml, err := pr.ConvertToYaml()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if opts.Output == "" {
+		fmt.Print(string(yaml))
+	} else {
+		if err := ioutil.WriteFile(opts.Output, yaml, 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
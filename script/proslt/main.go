@@ -0,0 +1,54 @@
+// proslt は yaml で記述した Prosl のテストを実行する。
+// fixture の書式は prosl/tester を参照。
+package main
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/jessevdk/go-flags"
+	"github.com/proskenion/proskenion/config"
+	"github.com/proskenion/proskenion/convertor"
+	"github.com/proskenion/proskenion/crypto"
+	"github.com/proskenion/proskenion/prosl/tester"
+	"github.com/proskenion/proskenion/query"
+	"log"
+	"os"
+)
+
+var opts struct {
+	Config string `short:"c" long:"config" description:"A config file" value-name:"FILE" default:"config/config.yaml"`
+}
+
+func main() {
+	files, err := flags.Parse(&opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(files) == 0 {
+		log.Fatal("usage: proslt [-c config.yaml] fixture.yaml...")
+	}
+	cryptor := crypto.NewEd25519Sha256Cryptor()
+	fc := convertor.NewModelFactory(cryptor, nil, nil, query.NewQueryVerifier())
+	pt := tester.NewProslTester(fc, cryptor, config.NewConfig(opts.Config))
+
+	failed := 0
+	for _, file := range files {
+		results, err := pt.RunFile(file)
+		if err != nil {
+			fmt.Println(color.RedString("ERROR %s: %s", file, err.Error()))
+			failed++
+			continue
+		}
+		for _, res := range results {
+			if res.Passed() {
+				fmt.Println(color.GreenString("PASS  %s: %s", file, res.Name))
+			} else {
+				fmt.Println(color.RedString("FAIL  %s: %s\n\t%s", file, res.Name, res.Err.Error()))
+				failed++
+			}
+		}
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -35,6 +35,14 @@ func GenesisCommitFromAccounts(t *testing.T, rp core.Repository, acs []*AccountW
 	}
 	tx := builder.Build()
 	require.NoError(t, txList.Push(tx))
+
+	// 各 Account は自身の Domain に Account を作成できる
+	grant := RandomFactory().NewTxBuilder()
+	for _, ac := range acs {
+		grant = grant.GrantPermission("root@com", ac.AccountId,
+			core.CreateAccountPermission, model.MustAddress(ac.AccountId).Domain())
+	}
+	require.NoError(t, txList.Push(grant.Build()))
 	require.NoError(t, rp.GenesisCommit(txList))
 }
 
@@ -10,6 +10,7 @@ import (
 	"github.com/proskenion/proskenion/core/model"
 	"github.com/stretchr/testify/assert"
 	"reflect"
+	"time"
 )
 
 type SenderManager struct {
@@ -82,6 +83,15 @@ func NewSenderManager(authorizer *AccountWithPri, server model.Peer, fc model.Mo
 	}
 }
 
+// ExampleWriteTimeout は Transaction が Commit されるまで待つ時間
+const ExampleWriteTimeout = 10 * time.Second
+
+// writeAndWait は tx を送信し、Commit されるまで待つ
+func (am *SenderManager) writeAndWait(tx model.Transaction) {
+	_, err := am.Client.WriteAndWait(tx, ExampleWriteTimeout)
+	RequireNoError(err)
+}
+
 func (am *SenderManager) SetAuthorizer() {
 	tx := am.fc.NewTxBuilder().
 		AddPublicKeys(am.Authorizer.AccountId, am.Authorizer.AccountId, []model.PublicKey{am.Authorizer.Pubkey}).
@@ -89,7 +99,8 @@ func (am *SenderManager) SetAuthorizer() {
 		Build()
 	fmt.Println(color.CyanString("SetAuthorizer: %+v", tx))
 	RequireNoError(tx.Sign(am.Authorizer.Pubkey, am.Authorizer.Prikey))
-	RequireNoError(am.Client.Write(tx))
+	// 以降の Transaction の署名を検証できるように Commit されるまで待つ
+	am.writeAndWait(tx)
 }
 
 func (am *SenderManager) CreateAccount(ac *AccountWithPri) {
@@ -99,7 +110,7 @@ func (am *SenderManager) CreateAccount(ac *AccountWithPri) {
 		Build()
 	fmt.Println(color.CyanString("CreateAccount: %+v", tx))
 	RequireNoError(tx.Sign(am.Authorizer.Pubkey, am.Authorizer.Prikey))
-	RequireNoError(am.Client.Write(tx))
+	am.writeAndWait(tx)
 }
 
 func (am *SenderManager) AddPeer(peer model.Peer) {
@@ -108,7 +119,7 @@ func (am *SenderManager) AddPeer(peer model.Peer) {
 		Build()
 	fmt.Println(color.CyanString("AddPeer: %+v", tx))
 	RequireNoError(tx.Sign(am.Authorizer.Pubkey, am.Authorizer.Prikey))
-	RequireNoError(am.Client.Write(tx))
+	am.writeAndWait(tx)
 }
 
 func (am *SenderManager) Consign(ac *AccountWithPri, peer model.Peer) {
@@ -117,7 +128,7 @@ func (am *SenderManager) Consign(ac *AccountWithPri, peer model.Peer) {
 		Build()
 	fmt.Println(color.CyanString("Consign: %+v", tx))
 	RequireNoError(tx.Sign(am.Authorizer.Pubkey, am.Authorizer.Prikey))
-	RequireNoError(am.Client.Write(tx))
+	am.writeAndWait(tx)
 }
 
 const (
@@ -154,7 +165,7 @@ func (am *SenderManager) AddEdge(ac *AccountWithPri, to *AccountWithPri) {
 		AddObject(am.Authorizer.AccountId, fmt.Sprintf("%s/%s", ac.AccountId, FollowStorage), FollowEdge, obj).
 		Build()
 	RequireNoError(tx.Sign(am.Authorizer.Pubkey, am.Authorizer.Prikey))
-	RequireNoError(am.Client.Write(tx))
+	am.writeAndWait(tx)
 }
 
 func (am *SenderManager) CreateEdgeStorage(ac *AccountWithPri) {
@@ -163,7 +174,7 @@ func (am *SenderManager) CreateEdgeStorage(ac *AccountWithPri) {
 		Build()
 	fmt.Println(color.CyanString("CreateEdgeStorage: %+v", tx))
 	RequireNoError(tx.Sign(am.Authorizer.Pubkey, am.Authorizer.Prikey))
-	RequireNoError(am.Client.Write(tx))
+	am.writeAndWait(tx)
 }
 
 func (am *SenderManager) ProposeNewAlgorithm(pType string, prosl []byte) { //consensus []byte, incentive []byte) {
@@ -185,7 +196,7 @@ func (am *SenderManager) ProposeNewAlgorithm(pType string, prosl []byte) { //con
 		Build()
 	fmt.Println(color.CyanString("ProposeNewAlgorithm: %+v", tx))
 	RequireNoError(tx.Sign(am.Authorizer.Pubkey, am.Authorizer.Prikey))
-	RequireNoError(am.Client.Write(tx))
+	am.writeAndWait(tx)
 }
 
 func (am *SenderManager) CreateProslSignStorage() {
@@ -195,7 +206,7 @@ func (am *SenderManager) CreateProslSignStorage() {
 		Build()
 	fmt.Println(color.CyanString("CreateProslSignStorage: %+v", tx))
 	RequireNoError(tx.Sign(am.Authorizer.Pubkey, am.Authorizer.Prikey))
-	RequireNoError(am.Client.Write(tx))
+	am.writeAndWait(tx)
 }
 
 func (am *SenderManager) VoteNewConsensus(dest *AccountWithPri, key string, prosl model.Object) {
@@ -221,7 +232,7 @@ func (am *SenderManager) VoteNewConsensus(dest *AccountWithPri, key string, pros
 		Build()
 	fmt.Println(color.CyanString("VoteNewConsensus: %+v", tx))
 	RequireNoError(tx.Sign(am.Authorizer.Pubkey, am.Authorizer.Prikey))
-	RequireNoError(am.Client.Write(tx))
+	am.writeAndWait(tx)
 }
 
 func (am *SenderManager) CheckAndCommit() {
@@ -239,7 +250,7 @@ func (am *SenderManager) CheckAndCommit() {
 		Build()
 	fmt.Println(color.CyanString("CheckAndCommit: %+v", tx))
 	RequireNoError(tx.Sign(am.Authorizer.Pubkey, am.Authorizer.Prikey))
-	RequireNoError(am.Client.Write(tx))
+	am.writeAndWait(tx)
 }
 
 func (am *SenderManager) CheckAndCommitInc() {
@@ -252,7 +263,7 @@ func (am *SenderManager) CheckAndCommitInc() {
 		Build()
 	fmt.Println(color.CyanString("CheckAndCommitInc: %+v", tx))
 	RequireNoError(tx.Sign(am.Authorizer.Pubkey, am.Authorizer.Prikey))
-	RequireNoError(am.Client.Write(tx))
+	am.writeAndWait(tx)
 }
 
 func (am *SenderManager) QueryAccountPassed(ac *AccountWithPri) {
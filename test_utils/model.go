@@ -38,8 +38,8 @@ func NewTestFactories() (model.ModelFactory,
 	)
 	rp := repository.NewRepository(RandomDBA(), c, fc, cf)
 	pr := prosl.NewProsl(fc, c, cf)
-	ex.SetField(fc, pr)
-	vl.SetField(fc, pr)
+	ex.SetField(fc, c)
+	vl.SetField(fc, c)
 	return fc, ex, vl, c, rp, pr, cf
 }
 
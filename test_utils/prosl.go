@@ -44,6 +44,9 @@ func CommitTxWrapBlock(t *testing.T, rp core.Repository, fc model.ModelFactory,
 	}
 	err = txHistory.Append(txList)
 	require.NoError(t, err)
+	receipts := repository.NewReceiptList(RandomCryptor())
+	require.NoError(t, receipts.Push(fc.NewReceipt(tx.Hash(), wsv.PopEvents())))
+	require.NoError(t, txHistory.AppendReceipts(receipts))
 
 	// hash check and block 生成
 	wsvHash := wsv.Hash()
@@ -53,6 +56,7 @@ func CommitTxWrapBlock(t *testing.T, rp core.Repository, fc model.ModelFactory,
 	block := fc.NewBlockBuilder().
 		CreatedTime(RandomNow()).
 		TxListHash(txList.Hash()).
+		ReceiptsHash(receipts.Hash()).
 		PreBlockHash(topHash).
 		TxHistoryHash(txHistoryHash).
 		WSVHash(wsvHash).
@@ -14,7 +14,6 @@ import (
 	"github.com/proskenion/proskenion/crypto"
 	"github.com/proskenion/proskenion/gate"
 	"github.com/proskenion/proskenion/p2p"
-	"github.com/proskenion/proskenion/prosl"
 	"github.com/proskenion/proskenion/proto"
 	"github.com/proskenion/proskenion/query"
 	"github.com/proskenion/proskenion/repository"
@@ -39,10 +38,8 @@ func RandomSetUpConsensusServer(t *testing.T, conf *config.Config, s *grpc.Serve
 	blockQueue := repository.NewProposalBlockQueueOnMemory(conf)
 	txListCache := repository.NewTxListCache(conf)
 
-	pr := prosl.NewProsl(fc, cryptor, conf)
-
-	cmdExecutor.SetField(fc, pr)
-	cmdValidator.SetField(fc, pr)
+	cmdExecutor.SetField(fc, cryptor)
+	cmdValidator.SetField(fc, cryptor)
 
 	// ==================== gate =======================
 	logger.Info("================= Consensus Gate Boot =================")
@@ -73,10 +70,8 @@ func RandomSetUpSyncServer(t *testing.T, conf *config.Config, rp core.Repository
 
 	qTx := repository.NewProposalTxQueueOnMemory(conf)
 
-	pr := prosl.NewProsl(fc, cryptor, conf)
-
-	cmdExecutor.SetField(fc, pr)
-	cmdValidator.SetField(fc, pr)
+	cmdExecutor.SetField(fc, cryptor)
+	cmdValidator.SetField(fc, cryptor)
 
 	// ==================== gate =======================
 	logger.Info("================= Sync And API Gate Boot =================")